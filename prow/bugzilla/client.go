@@ -18,6 +18,7 @@ package bugzilla
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -39,7 +40,12 @@ type Client interface {
 	GetBug(id int) (*Bug, error)
 	GetExternalBugPRsOnBug(id int) ([]ExternalBug, error)
 	UpdateBug(id int, update BugUpdate) error
+	CreateBug(bug *BugCreate) (int, error)
+	CreateBugComment(id int, comment string) (int, error)
+	ForEndpoint(endpoint string) Client
+	WithContext(ctx context.Context) Client
 	AddPullRequestAsExternalBug(id int, org, repo string, num int) (bool, error)
+	RemoveExternalBugFromBug(id int, org, repo string, num int) (bool, error)
 }
 
 func NewClient(getAPIKey func() []byte, endpoint string) Client {
@@ -56,6 +62,8 @@ type client struct {
 	client    *http.Client
 	endpoint  string
 	getAPIKey func() []byte
+	// ctx, if set, bounds every request made by the client
+	ctx context.Context
 }
 
 // the client is a Client impl
@@ -158,6 +166,113 @@ func (c *client) UpdateBug(id int, update BugUpdate) error {
 	return err
 }
 
+// ForEndpoint returns an anonymous client for another Bugzilla server. The API
+// key of this client is not sent to the other server.
+func (c *client) ForEndpoint(endpoint string) Client {
+	return &client{
+		logger:   c.logger.WithField("endpoint", endpoint),
+		client:   c.client,
+		endpoint: endpoint,
+		getAPIKey: func() []byte {
+			return []byte{}
+		},
+		ctx: c.ctx,
+	}
+}
+
+// WithContext returns a client for the same server that issues every
+// request with the given context, so that a caller can cancel them or
+// bound them with a deadline
+func (c *client) WithContext(ctx context.Context) Client {
+	return &client{
+		logger:    c.logger,
+		client:    c.client,
+		endpoint:  c.endpoint,
+		getAPIKey: c.getAPIKey,
+		ctx:       ctx,
+	}
+}
+
+// CreateBug files a new bug on the server and returns its ID
+// https://bugzilla.readthedocs.io/en/latest/api/core/v1/bug.html#create-bug
+func (c *client) CreateBug(bug *BugCreate) (int, error) {
+	logger := c.logger.WithFields(logrus.Fields{methodField: "CreateBug", "bug": bug})
+	body, err := json.Marshal(bug)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal create payload: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/rest/bug", c.endpoint), bytes.NewBuffer(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	raw, err := c.request(req, logger)
+	if err != nil {
+		return 0, err
+	}
+	var parsedResponse struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &parsedResponse); err != nil {
+		return 0, fmt.Errorf("could not unmarshal response body: %v", err)
+	}
+	return parsedResponse.ID, nil
+}
+
+// CreateBugComment adds a public comment to the bug and returns the ID of the comment
+// https://bugzilla.readthedocs.io/en/latest/api/core/v1/comment.html#create-comments
+func (c *client) CreateBugComment(id int, comment string) (int, error) {
+	logger := c.logger.WithFields(logrus.Fields{methodField: "CreateBugComment", "id": id})
+	body, err := json.Marshal(struct {
+		Comment string `json:"comment"`
+	}{Comment: comment})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal comment payload: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/rest/bug/%d/comment", c.endpoint, id), bytes.NewBuffer(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	raw, err := c.request(req, logger)
+	if err != nil {
+		return 0, err
+	}
+	var parsedResponse struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &parsedResponse); err != nil {
+		return 0, fmt.Errorf("could not unmarshal response body: %v", err)
+	}
+	return parsedResponse.ID, nil
+}
+
+// CloneBugStruct determines the fields used to file a clone of the bug. The
+// clone depends on the original bug so that it tracks the original fix.
+func CloneBugStruct(bug *Bug) *BugCreate {
+	return &BugCreate{
+		Product:         bug.Product,
+		Component:       bug.Component,
+		Summary:         bug.Summary,
+		Version:         bug.Version,
+		Description:     fmt.Sprintf("This is a clone of Bug %d: %s", bug.ID, bug.Summary),
+		OperatingSystem: bug.OperatingSystem,
+		Platform:        bug.Platform,
+		Priority:        bug.Priority,
+		Severity:        bug.Severity,
+		TargetRelease:   bug.TargetRelease,
+		AssignedTo:      bug.AssignedTo,
+		QAContact:       bug.QAContact,
+		CC:              bug.CC,
+		Keywords:        bug.Keywords,
+		Groups:          bug.Groups,
+		DependsOn:       []int{bug.ID},
+		Blocks:          bug.Blocks,
+	}
+}
+
 func (c *client) request(req *http.Request, logger *logrus.Entry) ([]byte, error) {
 	if apiKey := c.getAPIKey(); len(apiKey) > 0 {
 		// some BugZilla servers are too old and can't handle the header.
@@ -168,18 +283,19 @@ func (c *client) request(req *http.Request, logger *logrus.Entry) ([]byte, error
 		values.Add("api_key", string(apiKey))
 		req.URL.RawQuery = values.Encode()
 	}
+	if c.ctx != nil {
+		req = req.WithContext(c.ctx)
+	}
 	start := time.Now()
 	resp, err := c.client.Do(req)
 	stop := time.Now()
-	requestDurations.With(prometheus.Labels{methodField: logger.Data[methodField].(string), "status": strconv.Itoa(resp.StatusCode)}).Observe(float64(stop.Sub(start).Seconds()))
+	code := -1
 	if resp != nil {
+		code = resp.StatusCode
 		logger.WithField("response", resp.StatusCode).Debug("Got response from Bugzilla.")
 	}
+	requestDurations.With(prometheus.Labels{methodField: logger.Data[methodField].(string), "status": strconv.Itoa(code)}).Observe(float64(stop.Sub(start).Seconds()))
 	if err != nil {
-		code := -1
-		if resp != nil {
-			code = resp.StatusCode
-		}
 		return nil, &requestError{statusCode: code, message: err.Error()}
 	}
 	defer func() {
@@ -214,6 +330,27 @@ func IsNotFound(err error) bool {
 	return reqError.statusCode == http.StatusNotFound
 }
 
+// IsAccessDenied determines if the error is due to the client not being
+// authorized to access the resource, e.g. because the bug is private.
+func IsAccessDenied(err error) bool {
+	reqError, ok := err.(*requestError)
+	if !ok {
+		return false
+	}
+	return reqError.statusCode == http.StatusUnauthorized || reqError.statusCode == http.StatusForbidden
+}
+
+// IsRetryable determines if the error is transient, i.e. the Bugzilla server
+// is rate limiting us or is temporarily failing, so that the request may
+// succeed if it is retried later.
+func IsRetryable(err error) bool {
+	reqError, ok := err.(*requestError)
+	if !ok {
+		return false
+	}
+	return reqError.statusCode == http.StatusTooManyRequests || reqError.statusCode >= http.StatusInternalServerError
+}
+
 // AddPullRequestAsExternalBug attempts to add a PR to the external tracker list.
 // External bugs are assumed to fall under the type identified by their hostname,
 // so we will provide https://github.com/ here for the URL identifier. We return
@@ -301,6 +438,82 @@ func (c *client) AddPullRequestAsExternalBug(id int, org, repo string, num int)
 	return changed, nil
 }
 
+// RemoveExternalBugFromBug attempts to remove a PR from the external tracker list.
+// External bugs are assumed to fall under the type identified by their hostname,
+// so we will provide https://github.com/ here for the URL identifier. We return
+// any error as well as whether a change was actually made.
+// This will be done via JSONRPC:
+// https://bugzilla.redhat.com/docs/en/html/integrating/api/Bugzilla/Extension/ExternalBugs/WebService.html#remove-external-bug
+func (c *client) RemoveExternalBugFromBug(id int, org, repo string, num int) (bool, error) {
+	logger := c.logger.WithFields(logrus.Fields{methodField: "RemoveExternalBug", "id": id, "org": org, "repo": repo, "num": num})
+	pullIdentifier := IdentifierForPull(org, repo, num)
+	rpcPayload := struct {
+		// Version is the version of JSONRPC to use. All Bugzilla servers
+		// support 1.0. Some support 1.1 and some support 2.0
+		Version string `json:"jsonrpc"`
+		Method  string `json:"method"`
+		// Parameters must be specified in JSONRPC 1.0 as a structure in the first
+		// index of this slice
+		Parameters []RemoveExternalBugParameters `json:"params"`
+		ID         string                        `json:"id"`
+	}{
+		Version: "1.0", // some Bugzilla servers support 2.0 but all support 1.0
+		Method:  "ExternalBugs.remove_external_bug",
+		ID:      "identifier", // this is useful when fielding asynchronous responses, but not here
+		Parameters: []RemoveExternalBugParameters{{
+			APIKey: string(c.getAPIKey()),
+			BugIDs: []int{id},
+			NewExternalBugIdentifier: NewExternalBugIdentifier{
+				Type: "https://github.com/",
+				ID:   pullIdentifier,
+			},
+		}},
+	}
+	body, err := json.Marshal(rpcPayload)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal JSONRPC payload: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/jsonrpc.cgi", c.endpoint), bytes.NewBuffer(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.request(req, logger)
+	if err != nil {
+		return false, err
+	}
+	var response struct {
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error,omitempty"`
+		ID     string `json:"id"`
+		Result *struct {
+			ExternalBugs []struct {
+				Type string `json:"ext_type_url"`
+				ID   string `json:"ext_bz_bug_id"`
+			} `json:"external_bugs"`
+		} `json:"result,omitempty"`
+	}
+	if err := json.Unmarshal(resp, &response); err != nil {
+		return false, fmt.Errorf("failed to unmarshal JSONRPC response: %v", err)
+	}
+	if response.Error != nil {
+		return false, fmt.Errorf("JSONRPC error %d: %v", response.Error.Code, response.Error.Message)
+	}
+	if response.ID != rpcPayload.ID {
+		return false, fmt.Errorf("JSONRPC returned mismatched identifier, expected %s but got %s", rpcPayload.ID, response.ID)
+	}
+	changed := false
+	if response.Result != nil {
+		for _, bug := range response.Result.ExternalBugs {
+			changed = changed || bug.ID == pullIdentifier
+		}
+	}
+	return changed, nil
+}
+
 func IdentifierForPull(org, repo string, num int) string {
 	return fmt.Sprintf("%s/%s/pull/%d", org, repo, num)
 }
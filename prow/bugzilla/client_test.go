@@ -17,8 +17,10 @@ limitations under the License.
 package bugzilla
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -26,6 +28,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/diff"
@@ -109,6 +112,78 @@ func TestGetBug(t *testing.T) {
 	}
 }
 
+func TestIsRetryable(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "rate limiting is retryable",
+			err:      &requestError{statusCode: http.StatusTooManyRequests},
+			expected: true,
+		},
+		{
+			name:     "server error is retryable",
+			err:      &requestError{statusCode: http.StatusBadGateway},
+			expected: true,
+		},
+		{
+			name:     "not found is not retryable",
+			err:      &requestError{statusCode: http.StatusNotFound},
+			expected: false,
+		},
+		{
+			name:     "other error is not retryable",
+			err:      errors.New("oops"),
+			expected: false,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := IsRetryable(testCase.err); actual != testCase.expected {
+				t.Errorf("%s: expected %v, got %v", testCase.name, testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsAccessDenied(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "unauthorized is access denied",
+			err:      &requestError{statusCode: http.StatusUnauthorized},
+			expected: true,
+		},
+		{
+			name:     "forbidden is access denied",
+			err:      &requestError{statusCode: http.StatusForbidden},
+			expected: true,
+		},
+		{
+			name:     "not found is not access denied",
+			err:      &requestError{statusCode: http.StatusNotFound},
+			expected: false,
+		},
+		{
+			name:     "other error is not access denied",
+			err:      errors.New("oops"),
+			expected: false,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := IsAccessDenied(testCase.err); actual != testCase.expected {
+				t.Errorf("%s: expected %v, got %v", testCase.name, testCase.expected, actual)
+			}
+		})
+	}
+}
+
 func TestUpdateBug(t *testing.T) {
 	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("X-BUGZILLA-API-KEY") != "api-key" {
@@ -171,6 +246,156 @@ func TestUpdateBug(t *testing.T) {
 	}
 }
 
+func TestCreateBug(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-BUGZILLA-API-KEY") != "api-key" {
+			t.Error("did not get api-key passed in X-BUGZILLA-API-KEY header")
+			http.Error(w, "403 Forbidden", http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Error("did not correctly set content-type header for JSON")
+			http.Error(w, "403 Forbidden", http.StatusForbidden)
+			return
+		}
+		if r.Method != http.MethodPost {
+			t.Errorf("incorrect method to create a bug: %s", r.Method)
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path != "/rest/bug" {
+			t.Errorf("incorrect path to create a bug: %s", r.URL.Path)
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
+		raw, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read create body: %v", err)
+		}
+		if actual, expected := string(raw), `{"product":"Product","component":["Component"],"summary":"Clone","depends_on":[1705243]}`; actual != expected {
+			t.Errorf("got incorrect create: expected %v, got %v", expected, actual)
+		}
+		if _, err := w.Write([]byte(`{"id":1705244}`)); err != nil {
+			t.Fatalf("failed to send JSON response: %v", err)
+		}
+	}))
+	defer testServer.Close()
+	client := clientForUrl(testServer.URL)
+
+	id, err := client.CreateBug(&BugCreate{Product: "Product", Component: []string{"Component"}, Summary: "Clone", DependsOn: []int{1705243}})
+	if err != nil {
+		t.Errorf("expected no error, but got one: %v", err)
+	}
+	if id != 1705244 {
+		t.Errorf("expected the new bug to have ID 1705244, got %d", id)
+	}
+}
+
+func TestCreateBugComment(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-BUGZILLA-API-KEY") != "api-key" {
+			t.Error("did not get api-key passed in X-BUGZILLA-API-KEY header")
+			http.Error(w, "403 Forbidden", http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Error("did not correctly set content-type header for JSON")
+			http.Error(w, "403 Forbidden", http.StatusForbidden)
+			return
+		}
+		if r.Method != http.MethodPost {
+			t.Errorf("incorrect method to comment on a bug: %s", r.Method)
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path != "/rest/bug/1705243/comment" {
+			t.Errorf("incorrect path to comment on a bug: %s", r.URL.Path)
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
+		raw, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read comment body: %v", err)
+		}
+		if actual, expected := string(raw), `{"comment":"Please triage."}`; actual != expected {
+			t.Errorf("got incorrect comment: expected %v, got %v", expected, actual)
+		}
+		if _, err := w.Write([]byte(`{"id":42}`)); err != nil {
+			t.Fatalf("failed to send JSON response: %v", err)
+		}
+	}))
+	defer testServer.Close()
+	client := clientForUrl(testServer.URL)
+
+	id, err := client.CreateBugComment(1705243, "Please triage.")
+	if err != nil {
+		t.Errorf("expected no error, but got one: %v", err)
+	}
+	if id != 42 {
+		t.Errorf("expected the new comment to have ID 42, got %d", id)
+	}
+}
+
+func TestForEndpoint(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-BUGZILLA-API-KEY") != "" || r.URL.Query().Get("api_key") != "" {
+			t.Error("api-key was sent to a different Bugzilla server")
+			http.Error(w, "403 Forbidden", http.StatusForbidden)
+			return
+		}
+		w.Write(bugData)
+	}))
+	defer testServer.Close()
+	client := clientForUrl("https://bugzilla.example.com").ForEndpoint(testServer.URL)
+
+	if actual, expected := client.Endpoint(), testServer.URL; actual != expected {
+		t.Errorf("expected endpoint %q, got %q", expected, actual)
+	}
+	bug, err := client.GetBug(1705243)
+	if err != nil {
+		t.Fatalf("expected no error, but got one: %v", err)
+	}
+	if !reflect.DeepEqual(bug, bugStruct) {
+		t.Errorf("got incorrect bug: %v", diff.ObjectReflectDiff(bug, bugStruct))
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	release := make(chan struct{})
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write(bugData)
+	}))
+	defer testServer.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	client := clientForUrl(testServer.URL).WithContext(ctx)
+	if actual, expected := client.Endpoint(), testServer.URL; actual != expected {
+		t.Errorf("expected endpoint %q, got %q", expected, actual)
+	}
+	if _, err := client.GetBug(1705243); err == nil {
+		t.Error("expected an error when the deadline passed, but got none")
+	}
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Errorf("expected the deadline to have passed, but got: %v", ctx.Err())
+	}
+}
+
+func TestCloneBugStruct(t *testing.T) {
+	clone := CloneBugStruct(bugStruct)
+	if actual, expected := clone.DependsOn, []int{bugStruct.ID}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("clone should depend on the original bug: expected %v, got %v", expected, actual)
+	}
+	if actual, expected := clone.Summary, bugStruct.Summary; actual != expected {
+		t.Errorf("clone should keep the summary: expected %q, got %q", expected, actual)
+	}
+	if actual, expected := clone.Component, bugStruct.Component; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("clone should keep the component: expected %v, got %v", expected, actual)
+	}
+}
+
 func TestAddPullRequestAsExternalBug(t *testing.T) {
 	var testCases = []struct {
 		name            string
@@ -309,6 +534,121 @@ func TestAddPullRequestAsExternalBug(t *testing.T) {
 	}
 }
 
+func TestRemoveExternalBugFromBug(t *testing.T) {
+	var testCases = []struct {
+		name            string
+		id              int
+		expectedPayload string
+		response        string
+		expectedError   bool
+		expectedChanged bool
+	}{
+		{
+			name:            "update succeeds, makes a change",
+			id:              1705243,
+			expectedPayload: `{"jsonrpc":"1.0","method":"ExternalBugs.remove_external_bug","params":[{"api_key":"api-key","bug_ids":[1705243],"ext_type_url":"https://github.com/","ext_bz_bug_id":"org/repo/pull/1"}],"id":"identifier"}`,
+			response:        `{"error":null,"id":"identifier","result":{"external_bugs":[{"ext_type_url":"https://github.com/","ext_bz_bug_id":"org/repo/pull/1"}]}}`,
+			expectedError:   false,
+			expectedChanged: true,
+		},
+		{
+			name:            "update succeeds, makes no change",
+			id:              1705244,
+			expectedPayload: `{"jsonrpc":"1.0","method":"ExternalBugs.remove_external_bug","params":[{"api_key":"api-key","bug_ids":[1705244],"ext_type_url":"https://github.com/","ext_bz_bug_id":"org/repo/pull/1"}],"id":"identifier"}`,
+			response:        `{"error":null,"id":"identifier","result":{"external_bugs":[]}}`,
+			expectedError:   false,
+			expectedChanged: false,
+		},
+		{
+			name:            "update fails, makes no change",
+			id:              1705245,
+			expectedPayload: `{"jsonrpc":"1.0","method":"ExternalBugs.remove_external_bug","params":[{"api_key":"api-key","bug_ids":[1705245],"ext_type_url":"https://github.com/","ext_bz_bug_id":"org/repo/pull/1"}],"id":"identifier"}`,
+			response:        `{"error":{"code": 100400,"message":"Invalid params for JSONRPC 1.0."},"id":"identifier","result":null}`,
+			expectedError:   true,
+			expectedChanged: false,
+		},
+		{
+			name:            "get unrelated JSONRPC response",
+			id:              1705246,
+			expectedPayload: `{"jsonrpc":"1.0","method":"ExternalBugs.remove_external_bug","params":[{"api_key":"api-key","bug_ids":[1705246],"ext_type_url":"https://github.com/","ext_bz_bug_id":"org/repo/pull/1"}],"id":"identifier"}`,
+			response:        `{"error":null,"id":"oops","result":{"external_bugs":[]}}`,
+			expectedError:   true,
+			expectedChanged: false,
+		},
+	}
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Error("did not correctly set content-type header for JSON")
+			http.Error(w, "403 Forbidden", http.StatusForbidden)
+			return
+		}
+		if r.Method != http.MethodPost {
+			t.Errorf("incorrect method to use the JSONRPC API: %s", r.Method)
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path != "/jsonrpc.cgi" {
+			t.Errorf("incorrect path to use the JSONRPC API: %s", r.URL.Path)
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
+		var payload struct {
+			Parameters []RemoveExternalBugParameters `json:"params"`
+		}
+		raw, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request body: %v", err)
+			http.Error(w, "500 Server Error", http.StatusInternalServerError)
+			return
+		}
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			t.Errorf("malformed JSONRPC payload: %s", string(raw))
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
+		for _, testCase := range testCases {
+			if payload.Parameters[0].BugIDs[0] == testCase.id {
+				if actual, expected := string(raw), testCase.expectedPayload; actual != expected {
+					t.Errorf("%s: got incorrect JSONRPC payload: %v", testCase.name, diff.ObjectReflectDiff(expected, actual))
+				}
+				if _, err := w.Write([]byte(testCase.response)); err != nil {
+					t.Fatalf("%s: failed to send JSONRPC response: %v", testCase.name, err)
+				}
+				return
+			}
+		}
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+	}))
+	defer testServer.Close()
+	client := clientForUrl(testServer.URL)
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			changed, err := client.RemoveExternalBugFromBug(testCase.id, "org", "repo", 1)
+			if !testCase.expectedError && err != nil {
+				t.Errorf("%s: expected no error, but got one: %v", testCase.name, err)
+			}
+			if testCase.expectedError && err == nil {
+				t.Errorf("%s: expected an error, but got none", testCase.name)
+			}
+			if testCase.expectedChanged != changed {
+				t.Errorf("%s: got incorrect state change", testCase.name)
+			}
+		})
+	}
+
+	// this should 404
+	changed, err := client.RemoveExternalBugFromBug(1, "org", "repo", 1)
+	if err == nil {
+		t.Error("expected an error, but got none")
+	} else if !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if changed {
+		t.Error("expected not to change state, but did")
+	}
+}
+
 func TestIdentifierForPull(t *testing.T) {
 	var testCases = []struct {
 		name      string
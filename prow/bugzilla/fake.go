@@ -17,6 +17,7 @@ limitations under the License.
 package bugzilla
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
@@ -29,6 +30,16 @@ type Fake struct {
 	Bugs           map[int]Bug
 	BugErrors      sets.Int
 	ExternalBugs   map[int][]ExternalBug
+	// TransientBugErrors holds the number of times GetBug will
+	// respond with an error that matches IsRetryable for a bug
+	TransientBugErrors map[int]int
+	// PrivateBugs holds bugs for which GetBug will respond
+	// with an error that matches IsAccessDenied
+	PrivateBugs sets.Int
+	// Remotes holds the fakes returned by ForEndpoint
+	Remotes map[string]*Fake
+	// BugComments holds the comments added to each bug
+	BugComments map[int][]string
 }
 
 // Endpoint returns the endpoint for this fake
@@ -42,6 +53,13 @@ func (c *Fake) GetBug(id int) (*Bug, error) {
 	if c.BugErrors.Has(id) {
 		return nil, errors.New("injected error getting bug")
 	}
+	if c.PrivateBugs.Has(id) {
+		return nil, &requestError{statusCode: http.StatusUnauthorized, message: "injected authorization error getting bug"}
+	}
+	if c.TransientBugErrors[id] > 0 {
+		c.TransientBugErrors[id]--
+		return nil, &requestError{statusCode: http.StatusTooManyRequests, message: "injected rate limit error getting bug"}
+	}
 	if bug, exists := c.Bugs[id]; exists {
 		return &bug, nil
 	}
@@ -76,6 +94,69 @@ func (c *Fake) UpdateBug(id int, update BugUpdate) error {
 	return &requestError{statusCode: http.StatusNotFound, message: "bug not registered in the fake"}
 }
 
+// ForEndpoint returns the remote fake registered for the endpoint,
+// or a fake without any bugs
+func (c *Fake) ForEndpoint(endpoint string) Client {
+	if remote, exists := c.Remotes[endpoint]; exists {
+		return remote
+	}
+	return &Fake{EndpointString: endpoint}
+}
+
+// WithContext returns the fake itself, as the fake never blocks
+func (c *Fake) WithContext(ctx context.Context) Client {
+	return c
+}
+
+// CreateBug files a new bug with the next unused ID
+func (c *Fake) CreateBug(bug *BugCreate) (int, error) {
+	var next int
+	for id := range c.Bugs {
+		if id > next {
+			next = id
+		}
+	}
+	next++
+	c.Bugs[next] = Bug{
+		ID:              next,
+		Product:         bug.Product,
+		Component:       bug.Component,
+		Summary:         bug.Summary,
+		Version:         bug.Version,
+		OperatingSystem: bug.OperatingSystem,
+		Platform:        bug.Platform,
+		Priority:        bug.Priority,
+		Severity:        bug.Severity,
+		TargetRelease:   bug.TargetRelease,
+		AssignedTo:      bug.AssignedTo,
+		QAContact:       bug.QAContact,
+		CC:              bug.CC,
+		Keywords:        bug.Keywords,
+		Groups:          bug.Groups,
+		DependsOn:       bug.DependsOn,
+		Blocks:          bug.Blocks,
+		IsOpen:          true,
+		Status:          "NEW",
+	}
+	return next, nil
+}
+
+// CreateBugComment adds the comment to the bug, if registered, or an
+// error, if set, or responds with an error that matches IsNotFound
+func (c *Fake) CreateBugComment(id int, comment string) (int, error) {
+	if c.BugErrors.Has(id) {
+		return 0, errors.New("injected error commenting on bug")
+	}
+	if _, exists := c.Bugs[id]; !exists {
+		return 0, &requestError{statusCode: http.StatusNotFound, message: "bug not registered in the fake"}
+	}
+	if c.BugComments == nil {
+		c.BugComments = map[int][]string{}
+	}
+	c.BugComments[id] = append(c.BugComments[id], comment)
+	return len(c.BugComments[id]), nil
+}
+
 // AddPullRequestAsExternalBug adds an external bug to the Bugzilla bug,
 // if registered, or an error, if set, or responds with an error that
 // matches IsNotFound
@@ -99,5 +180,25 @@ func (c *Fake) AddPullRequestAsExternalBug(id int, org, repo string, num int) (b
 	return false, &requestError{statusCode: http.StatusNotFound, message: "bug not registered in the fake"}
 }
 
+// RemoveExternalBugFromBug removes an external bug from the Bugzilla bug,
+// if registered, or an error, if set, or responds with an error that
+// matches IsNotFound
+func (c *Fake) RemoveExternalBugFromBug(id int, org, repo string, num int) (bool, error) {
+	if c.BugErrors.Has(id) {
+		return false, errors.New("injected error removing external bug from bug")
+	}
+	if _, exists := c.Bugs[id]; exists {
+		pullIdentifier := IdentifierForPull(org, repo, num)
+		for i, bug := range c.ExternalBugs[id] {
+			if bug.BugzillaBugID == id && bug.ExternalBugID == pullIdentifier {
+				c.ExternalBugs[id] = append(c.ExternalBugs[id][:i], c.ExternalBugs[id][i+1:]...)
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, &requestError{statusCode: http.StatusNotFound, message: "bug not registered in the fake"}
+}
+
 // the Fake is a Client
 var _ Client = &Fake{}
@@ -147,6 +147,45 @@ type BugUpdate struct {
 	Resolution string `json:"resolution,omitempty"`
 }
 
+// BugCreate holds the fields used to file a new Bug. See API documentation at:
+// https://bugzilla.readthedocs.io/en/latest/api/core/v1/bug.html#create-bug
+type BugCreate struct {
+	// Product is the name of the product the bug is being filed against.
+	Product string `json:"product,omitempty"`
+	// Component is the name of the component in the product.
+	Component []string `json:"component,omitempty"`
+	// Summary is a brief description of the bug being filed.
+	Summary string `json:"summary,omitempty"`
+	// Version is the version of the product the bug was found in.
+	Version []string `json:"version,omitempty"`
+	// Description is the initial description for the bug.
+	Description string `json:"description,omitempty"`
+	// OperatingSystem is the operating system the bug was discovered on.
+	OperatingSystem string `json:"op_sys,omitempty"`
+	// Platform is the platform (hardware) the bug was discovered on.
+	Platform string `json:"platform,omitempty"`
+	// Priority is the priority of the bug.
+	Priority string `json:"priority,omitempty"`
+	// Severity is the severity of the bug.
+	Severity string `json:"severity,omitempty"`
+	// TargetRelease are the releases that the bug will be fixed in.
+	TargetRelease []string `json:"target_release,omitempty"`
+	// AssignedTo is the login name of the user to assign the bug to.
+	AssignedTo string `json:"assigned_to,omitempty"`
+	// QAContact is the login name of the QA Contact for the bug.
+	QAContact string `json:"qa_contact,omitempty"`
+	// CC is the login names of users to put on the CC list of the bug.
+	CC []string `json:"cc,omitempty"`
+	// Keywords are the keywords to set on the bug.
+	Keywords []string `json:"keywords,omitempty"`
+	// Groups are the names of the groups the bug should be restricted to.
+	Groups []string `json:"groups,omitempty"`
+	// DependsOn is the IDs of bugs that the new bug "depends on".
+	DependsOn []int `json:"depends_on,omitempty"`
+	// Blocks is the IDs of bugs that are "blocked" by the new bug.
+	Blocks []int `json:"blocks,omitempty"`
+}
+
 // ExternalBug contains details about an external bug linked to a Bugzilla bug.
 // See API documentation at:
 // https://bugzilla.redhat.com/docs/en/html/integrating/api/Bugzilla/Extension/ExternalBugs/WebService.html
@@ -179,6 +218,17 @@ type AddExternalBugParameters struct {
 	ExternalBugs []NewExternalBugIdentifier `json:"external_bugs"`
 }
 
+// RemoveExternalBugParameters are the parameters required to remove an external
+// tracker bug from a Bugzilla bug
+type RemoveExternalBugParameters struct {
+	// APIKey is the API key to use when authenticating with Bugzilla
+	APIKey string `json:"api_key"`
+	// BugIDs are the IDs of Bugzilla bugs to update
+	BugIDs []int `json:"bug_ids"`
+	// NewExternalBugIdentifier identifies the external bug to remove
+	NewExternalBugIdentifier
+}
+
 // NewExternalBugIdentifier holds fields used to identify new external bugs when
 // adding them using the JSONRPC API
 type NewExternalBugIdentifier struct {
@@ -20,7 +20,9 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"regexp"
 	"time"
 
 	"cloud.google.com/go/storage"
@@ -46,6 +48,7 @@ import (
 	"k8s.io/test-infra/prow/logrusutil"
 	"k8s.io/test-infra/prow/metrics"
 	"k8s.io/test-infra/prow/pjutil"
+	"k8s.io/test-infra/prow/version"
 )
 
 const (
@@ -71,7 +74,18 @@ type options struct {
 
 	slackTokenFile string
 
-	gcsCredentialsFile string
+	gcsCredentialsFile      string
+	gcsWriteLatestPassing   bool
+	gcsProwJobGzipThreshold int
+	gcsWriteManifest        bool
+	gcsObjectPrefix         string
+	gcsUploadTimeout        time.Duration
+	gcsWriteRetries         int
+	gcsOverwriteStarted     bool
+	gcsTerminalProwJobsOnly bool
+	gcsStartedMetadataKeys  prowflagutil.Strings
+	gcsExcludeJobTypes      prowflagutil.Strings
+	gcsExcludeJobs          string
 
 	k8sReportFraction float64
 
@@ -99,6 +113,30 @@ func (o *options) validate() error {
 		return errors.New("--kubernetes-report-fraction must be a float between 0 and 1")
 	}
 
+	if o.gcsProwJobGzipThreshold < 0 {
+		return errors.New("--gcs-prowjob-gzip-threshold must not be negative")
+	}
+
+	if o.gcsUploadTimeout < 0 {
+		return errors.New("--gcs-upload-timeout must not be negative")
+	}
+
+	if o.gcsWriteRetries < 0 {
+		return errors.New("--gcs-write-retries must not be negative")
+	}
+
+	for _, jobType := range o.gcsExcludeJobTypes.Strings() {
+		switch prowapi.ProwJobType(jobType) {
+		case prowapi.PresubmitJob, prowapi.PostsubmitJob, prowapi.PeriodicJob, prowapi.BatchJob:
+		default:
+			return fmt.Errorf("--gcs-exclude-job-type: %q is not a job type", jobType)
+		}
+	}
+
+	if _, err := regexp.Compile(o.gcsExcludeJobs); err != nil {
+		return fmt.Errorf("--gcs-exclude-jobs is not a valid regular expression: %v", err)
+	}
+
 	if o.gerritWorkers > 0 {
 		if len(o.gerritProjects) == 0 {
 			return errors.New("--gerrit-projects must be set")
@@ -142,6 +180,17 @@ func (o *options) parseArgs(fs *flag.FlagSet, args []string) error {
 	fs.IntVar(&o.k8sGCSWorkers, "kubernetes-gcs-workers", 0, "Number of Kubernetes-specific GCS report workers (0 means disabled)")
 	fs.Float64Var(&o.k8sReportFraction, "kubernetes-report-fraction", 1.0, "Approximate portion of jobs to report pod information for, if kubernetes-gcs-workers are enabled (0 - > none, 1.0 -> all)")
 	fs.StringVar(&o.gcsCredentialsFile, "gcs-credentials-file", "", "Location of the GCS credentials file, if gcs-workers is non-zero")
+	fs.BoolVar(&o.gcsWriteLatestPassing, "gcs-write-latest-passing-build", false, "Update latest-passing-build.txt in the job directory when a job succeeds, if gcs-workers is non-zero")
+	fs.BoolVar(&o.gcsWriteManifest, "gcs-write-manifest", false, "Write a manifest.json listing the objects uploaded for the job on every report, if gcs-workers is non-zero")
+	fs.IntVar(&o.gcsProwJobGzipThreshold, "gcs-prowjob-gzip-threshold", 0, "Gzip-compress prowjob.json uploads larger than this many bytes (0 means never compress)")
+	fs.StringVar(&o.gcsObjectPrefix, "gcs-object-prefix", "", "Prefix prepended to the names of all objects uploaded by the GCS reporter, to keep instances sharing a bucket apart")
+	fs.DurationVar(&o.gcsUploadTimeout, "gcs-upload-timeout", 0, "How long the GCS reporter may spend uploading the objects for a single report (0 means the default of 10s)")
+	fs.IntVar(&o.gcsWriteRetries, "gcs-write-retries", 0, "How many times the GCS reporter retries writes that failed in a way that may be transient, with exponential backoff")
+	fs.BoolVar(&o.gcsOverwriteStarted, "gcs-overwrite-crier-started", false, "Replace a started.json previously uploaded by crier when reporting the job again, but never one uploaded by the job's pod")
+	fs.BoolVar(&o.gcsTerminalProwJobsOnly, "gcs-terminal-prowjobs-only", false, "Upload prowjob.json only the first time a running job is reported and when it completes, rather than on every update")
+	fs.Var(&o.gcsStartedMetadataKeys, "gcs-started-metadata-key", "Label or annotation of prowjobs that the GCS reporter copies into the metadata of started.json if set, may be repeated")
+	fs.Var(&o.gcsExcludeJobTypes, "gcs-exclude-job-type", "Type of jobs the GCS reporter does not report, may be repeated")
+	fs.StringVar(&o.gcsExcludeJobs, "gcs-exclude-jobs", "", "Regular expression matching the names of jobs the GCS reporter does not report")
 	fs.StringVar(&o.slackTokenFile, "slack-token-file", "", "Path to a Slack token file")
 	fs.StringVar(&o.reportAgent, "report-agent", "", "Only report specified agent - empty means report to all agents (effective for github and Slack only)")
 
@@ -280,7 +329,27 @@ func main() {
 		}
 
 		if o.gcsWorkers > 0 {
-			gcsReporter := gcsreporter.New(cfg, s, o.dryrun)
+			var filter gcsreporter.JobFilter
+			for _, jobType := range o.gcsExcludeJobTypes.Strings() {
+				filter.ExcludedTypes = append(filter.ExcludedTypes, prowapi.ProwJobType(jobType))
+			}
+			if o.gcsExcludeJobs != "" {
+				filter.ExcludedJobs = regexp.MustCompile(o.gcsExcludeJobs)
+			}
+			gcsReporter := gcsreporter.New(interrupts.Context(), cfg, s, gcsreporter.Options{
+				WriteLatestPassing:    o.gcsWriteLatestPassing,
+				ProwJobGzipThreshold:  o.gcsProwJobGzipThreshold,
+				WriteManifest:         o.gcsWriteManifest,
+				ObjectPrefix:          o.gcsObjectPrefix,
+				UploadTimeout:         o.gcsUploadTimeout,
+				WriteRetries:          o.gcsWriteRetries,
+				OverwriteCrierStarted: o.gcsOverwriteStarted,
+				TerminalProwJobsOnly:  o.gcsTerminalProwJobsOnly,
+				StartedMetadataKeys:   o.gcsStartedMetadataKeys.Strings(),
+				Filter:                filter,
+				Version:               version.Version,
+				DryRun:                o.dryrun,
+			})
 			controllers = append(
 				controllers,
 				crier.NewController(
@@ -22,6 +22,7 @@ import (
 	prowflagutil "k8s.io/test-infra/prow/flagutil"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestOptions(t *testing.T) {
@@ -31,6 +32,10 @@ func TestOptions(t *testing.T) {
 
 	defaultGerritProjects := make(map[string][]string, 0)
 
+	excludedJobTypes := prowflagutil.NewStrings()
+	excludedJobTypes.Set("batch")
+	excludedJobTypes.Set("periodic")
+
 	cases := []struct {
 		name     string
 		args     []string
@@ -160,6 +165,111 @@ func TestOptions(t *testing.T) {
 			name: "k8s-gcs with negative report fraction rejects",
 			args: []string{"--kubernetes-gcs-workers=3", "--config-path=foo", "--kubernetes-report-fraction=-1.2"},
 		},
+		{
+			name: "gcs with prowjob gzip threshold sets threshold",
+			args: []string{"--gcs-workers=3", "--config-path=foo", "--gcs-prowjob-gzip-threshold=1024"},
+			expected: &options{
+				gcsWorkers:              3,
+				gcsProwJobGzipThreshold: 1024,
+				configPath:              "foo",
+				github:                  defaultGitHubOptions,
+				gerritProjects:          defaultGerritProjects,
+				k8sReportFraction:       1.0,
+			},
+		},
+		{
+			name: "gcs with negative prowjob gzip threshold rejects",
+			args: []string{"--gcs-workers=3", "--config-path=foo", "--gcs-prowjob-gzip-threshold=-1"},
+		},
+		{
+			name: "gcs with object prefix sets prefix",
+			args: []string{"--gcs-workers=3", "--config-path=foo", "--gcs-object-prefix=instance-a"},
+			expected: &options{
+				gcsWorkers:        3,
+				gcsObjectPrefix:   "instance-a",
+				configPath:        "foo",
+				github:            defaultGitHubOptions,
+				gerritProjects:    defaultGerritProjects,
+				k8sReportFraction: 1.0,
+			},
+		},
+		{
+			name: "gcs with upload timeout sets timeout",
+			args: []string{"--gcs-workers=3", "--config-path=foo", "--gcs-upload-timeout=1m"},
+			expected: &options{
+				gcsWorkers:        3,
+				gcsUploadTimeout:  time.Minute,
+				configPath:        "foo",
+				github:            defaultGitHubOptions,
+				gerritProjects:    defaultGerritProjects,
+				k8sReportFraction: 1.0,
+			},
+		},
+		{
+			name: "gcs with negative upload timeout rejects",
+			args: []string{"--gcs-workers=3", "--config-path=foo", "--gcs-upload-timeout=-1s"},
+		},
+		{
+			name: "gcs with write retries sets retries",
+			args: []string{"--gcs-workers=3", "--config-path=foo", "--gcs-write-retries=3"},
+			expected: &options{
+				gcsWorkers:        3,
+				gcsWriteRetries:   3,
+				configPath:        "foo",
+				github:            defaultGitHubOptions,
+				gerritProjects:    defaultGerritProjects,
+				k8sReportFraction: 1.0,
+			},
+		},
+		{
+			name: "gcs with overwriting crier's started.json",
+			args: []string{"--gcs-workers=3", "--config-path=foo", "--gcs-overwrite-crier-started"},
+			expected: &options{
+				gcsWorkers:          3,
+				gcsOverwriteStarted: true,
+				configPath:          "foo",
+				github:              defaultGitHubOptions,
+				gerritProjects:      defaultGerritProjects,
+				k8sReportFraction:   1.0,
+			},
+		},
+		{
+			name: "gcs with terminal prowjobs only",
+			args: []string{"--gcs-workers=3", "--config-path=foo", "--gcs-terminal-prowjobs-only"},
+			expected: &options{
+				gcsWorkers:              3,
+				gcsTerminalProwJobsOnly: true,
+				configPath:              "foo",
+				github:                  defaultGitHubOptions,
+				gerritProjects:          defaultGerritProjects,
+				k8sReportFraction:       1.0,
+			},
+		},
+		{
+			name: "gcs with excluded job types and names",
+			args: []string{"--gcs-workers=3", "--config-path=foo", "--gcs-exclude-job-type=batch", "--gcs-exclude-job-type=periodic", "--gcs-exclude-jobs=^ci-"},
+			expected: &options{
+				gcsWorkers:         3,
+				gcsExcludeJobTypes: excludedJobTypes,
+				gcsExcludeJobs:     "^ci-",
+				configPath:         "foo",
+				github:             defaultGitHubOptions,
+				gerritProjects:     defaultGerritProjects,
+				k8sReportFraction:  1.0,
+			},
+		},
+		{
+			name: "gcs with an unknown excluded job type rejects",
+			args: []string{"--gcs-workers=3", "--config-path=foo", "--gcs-exclude-job-type=nightly"},
+		},
+		{
+			name: "gcs with an invalid excluded jobs regexp rejects",
+			args: []string{"--gcs-workers=3", "--config-path=foo", "--gcs-exclude-jobs=ci-("},
+		},
+		{
+			name: "gcs with negative write retries rejects",
+			args: []string{"--gcs-workers=3", "--config-path=foo", "--gcs-write-retries=-1"},
+		},
 	}
 
 	for _, tc := range cases {
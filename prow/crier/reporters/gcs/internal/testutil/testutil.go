@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync"
 
 	"k8s.io/test-infra/prow/config"
 )
@@ -33,12 +34,13 @@ func (ca Fca) Config() *config.Config {
 }
 
 type TestAuthor struct {
-	AlreadyUsed bool
-	Bucket      string
-	Path        string
-	Content     []byte
-	Overwrite   bool
-	Closed      bool
+	AlreadyUsed     bool
+	Bucket          string
+	Path            string
+	Content         []byte
+	Overwrite       bool
+	Closed          bool
+	ContentEncoding string
 }
 
 type TestAuthorWriteCloser struct {
@@ -55,7 +57,7 @@ func (wc *TestAuthorWriteCloser) Close() error {
 	return nil
 }
 
-func (ta *TestAuthor) NewWriter(ctx context.Context, bucket, path string, overwrite bool) io.WriteCloser {
+func (ta *TestAuthor) NewWriter(ctx context.Context, bucket, path string, overwrite bool, contentEncoding string) io.WriteCloser {
 	if ta.AlreadyUsed {
 		panic(fmt.Sprintf("NewWriter called on testAuthor twice: first for %q/%q, now for %q/%q", ta.Bucket, ta.Path, bucket, path))
 	}
@@ -63,5 +65,38 @@ func (ta *TestAuthor) NewWriter(ctx context.Context, bucket, path string, overwr
 	ta.Bucket = bucket
 	ta.Path = path
 	ta.Overwrite = overwrite
+	ta.ContentEncoding = contentEncoding
 	return &TestAuthorWriteCloser{author: ta}
 }
+
+// TestMultiAuthor records the content of every object written with it, keyed
+// by the path of the object. Objects may be written concurrently.
+type TestMultiAuthor struct {
+	lock    sync.Mutex
+	Objects map[string][]byte
+}
+
+type testMultiAuthorWriteCloser struct {
+	author  *TestMultiAuthor
+	path    string
+	content []byte
+}
+
+func (wc *testMultiAuthorWriteCloser) Write(p []byte) (int, error) {
+	wc.content = append(wc.content, p...)
+	return len(p), nil
+}
+
+func (wc *testMultiAuthorWriteCloser) Close() error {
+	wc.author.lock.Lock()
+	defer wc.author.lock.Unlock()
+	if wc.author.Objects == nil {
+		wc.author.Objects = map[string][]byte{}
+	}
+	wc.author.Objects[wc.path] = wc.content
+	return nil
+}
+
+func (ta *TestMultiAuthor) NewWriter(ctx context.Context, bucket, path string, overwrite bool, contentEncoding string) io.WriteCloser {
+	return &testMultiAuthorWriteCloser{author: ta, path: path}
+}
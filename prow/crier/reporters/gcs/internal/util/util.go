@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 
 	"cloud.google.com/go/storage"
@@ -32,50 +33,136 @@ import (
 	"k8s.io/test-infra/prow/pod-utils/downwardapi"
 )
 
+// Author creates the objects the reporter uploads. It is not tied to GCS, so that
+// artifacts can be written to any object store.
+//
+// The content of an object is written to the returned writer, and the object is
+// created once the writer is closed; errors may be returned by either Write or
+// Close. If overwrite is true, an existing object is replaced. If it is false, an
+// existing object must be left alone, and the write must fail with a googleapi.Error
+// with code http.StatusPreconditionFailed, which WriteContent treats as success.
+// Writers should fail with a googleapi.Error with code http.StatusTooManyRequests or
+// a 5xx code if trying again may succeed, as those are the only errors retried.
 type Author interface {
-	NewWriter(ctx context.Context, bucket, path string, overwrite bool) io.WriteCloser
+	// NewWriter returns a writer for the object at bucket/path. If contentEncoding
+	// is not empty, it is set as the Content-Encoding of the object.
+	NewWriter(ctx context.Context, bucket, path string, overwrite bool, contentEncoding string) io.WriteCloser
 }
 
 type StorageAuthor struct {
 	Client *storage.Client
 }
 
-func (sa StorageAuthor) NewWriter(ctx context.Context, bucket, path string, overwrite bool) io.WriteCloser {
+func (sa StorageAuthor) NewWriter(ctx context.Context, bucket, path string, overwrite bool, contentEncoding string) io.WriteCloser {
 	obj := sa.Client.Bucket(bucket).Object(path)
 	if !overwrite {
 		obj = obj.If(storage.Conditions{DoesNotExist: true})
 	}
-	return obj.NewWriter(ctx)
+	w := obj.NewWriter(ctx)
+	w.ContentEncoding = contentEncoding
+	return w
 }
 
+// GenerationAuthor reads objects along with their generation, and replaces them
+// only if they still have the generation that was read.
+type GenerationAuthor interface {
+	// ReadObject returns the content and generation of the object at bucket/path,
+	// or storage.ErrObjectNotExist if there is no such object.
+	ReadObject(ctx context.Context, bucket, path string) ([]byte, int64, error)
+	// NewGenerationWriter returns a writer replacing the object at bucket/path,
+	// failing with Precondition Failed if its generation is no longer generation.
+	NewGenerationWriter(ctx context.Context, bucket, path string, generation int64) io.WriteCloser
+}
+
+func (sa StorageAuthor) ReadObject(ctx context.Context, bucket, path string) ([]byte, int64, error) {
+	obj := sa.Client.Bucket(bucket).Object(path)
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	r, err := obj.Generation(attrs.Generation).NewReader(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer r.Close()
+	content, err := ioutil.ReadAll(r)
+	return content, attrs.Generation, err
+}
+
+func (sa StorageAuthor) NewGenerationWriter(ctx context.Context, bucket, path string, generation int64) io.WriteCloser {
+	return sa.Client.Bucket(bucket).Object(path).If(storage.Conditions{GenerationMatch: generation}).NewWriter(ctx)
+}
+
+// generationAuthor writes objects with a GenerationAuthor, for objects of a given generation
+type generationAuthor struct {
+	author     GenerationAuthor
+	generation int64
+}
+
+func (ga generationAuthor) NewWriter(ctx context.Context, bucket, path string, _ bool, _ string) io.WriteCloser {
+	return ga.author.NewGenerationWriter(ctx, bucket, path, ga.generation)
+}
+
+// ReplaceContent is like WriteContent, but only replaces the object if it still has
+// the given generation. If it doesn't, the object is left alone without an error.
+func ReplaceContent(ctx context.Context, logger *logrus.Entry, author GenerationAuthor, bucket, path string, generation int64, content []byte) error {
+	return WriteContent(ctx, logger, generationAuthor{author: author, generation: generation}, bucket, path, true, content)
+}
+
+// WriteContent uploads content to bucket/path with author. If overwrite is false and
+// the object already exists, it is left alone without an error.
 func WriteContent(ctx context.Context, logger *logrus.Entry, author Author, bucket, path string, overwrite bool, content []byte) error {
-	logger.WithFields(logrus.Fields{"bucket": bucket, "path": path}).Debugf("Uploading to gs://%s/%s; overwrite: %v", bucket, path, overwrite)
-	w := author.NewWriter(ctx, bucket, path, overwrite)
+	return WriteEncodedContent(ctx, logger, author, bucket, path, overwrite, "", content)
+}
+
+// WriteEncodedContent is like WriteContent, but sets the Content-Encoding of the
+// uploaded object to contentEncoding. The content must already be encoded.
+func WriteEncodedContent(ctx context.Context, logger *logrus.Entry, author Author, bucket, path string, overwrite bool, contentEncoding string, content []byte) error {
+	logger.WithFields(logrus.Fields{"bucket": bucket, "path": path}).Debugf("Uploading to gs://%s/%s; overwrite: %v; encoding: %q", bucket, path, overwrite, contentEncoding)
+	w := author.NewWriter(ctx, bucket, path, overwrite, contentEncoding)
 	_, err := w.Write(content)
 	var reportErr error
 	if isErrUnexpected(err) {
 		reportErr = err
 		logger.WithError(err).WithFields(logrus.Fields{"bucket": bucket, "path": path}).Warn("Uploading info to GCS failed (write)")
 	}
+	exists := isErrPreconditionFailed(err)
 	err = w.Close()
 	if isErrUnexpected(err) {
 		reportErr = err
 		logger.WithError(err).WithFields(logrus.Fields{"bucket": bucket, "path": path}).Warn("Uploading info to GCS failed (close)")
 	}
+	exists = exists || isErrPreconditionFailed(err)
+	if exists && reportErr == nil {
+		// Another writer, like a second crier replica, got there first.
+		logger.WithFields(logrus.Fields{"bucket": bucket, "path": path}).Info("Object already exists in GCS, leaving it alone")
+	}
 	return reportErr
 }
 
+// IsErrRetryable determines whether an upload that failed with err might succeed
+// if tried again, which is the case when GCS rate limits us or fails internally.
+// Any other error, like Precondition Failed for an object that already exists, is permanent.
+func IsErrRetryable(err error) bool {
+	if e, ok := err.(*googleapi.Error); ok {
+		return e.Code == http.StatusTooManyRequests || e.Code >= http.StatusInternalServerError
+	}
+	return false
+}
+
 func isErrUnexpected(err error) bool {
 	if err == nil {
 		return false
 	}
 	// Precondition Failed is expected and we can silently ignore it.
-	if e, ok := err.(*googleapi.Error); ok {
-		if e.Code == http.StatusPreconditionFailed {
-			return false
-		}
-	}
-	return true
+	return !isErrPreconditionFailed(err)
+}
+
+// isErrPreconditionFailed determines whether an upload failed because the object
+// already exists, which is how a write that must not overwrite is refused.
+func isErrPreconditionFailed(err error) bool {
+	e, ok := err.(*googleapi.Error)
+	return ok && e.Code == http.StatusPreconditionFailed
 }
 
 func GetJobDestination(cfg config.Getter, pj *v1.ProwJob) (bucket, dir string, err error) {
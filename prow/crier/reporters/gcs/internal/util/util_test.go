@@ -68,6 +68,92 @@ func TestIsErrUnexpected(t *testing.T) {
 	}
 }
 
+func TestIsErrPreconditionFailed(t *testing.T) {
+	tests := []struct {
+		name               string
+		err                error
+		preconditionFailed bool
+	}{
+		{
+			name:               "nil errors are not precondition failures",
+			err:                nil,
+			preconditionFailed: false,
+		},
+		{
+			name:               "standard errors are not precondition failures",
+			err:                errors.New("this is just a normal error"),
+			preconditionFailed: false,
+		},
+		{
+			name:               "googleapi errors other than Precondition Failed are not precondition failures",
+			err:                &googleapi.Error{Code: http.StatusServiceUnavailable},
+			preconditionFailed: false,
+		},
+		{
+			name:               "Precondition Failed googleapi errors are precondition failures",
+			err:                &googleapi.Error{Code: http.StatusPreconditionFailed},
+			preconditionFailed: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := isErrPreconditionFailed(tc.err)
+			if result != tc.preconditionFailed {
+				t.Errorf("Expected isErrPreconditionFailed() to return %v, got %v", tc.preconditionFailed, result)
+			}
+		})
+	}
+}
+
+func TestIsErrRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{
+			name:      "nil errors are not retryable",
+			err:       nil,
+			retryable: false,
+		},
+		{
+			name:      "standard errors are not retryable",
+			err:       errors.New("this is just a normal error"),
+			retryable: false,
+		},
+		{
+			name:      "Precondition Failed googleapi errors are not retryable",
+			err:       &googleapi.Error{Code: http.StatusPreconditionFailed},
+			retryable: false,
+		},
+		{
+			name:      "Forbidden googleapi errors are not retryable",
+			err:       &googleapi.Error{Code: http.StatusForbidden},
+			retryable: false,
+		},
+		{
+			name:      "Too Many Requests googleapi errors are retryable",
+			err:       &googleapi.Error{Code: http.StatusTooManyRequests},
+			retryable: true,
+		},
+		{
+			name:      "Service Unavailable googleapi errors are retryable",
+			err:       &googleapi.Error{Code: http.StatusServiceUnavailable},
+			retryable: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := IsErrRetryable(tc.err)
+			if result != tc.retryable {
+				t.Errorf("Expected IsErrRetryable() to return %v, got %v", tc.retryable, result)
+			}
+		})
+	}
+}
+
 func TestGetJobDestination(t *testing.T) {
 	standardGcsConfig := &prowv1.GCSConfiguration{
 		Bucket:       "kubernetes-jenkins",
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcs
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// jobsWithoutDestination provides the 'gcs_reporter_jobs_without_destination' counter
+// that keeps track of the jobs we couldn't report because we found nowhere to upload to.
+var jobsWithoutDestination = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "gcs_reporter_jobs_without_destination",
+		Help: "Number of job reports skipped because no GCS destination could be found, by job type.",
+	},
+	[]string{"type"},
+)
+
+// uploadDurations provides the 'gcs_reporter_upload_duration_seconds' histogram that keeps
+// track of how long uploads take, by the artifact uploaded.
+var uploadDurations = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "gcs_reporter_upload_duration_seconds",
+		Help:    "GCS upload duration in seconds, by artifact.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	},
+	[]string{"artifact"},
+)
+
+// uploadFailures provides the 'gcs_reporter_upload_failures' counter that keeps
+// track of the uploads that failed, by the artifact uploaded.
+var uploadFailures = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "gcs_reporter_upload_failures",
+		Help: "Number of failed GCS uploads, by artifact.",
+	},
+	[]string{"artifact"},
+)
+
+func init() {
+	prometheus.MustRegister(jobsWithoutDestination)
+	prometheus.MustRegister(uploadDurations)
+	prometheus.MustRegister(uploadFailures)
+}
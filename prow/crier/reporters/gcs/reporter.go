@@ -17,11 +17,17 @@ limitations under the License.
 package gcs
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"path"
+	"regexp"
+	"sort"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/storage"
@@ -34,53 +40,164 @@ import (
 	"k8s.io/test-infra/prow/config"
 )
 
-const reporterName = "gcsreporter"
+const (
+	reporterName = "gcsreporter"
+	// defaultUploadTimeout bounds the uploads for a single report if no other timeout is configured
+	defaultUploadTimeout = 10 * time.Second
+	// defaultRetryBackoff is how long we wait before retrying a failed write
+	// for the first time; the wait doubles with every further retry.
+	defaultRetryBackoff = time.Second
+	// defaultVersion identifies the crier that uploaded an artifact if its version is not known.
+	defaultVersion = "unknown"
+	// uploadedProwjobTTL is how long a running job is remembered as uploaded; by
+	// the default job timeout, a job that is still tracked has usually been deleted.
+	uploadedProwjobTTL = config.DefaultJobTimeout
+)
 
 type gcsReporter struct {
-	cfg    config.Getter
-	dryRun bool
-	logger *logrus.Entry
-	author util.Author
+	cfg                config.Getter
+	dryRun             bool
+	logger             *logrus.Entry
+	author             util.Author
+	writeLatestPassing bool
+	// prowjobGzipThreshold is the size in bytes above which prowjob.json is
+	// gzip-compressed before upload. Compression is disabled if it is not positive.
+	prowjobGzipThreshold int
+	writeManifest        bool
+	// objectPrefix is prepended to the names of all uploaded objects, so
+	// that several Prow instances can share a bucket. It may be empty.
+	objectPrefix string
+	// ctx is the parent of the context of every report, so that uploads are
+	// cancelled when crier shuts down.
+	ctx           context.Context
+	uploadTimeout time.Duration
+	// writeRetries is how many times writes that failed in a way that may be
+	// transient are retried.
+	writeRetries int
+	retryBackoff time.Duration
+	// overwriteCrierStarted allows replacing a started.json that crier uploaded
+	// before, using replacer to make sure one uploaded by the pod is kept.
+	overwriteCrierStarted bool
+	replacer              util.GenerationAuthor
+	// terminalProwjobsOnly restricts uploads of prowjob.json to the first time a
+	// running job is seen and to its completion, using uploadedProwjobs to
+	// remember the running jobs that were uploaded.
+	terminalProwjobsOnly bool
+	uploadedProwjobs     *prowjobSet
+	// startedMetadataKeys are the prowjob labels and annotations copied into the
+	// metadata of started.json.
+	startedMetadataKeys []string
+	filter              JobFilter
+	// version identifies the crier build in the metadata of uploaded artifacts.
+	version string
+}
+
+// JobFilter selects the jobs that the reporter uploads results for.
+type JobFilter struct {
+	// ExcludedTypes are the types of jobs that are never reported.
+	ExcludedTypes []prowv1.ProwJobType
+	// ExcludedJobs, if set, matches the names of jobs that are never reported.
+	ExcludedJobs *regexp.Regexp
+}
+
+// excludes determines whether the job must not be reported.
+func (f JobFilter) excludes(pj *prowv1.ProwJob) bool {
+	for _, t := range f.ExcludedTypes {
+		if pj.Spec.Type == t {
+			return true
+		}
+	}
+	return f.ExcludedJobs != nil && f.ExcludedJobs.MatchString(pj.Spec.Job)
 }
 
 func (gr *gcsReporter) Report(pj *prowv1.ProwJob) ([]*prowv1.ProwJob, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second) // TODO: pass through a global context?
+	ctx, cancel := context.WithTimeout(gr.ctx, gr.uploadTimeout)
 	defer cancel()
 
 	_, _, err := util.GetJobDestination(gr.cfg, pj)
 	if err != nil {
 		gr.logger.Infof("Not uploading %q (%s#%s) because we couldn't find a destination: %v", pj.Name, pj.Spec.Job, pj.Status.BuildID, err)
+		jobsWithoutDestination.WithLabelValues(string(pj.Spec.Type)).Inc()
 		return []*prowv1.ProwJob{pj}, nil
 	}
-	stateErr := gr.reportJobState(ctx, pj)
-	prowjobErr := gr.reportProwjob(ctx, pj)
+	run := gr
+	var recorder *recordingAuthor
+	if gr.writeManifest {
+		// record the objects written in this run so that we can list them in the manifest
+		recorder = &recordingAuthor{author: gr.author}
+		recording := *gr
+		recording.author = recorder
+		run = &recording
+	}
+	// every upload is independent, so do them all at once; they are all bounded by ctx
+	var stateErr, prowjobErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		stateErr = run.reportJobState(ctx, pj)
+	}()
+	go func() {
+		defer wg.Done()
+		prowjobErr = run.reportProwjob(ctx, pj)
+	}()
+	wg.Wait()
+	var manifestErr error
+	if recorder != nil {
+		manifestErr = gr.reportManifest(ctx, pj, recorder.objects)
+	}
 
-	return []*prowv1.ProwJob{pj}, errorutil.NewAggregate(stateErr, prowjobErr)
+	return []*prowv1.ProwJob{pj}, errorutil.NewAggregate(stateErr, prowjobErr, manifestErr)
 }
 
 func (gr *gcsReporter) reportJobState(ctx context.Context, pj *prowv1.ProwJob) error {
-	startedErr := gr.reportStartedJob(ctx, pj)
-	var finishedErr error
+	var startedErr, finishedErr, latestErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		startedErr = gr.reportStartedJob(ctx, pj)
+	}()
 	if pj.Complete() {
-		finishedErr = gr.reportFinishedJob(ctx, pj)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			finishedErr = gr.reportFinishedJob(ctx, pj)
+			if gr.writeLatestPassing {
+				latestErr = gr.reportLatestPassingBuild(ctx, pj)
+			}
+		}()
 	}
-	return errorutil.NewAggregate(startedErr, finishedErr)
+	wg.Wait()
+	return errorutil.NewAggregate(startedErr, finishedErr, latestErr)
 }
 
 // reportStartedJob uploads a started.json for the job. This will almost certainly
 // happen before the pod itself gets to upload one, at which point the pod will
-// upload its own. If for some reason one already exists, it will not be overwritten.
+// upload its own. If for some reason one already exists, it will not be overwritten,
+// unless crier uploaded it and overwriteCrierStarted is set.
 func (gr *gcsReporter) reportStartedJob(ctx context.Context, pj *prowv1.ProwJob) error {
 	s := metadata.Started{
 		Timestamp: pj.Status.StartTime.Unix(),
-		Metadata:  metadata.Metadata{"uploader": "crier"},
+		Metadata:  metadata.Metadata{"uploader": "crier", "crier-version": gr.version},
+	}
+	for _, key := range gr.startedMetadataKeys {
+		if _, reserved := s.Metadata[key]; reserved {
+			continue
+		}
+		// annotations take precedence over labels with the same key
+		if value, ok := pj.Annotations[key]; ok {
+			s.Metadata[key] = value
+		} else if value, ok := pj.Labels[key]; ok {
+			s.Metadata[key] = value
+		}
 	}
 	output, err := json.Marshal(s)
 	if err != nil {
 		return fmt.Errorf("failed to marshal started metadata: %v", err)
 	}
 
-	bucketName, dir, err := util.GetJobDestination(gr.cfg, pj)
+	bucketName, dir, err := gr.jobDestination(pj)
 	if err != nil {
 		return fmt.Errorf("failed to get job destination: %v", err)
 	}
@@ -89,7 +206,30 @@ func (gr *gcsReporter) reportStartedJob(ctx context.Context, pj *prowv1.ProwJob)
 		gr.logger.Infof("Would upload started.json to %q/%q", bucketName, dir)
 		return nil
 	}
-	return util.WriteContent(ctx, gr.logger, gr.author, bucketName, path.Join(dir, "started.json"), false, output)
+	return gr.writeWithRetries(ctx, "started", func() error {
+		if gr.overwriteCrierStarted && gr.replacer != nil {
+			return gr.replaceCrierStarted(ctx, bucketName, path.Join(dir, "started.json"), output)
+		}
+		return util.WriteContent(ctx, gr.logger, gr.author, bucketName, path.Join(dir, "started.json"), false, output)
+	})
+}
+
+// replaceCrierStarted uploads started.json, replacing an existing one only if crier
+// uploaded it. One uploaded by the pod is never replaced, even if the pod uploads it
+// between us reading and writing the object.
+func (gr *gcsReporter) replaceCrierStarted(ctx context.Context, bucketName, name string, content []byte) error {
+	existing, generation, err := gr.replacer.ReadObject(ctx, bucketName, name)
+	if err == storage.ErrObjectNotExist {
+		return util.WriteContent(ctx, gr.logger, gr.author, bucketName, name, false, content)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read existing started.json: %v", err)
+	}
+	var started metadata.Started
+	if err := json.Unmarshal(existing, &started); err != nil || started.Metadata["uploader"] != "crier" {
+		return nil
+	}
+	return util.ReplaceContent(ctx, gr.logger, gr.replacer, bucketName, name, generation, content)
 }
 
 // reportFinishedJob uploads a finished.json for the job, iff one did not already exist.
@@ -98,19 +238,23 @@ func (gr *gcsReporter) reportFinishedJob(ctx context.Context, pj *prowv1.ProwJob
 		return errors.New("cannot report finished.json for incomplete job")
 	}
 	completion := pj.Status.CompletionTime.Unix()
-	passed := pj.Status.State == prowv1.SuccessState
+	result := testgridResult(pj.Status.State)
+	passed := result == "SUCCESS"
 	f := metadata.Finished{
 		Timestamp: &completion,
 		Passed:    &passed,
-		Metadata:  metadata.Metadata{"uploader": "crier"},
-		Result:    string(pj.Status.State),
+		Metadata:  metadata.Metadata{"uploader": "crier", "crier-version": gr.version},
+		Result:    result,
+	}
+	if duration, ok := durationSeconds(pj); ok {
+		f.Metadata["duration_seconds"] = duration
 	}
 	output, err := json.Marshal(f)
 	if err != nil {
 		return fmt.Errorf("failed to marshal finished metadata: %v", err)
 	}
 
-	bucketName, dir, err := util.GetJobDestination(gr.cfg, pj)
+	bucketName, dir, err := gr.jobDestination(pj)
 	if err != nil {
 		return fmt.Errorf("failed to get job destination: %v", err)
 	}
@@ -119,7 +263,56 @@ func (gr *gcsReporter) reportFinishedJob(ctx context.Context, pj *prowv1.ProwJob
 		gr.logger.Infof("Would upload finished.json info to %q/%q", bucketName, dir)
 		return nil
 	}
-	return util.WriteContent(ctx, gr.logger, gr.author, bucketName, path.Join(dir, "finished.json"), false, output)
+	// Not overwriting makes the write conditional on the object not existing, so
+	// that if several replicas report the same job only the first one writes it.
+	return gr.writeWithRetries(ctx, "finished", func() error {
+		return util.WriteContent(ctx, gr.logger, gr.author, bucketName, path.Join(dir, "finished.json"), false, output)
+	})
+}
+
+// durationSeconds is how many seconds a completed job ran for. There is no duration
+// if the job has no start time, or if it appears to have completed before it started.
+func durationSeconds(pj *prowv1.ProwJob) (int64, bool) {
+	if pj.Status.StartTime.IsZero() || pj.Status.CompletionTime == nil || pj.Status.CompletionTime.Time.Before(pj.Status.StartTime.Time) {
+		return 0, false
+	}
+	return int64(pj.Status.CompletionTime.Sub(pj.Status.StartTime.Time).Seconds()), true
+}
+
+// testgridResult maps the state of a completed job to the result testgrid
+// expects in finished.json, matching what the sidecar writes: SUCCESS for
+// successful jobs, ABORTED for aborted ones and FAILURE for failed or errored
+// ones. Jobs that haven't completed have no result.
+func testgridResult(state prowv1.ProwJobState) string {
+	switch state {
+	case prowv1.SuccessState:
+		return "SUCCESS"
+	case prowv1.AbortedState:
+		return "ABORTED"
+	case prowv1.FailureState, prowv1.ErrorState:
+		return "FAILURE"
+	default:
+		return ""
+	}
+}
+
+// reportLatestPassingBuild points latest-passing-build.txt in the job's root
+// directory at this build, iff the job succeeded.
+func (gr *gcsReporter) reportLatestPassingBuild(ctx context.Context, pj *prowv1.ProwJob) error {
+	if pj.Status.State != prowv1.SuccessState {
+		return nil
+	}
+
+	bucketName, dir, err := gr.jobDestination(pj)
+	if err != nil {
+		return fmt.Errorf("failed to get job destination: %v", err)
+	}
+
+	if gr.dryRun {
+		gr.logger.Infof("Would upload latest-passing-build.txt to %q/%q", bucketName, path.Dir(dir))
+		return nil
+	}
+	return util.WriteContent(ctx, gr.logger, gr.author, bucketName, path.Join(path.Dir(dir), "latest-passing-build.txt"), true, []byte(pj.Status.BuildID))
 }
 
 func (gr *gcsReporter) reportProwjob(ctx context.Context, pj *prowv1.ProwJob) error {
@@ -129,16 +322,226 @@ func (gr *gcsReporter) reportProwjob(ctx context.Context, pj *prowv1.ProwJob) er
 		return fmt.Errorf("failed to marshal prowjob: %v", err)
 	}
 
-	bucketName, dir, err := util.GetJobDestination(gr.cfg, pj)
+	bucketName, dir, err := gr.jobDestination(pj)
 	if err != nil {
 		return fmt.Errorf("failed to get job destination: %v", err)
 	}
 
+	if gr.terminalProwjobsOnly && !pj.Complete() && gr.uploadedProwjobs.has(pj.Name) {
+		gr.logger.Debugf("Not uploading prowjob.json for %q again until it completes", pj.Name)
+		return nil
+	}
+
 	if gr.dryRun {
 		gr.logger.Infof("Would upload pod info to %q/%q", bucketName, dir)
 		return nil
 	}
-	return util.WriteContent(ctx, gr.logger, gr.author, bucketName, path.Join(dir, "prowjob.json"), true, output)
+
+	var contentEncoding string
+	if gr.prowjobGzipThreshold > 0 && len(output) > gr.prowjobGzipThreshold {
+		compressed, err := gzipContent(output)
+		if err != nil {
+			return fmt.Errorf("failed to compress prowjob: %v", err)
+		}
+		output = compressed
+		contentEncoding = "gzip"
+	}
+	if err := gr.writeWithRetries(ctx, "prowjob", func() error {
+		return util.WriteEncodedContent(ctx, gr.logger, gr.author, bucketName, path.Join(dir, "prowjob.json"), true, contentEncoding, output)
+	}); err != nil {
+		return err
+	}
+	// only remember jobs once uploaded, so that a failed upload is tried again
+	if gr.terminalProwjobsOnly {
+		if pj.Complete() {
+			gr.uploadedProwjobs.delete(pj.Name)
+		} else {
+			gr.uploadedProwjobs.insert(pj.Name)
+		}
+	}
+	return nil
+}
+
+// prowjobSet is a set of prowjob names that is safe for concurrent use.
+// Names expire after ttl, so that jobs which are deleted before they complete,
+// and are therefore never reported again, are not remembered forever. Expired
+// names are pruned at most once per ttl, to keep inserts cheap.
+type prowjobSet struct {
+	lock       sync.Mutex
+	names      map[string]time.Time
+	ttl        time.Duration
+	lastPruned time.Time
+	now        func() time.Time
+}
+
+func newProwjobSet(ttl time.Duration) *prowjobSet {
+	return &prowjobSet{names: map[string]time.Time{}, ttl: ttl, now: time.Now}
+}
+
+func (s *prowjobSet) has(name string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	inserted, ok := s.names[name]
+	return ok && s.now().Sub(inserted) <= s.ttl
+}
+
+func (s *prowjobSet) insert(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := s.now()
+	if now.Sub(s.lastPruned) >= s.ttl {
+		for existing, inserted := range s.names {
+			if now.Sub(inserted) > s.ttl {
+				delete(s.names, existing)
+			}
+		}
+		s.lastPruned = now
+	}
+	s.names[name] = now
+}
+
+func (s *prowjobSet) delete(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.names, name)
+}
+
+// writeWithRetries calls write, retrying it up to writeRetries times with
+// exponential backoff for as long as it fails in a way that may be transient.
+// The duration and failures of every attempt are recorded for the artifact.
+func (gr *gcsReporter) writeWithRetries(ctx context.Context, artifact string, write func() error) error {
+	backoff := gr.retryBackoff
+	for retry := 0; ; retry++ {
+		start := time.Now()
+		err := write()
+		uploadDurations.WithLabelValues(artifact).Observe(time.Since(start).Seconds())
+		if err != nil {
+			uploadFailures.WithLabelValues(artifact).Inc()
+		}
+		if err == nil || retry >= gr.writeRetries || !util.IsErrRetryable(err) {
+			return err
+		}
+		gr.logger.WithError(err).Infof("Write failed, retrying in %v", backoff)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func gzipContent(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// manifest lists the objects that were written to GCS for a job by one run of the reporter.
+type manifest struct {
+	Objects []manifestObject `json:"objects"`
+}
+
+type manifestObject struct {
+	// Name is the full name of the object in the bucket
+	Name string `json:"name"`
+	// Size is the number of bytes written, after any compression
+	Size int64 `json:"size"`
+	// Timestamp is when the write completed, in seconds since the epoch
+	Timestamp int64 `json:"timestamp"`
+}
+
+// reportManifest uploads a manifest.json listing the objects, overwriting any
+// manifest written by a previous run.
+func (gr *gcsReporter) reportManifest(ctx context.Context, pj *prowv1.ProwJob, objects []manifestObject) error {
+	bucketName, dir, err := gr.jobDestination(pj)
+	if err != nil {
+		return fmt.Errorf("failed to get job destination: %v", err)
+	}
+
+	if gr.dryRun {
+		gr.logger.Infof("Would upload manifest.json to %q/%q", bucketName, dir)
+		return nil
+	}
+
+	if objects == nil {
+		objects = []manifestObject{}
+	}
+	// objects are written concurrently, so list them in a stable order
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Name < objects[j].Name
+	})
+	output, err := json.Marshal(manifest{Objects: objects})
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %v", err)
+	}
+	return util.WriteContent(ctx, gr.logger, gr.author, bucketName, path.Join(dir, "manifest.json"), true, output)
+}
+
+// recordingAuthor records the objects that were successfully written with it.
+type recordingAuthor struct {
+	author util.Author
+
+	lock    sync.Mutex
+	objects []manifestObject
+}
+
+func (ra *recordingAuthor) NewWriter(ctx context.Context, bucket, path string, overwrite bool, contentEncoding string) io.WriteCloser {
+	return &recordingWriter{
+		WriteCloser: ra.author.NewWriter(ctx, bucket, path, overwrite, contentEncoding),
+		author:      ra,
+		path:        path,
+	}
+}
+
+type recordingWriter struct {
+	io.WriteCloser
+	author *recordingAuthor
+	path   string
+	size   int64
+}
+
+func (rw *recordingWriter) Write(p []byte) (int, error) {
+	n, err := rw.WriteCloser.Write(p)
+	rw.size += int64(n)
+	return n, err
+}
+
+func (rw *recordingWriter) Close() error {
+	if err := rw.WriteCloser.Close(); err != nil {
+		return err
+	}
+	rw.author.lock.Lock()
+	defer rw.author.lock.Unlock()
+	rw.author.objects = append(rw.author.objects, manifestObject{
+		Name:      rw.path,
+		Size:      rw.size,
+		Timestamp: time.Now().Unix(),
+	})
+	return nil
+}
+
+// jobDestination determines the bucket and the directory that the artifacts
+// of the job are uploaded to, namespaced under the object prefix if one is set
+func (gr *gcsReporter) jobDestination(pj *prowv1.ProwJob) (string, string, error) {
+	bucketName, dir, err := util.GetJobDestination(gr.cfg, pj)
+	if err != nil {
+		return "", "", err
+	}
+	return bucketName, path.Join(gr.objectPrefix, dir), nil
+}
+
+// JobDestination determines the bucket and the directory that the GCS reporter
+// uploads the artifacts of the job to, so that other tools can find them. It does
+// not know about any object prefix the reporter was configured with.
+func JobDestination(cfg config.Getter, pj *prowv1.ProwJob) (bucket, dir string, err error) {
+	return util.GetJobDestination(cfg, pj)
 }
 
 func (gr *gcsReporter) GetName() string {
@@ -146,14 +549,90 @@ func (gr *gcsReporter) GetName() string {
 }
 
 func (gr *gcsReporter) ShouldReport(pj *prowv1.ProwJob) bool {
+	// Jobs that are filtered out are not our responsibility at all.
+	if gr.filter.excludes(pj) {
+		return false
+	}
 	// We can only report jobs once they have a build ID. By denying responsibility
 	// for it until it has one, crier will not mark us as having handled it until
 	// it is possible for us to handle it, ensuring that we get a chance to see it.
 	return pj.Status.BuildID != ""
 }
 
-func New(cfg config.Getter, storage *storage.Client, dryRun bool) *gcsReporter {
-	return newWithAuthor(cfg, util.StorageAuthor{Client: storage}, dryRun)
+// Author creates the uploaded objects; see util.Author for the contract that
+// implementations for object stores other than GCS must follow.
+type Author = util.Author
+
+// Options configure a GCS reporter. The zero value reports every job with the
+// default timeout and version, without retries.
+type Options struct {
+	// WriteLatestPassing determines whether latest-passing-build.txt in the root of the
+	// job is updated for passing jobs; latest-build.txt is not affected.
+	WriteLatestPassing bool
+	// ProwJobGzipThreshold is the size in bytes above which prowjob.json is gzipped;
+	// zero disables compression.
+	ProwJobGzipThreshold int
+	// WriteManifest determines whether a manifest.json listing the uploaded objects
+	// is written on every report.
+	WriteManifest bool
+	// ObjectPrefix is prepended to the paths of all uploaded objects.
+	ObjectPrefix string
+	// UploadTimeout bounds the uploads for a report; a non-positive timeout means
+	// the default of 10s.
+	UploadTimeout time.Duration
+	// WriteRetries is the number of times writes failing in a way that may be
+	// transient are retried.
+	WriteRetries int
+	// OverwriteCrierStarted determines whether a started.json that crier uploaded
+	// before is replaced.
+	OverwriteCrierStarted bool
+	// TerminalProwJobsOnly restricts uploads of prowjob.json to the first time a
+	// running job is seen and to its completion.
+	TerminalProwJobsOnly bool
+	// StartedMetadataKeys are the prowjob labels and annotations copied into the
+	// metadata of started.json.
+	StartedMetadataKeys []string
+	// Filter excludes jobs from being reported.
+	Filter JobFilter
+	// Version is the version of the reporter recorded in the uploaded files; empty
+	// means the default.
+	Version string
+	// DryRun determines whether uploads are only logged.
+	DryRun bool
+}
+
+// New creates a GCS reporter. Uploads for a report are cancelled along with ctx,
+// or once the upload timeout of the options passes.
+func New(ctx context.Context, cfg config.Getter, storage *storage.Client, opts Options) *gcsReporter {
+	author := util.StorageAuthor{Client: storage}
+	return NewWithAuthor(ctx, cfg, author, opts)
+}
+
+// NewWithAuthor is like New, but uploads with an already configured author, for
+// example one writing to an S3-compatible store. Replacing a started.json uploaded
+// by crier is only possible if the author also implements util.GenerationAuthor.
+func NewWithAuthor(ctx context.Context, cfg config.Getter, author Author, opts Options) *gcsReporter {
+	gr := newWithAuthor(cfg, author, opts.DryRun)
+	gr.writeLatestPassing = opts.WriteLatestPassing
+	gr.prowjobGzipThreshold = opts.ProwJobGzipThreshold
+	gr.writeManifest = opts.WriteManifest
+	gr.objectPrefix = opts.ObjectPrefix
+	gr.ctx = ctx
+	gr.writeRetries = opts.WriteRetries
+	gr.overwriteCrierStarted = opts.OverwriteCrierStarted
+	if replacer, ok := author.(util.GenerationAuthor); ok {
+		gr.replacer = replacer
+	}
+	gr.terminalProwjobsOnly = opts.TerminalProwJobsOnly
+	gr.startedMetadataKeys = opts.StartedMetadataKeys
+	gr.filter = opts.Filter
+	if opts.UploadTimeout > 0 {
+		gr.uploadTimeout = opts.UploadTimeout
+	}
+	if opts.Version != "" {
+		gr.version = opts.Version
+	}
+	return gr
 }
 
 func newWithAuthor(cfg config.Getter, author util.Author, dryRun bool) *gcsReporter {
@@ -162,5 +641,12 @@ func newWithAuthor(cfg config.Getter, author util.Author, dryRun bool) *gcsRepor
 		dryRun: dryRun,
 		logger: logrus.WithField("component", reporterName),
 		author: author,
+
+		ctx:           context.Background(),
+		uploadTimeout: defaultUploadTimeout,
+		retryBackoff:  defaultRetryBackoff,
+		version:       defaultVersion,
+
+		uploadedProwjobs: newProwjobSet(uploadedProwjobTTL),
 	}
 }
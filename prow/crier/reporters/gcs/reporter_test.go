@@ -17,15 +17,27 @@ limitations under the License.
 package gcs
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"cloud.google.com/go/storage"
 	"github.com/GoogleCloudPlatform/testgrid/metadata"
 	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/api/googleapi"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config"
@@ -38,6 +50,7 @@ func TestReportJobFinished(t *testing.T) {
 		jobState       prowv1.ProwJobState
 		completionTime *metav1.Time
 		passed         bool
+		result         string
 		expectErr      bool
 	}{
 		{
@@ -52,18 +65,22 @@ func TestReportJobFinished(t *testing.T) {
 			jobState:       prowv1.SuccessState,
 			completionTime: completionTime,
 			passed:         true,
+			result:         "SUCCESS",
 		},
 		{
 			jobState:       prowv1.AbortedState,
 			completionTime: completionTime,
+			result:         "ABORTED",
 		},
 		{
 			jobState:       prowv1.ErrorState,
 			completionTime: completionTime,
+			result:         "FAILURE",
 		},
 		{
 			jobState:       prowv1.FailureState,
 			completionTime: completionTime,
+			result:         "FAILURE",
 		},
 	}
 	for _, tc := range tests {
@@ -138,6 +155,80 @@ func TestReportJobFinished(t *testing.T) {
 			} else if *result.Passed != tc.passed {
 				t.Errorf("Expected finished.json passed to be %v, but got %v", tc.passed, *result.Passed)
 			}
+			if result.Result != tc.result {
+				t.Errorf("Expected finished.json result to be %q, but got %q", tc.result, result.Result)
+			}
+			if version := result.Metadata["crier-version"]; version != defaultVersion {
+				t.Errorf("Expected finished.json crier version to be %q, but got %v", defaultVersion, version)
+			}
+		})
+	}
+}
+
+func TestTestgridResult(t *testing.T) {
+	tests := []struct {
+		state    prowv1.ProwJobState
+		expected string
+	}{
+		{state: prowv1.TriggeredState, expected: ""},
+		{state: prowv1.PendingState, expected: ""},
+		{state: prowv1.SuccessState, expected: "SUCCESS"},
+		{state: prowv1.FailureState, expected: "FAILURE"},
+		{state: prowv1.AbortedState, expected: "ABORTED"},
+		{state: prowv1.ErrorState, expected: "FAILURE"},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.state), func(t *testing.T) {
+			if actual := testgridResult(tc.state); actual != tc.expected {
+				t.Errorf("Expected result %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDurationSeconds(t *testing.T) {
+	startTime := metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)}
+	tests := []struct {
+		name           string
+		startTime      metav1.Time
+		completionTime *metav1.Time
+		expected       int64
+		expectDuration bool
+	}{
+		{
+			name:           "completed job has a duration",
+			startTime:      startTime,
+			completionTime: &metav1.Time{Time: time.Date(2010, 10, 10, 19, 00, 0, 0, time.UTC)},
+			expected:       1800,
+			expectDuration: true,
+		},
+		{
+			name:           "incomplete job has no duration",
+			startTime:      startTime,
+			expectDuration: false,
+		},
+		{
+			name:           "job without a start time has no duration",
+			completionTime: &metav1.Time{Time: time.Date(2010, 10, 10, 19, 00, 0, 0, time.UTC)},
+			expectDuration: false,
+		},
+		{
+			name:           "job completed before it started has no duration",
+			startTime:      startTime,
+			completionTime: &metav1.Time{Time: time.Date(2010, 10, 10, 18, 00, 0, 0, time.UTC)},
+			expectDuration: false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pj := &prowv1.ProwJob{Status: prowv1.ProwJobStatus{StartTime: tc.startTime, CompletionTime: tc.completionTime}}
+			actual, ok := durationSeconds(pj)
+			if ok != tc.expectDuration {
+				t.Fatalf("Expected having a duration to be %v, but got %v", tc.expectDuration, ok)
+			}
+			if actual != tc.expected {
+				t.Errorf("Expected duration %d, but got %d", tc.expected, actual)
+			}
 		})
 	}
 }
@@ -203,6 +294,104 @@ func TestReportJobStarted(t *testing.T) {
 			if result.Timestamp != pj.Status.StartTime.Unix() {
 				t.Errorf("Expected started.json timestamp to be %d, but got %d", pj.Status.StartTime.Unix(), result.Timestamp)
 			}
+			if version := result.Metadata["crier-version"]; version != defaultVersion {
+				t.Errorf("Expected started.json crier version to be %q, but got %v", defaultVersion, version)
+			}
+		})
+	}
+}
+
+func TestStartedMetadataKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		keys        []string
+		labels      map[string]string
+		annotations map[string]string
+		expected    metadata.Metadata
+	}{
+		{
+			name:     "no keys configured copies nothing",
+			labels:   map[string]string{"git-sha": "abc123"},
+			expected: metadata.Metadata{"uploader": "crier", "crier-version": defaultVersion},
+		},
+		{
+			name:        "labels and annotations are copied",
+			keys:        []string{"git-sha", "release"},
+			labels:      map[string]string{"git-sha": "abc123"},
+			annotations: map[string]string{"release": "v1.2.3"},
+			expected:    metadata.Metadata{"uploader": "crier", "crier-version": defaultVersion, "git-sha": "abc123", "release": "v1.2.3"},
+		},
+		{
+			name:     "missing keys are skipped",
+			keys:     []string{"git-sha", "release"},
+			labels:   map[string]string{"git-sha": "abc123"},
+			expected: metadata.Metadata{"uploader": "crier", "crier-version": defaultVersion, "git-sha": "abc123"},
+		},
+		{
+			name:        "annotations take precedence over labels",
+			keys:        []string{"release"},
+			labels:      map[string]string{"release": "from-label"},
+			annotations: map[string]string{"release": "from-annotation"},
+			expected:    metadata.Metadata{"uploader": "crier", "crier-version": defaultVersion, "release": "from-annotation"},
+		},
+		{
+			name:        "reserved keys are not overwritten",
+			keys:        []string{"uploader", "crier-version"},
+			labels:      map[string]string{"uploader": "someone-else"},
+			annotations: map[string]string{"crier-version": "v0.0.0"},
+			expected:    metadata.Metadata{"uploader": "crier", "crier-version": defaultVersion},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testutil.Fca{C: config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{
+						DefaultDecorationConfigs: map[string]*prowv1.DecorationConfig{"*": {
+							GCSConfiguration: &prowv1.GCSConfiguration{
+								Bucket:       "kubernetes-jenkins",
+								PathPrefix:   "some-prefix",
+								PathStrategy: prowv1.PathStrategyLegacy,
+								DefaultOrg:   "kubernetes",
+								DefaultRepo:  "kubernetes",
+							},
+						}},
+					},
+				},
+			}}.Config
+			ta := &testutil.TestAuthor{}
+			reporter := newWithAuthor(cfg, ta, false)
+			reporter.startedMetadataKeys = tc.keys
+
+			pj := &prowv1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      tc.labels,
+					Annotations: tc.annotations,
+				},
+				Spec: prowv1.ProwJobSpec{
+					Type:  prowv1.PeriodicJob,
+					Agent: prowv1.KubernetesAgent,
+					Job:   "my-little-job",
+				},
+				Status: prowv1.ProwJobStatus{
+					State:     prowv1.PendingState,
+					StartTime: metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)},
+					BuildID:   "123",
+				},
+			}
+
+			if err := reporter.reportStartedJob(context.Background(), pj); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result metadata.Started
+			if err := json.Unmarshal(ta.Content, &result); err != nil {
+				t.Fatalf("Couldn't decode result as metadata.Started: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, result.Metadata); diff != "" {
+				t.Errorf("Unexpected started.json metadata (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
@@ -259,6 +448,10 @@ func TestReportProwJob(t *testing.T) {
 		t.Errorf("Expected prowjob.json to be written with overwrite enabled, but it was not.")
 	}
 
+	if ta.ContentEncoding != "" {
+		t.Errorf("Expected prowjob.json to be written without a content encoding, but got %q", ta.ContentEncoding)
+	}
+
 	var result prowv1.ProwJob
 	if err := json.Unmarshal(ta.Content, &result); err != nil {
 		t.Fatalf("Couldn't unmarshal prowjob.json: %v", err)
@@ -268,21 +461,1093 @@ func TestReportProwJob(t *testing.T) {
 	}
 }
 
-func TestShouldReport(t *testing.T) {
+func TestTerminalProwjobsOnly(t *testing.T) {
 	tests := []struct {
-		name         string
-		buildID      string
-		shouldReport bool
+		name                 string
+		terminalProwjobsOnly bool
+		expectedUploads      int
 	}{
 		{
-			name:         "tests with a build ID should be reported",
-			buildID:      "123",
-			shouldReport: true,
+			name:            "by default every update is uploaded",
+			expectedUploads: 4,
 		},
 		{
-			name:         "tests without a build ID should not be reported",
-			buildID:      "",
-			shouldReport: false,
+			name:                 "only the first and terminal updates are uploaded",
+			terminalProwjobsOnly: true,
+			expectedUploads:      2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testutil.Fca{C: config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{
+						DefaultDecorationConfigs: map[string]*prowv1.DecorationConfig{"*": {
+							GCSConfiguration: &prowv1.GCSConfiguration{
+								Bucket:       "kubernetes-jenkins",
+								PathPrefix:   "some-prefix",
+								PathStrategy: prowv1.PathStrategyLegacy,
+								DefaultOrg:   "kubernetes",
+								DefaultRepo:  "kubernetes",
+							},
+						}},
+					},
+				},
+			}}.Config
+			fa := &flakyAuthor{}
+			reporter := newWithAuthor(cfg, fa, false)
+			reporter.terminalProwjobsOnly = tc.terminalProwjobsOnly
+
+			pj := &prowv1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-prowjob"},
+				Spec: prowv1.ProwJobSpec{
+					Type:  prowv1.PeriodicJob,
+					Agent: prowv1.KubernetesAgent,
+					Job:   "my-little-job",
+				},
+				Status: prowv1.ProwJobStatus{
+					State:     prowv1.TriggeredState,
+					StartTime: metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)},
+					BuildID:   "123",
+				},
+			}
+			report := func() {
+				if err := reporter.reportProwjob(context.Background(), pj); err != nil {
+					t.Fatalf("Unexpected error calling reportProwjob: %v", err)
+				}
+			}
+
+			report()
+			if tracked := reporter.uploadedProwjobs.has(pj.Name); tracked != tc.terminalProwjobsOnly {
+				t.Errorf("Expected the running prowjob to be remembered only when uploading terminal prowjobs, but remembered=%t", tracked)
+			}
+			pj.Status.State = prowv1.PendingState
+			pj.Status.PodName = "some-pod"
+			report()
+			pj.Status.Description = "still running"
+			report()
+			pj.Status.State = prowv1.SuccessState
+			pj.Status.CompletionTime = &metav1.Time{Time: time.Date(2010, 10, 10, 19, 00, 0, 0, time.UTC)}
+			report()
+
+			if fa.attempts != tc.expectedUploads {
+				t.Errorf("Expected prowjob.json to be uploaded %d times, but it was uploaded %d times", tc.expectedUploads, fa.attempts)
+			}
+		})
+	}
+}
+
+func TestProwjobSetExpiry(t *testing.T) {
+	now := time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)
+	set := newProwjobSet(time.Hour)
+	set.now = func() time.Time { return now }
+
+	set.insert("deleted-prowjob")
+	if !set.has("deleted-prowjob") {
+		t.Fatal("Expected a freshly inserted prowjob to be in the set")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if set.has("deleted-prowjob") {
+		t.Error("Expected an expired prowjob not to be in the set")
+	}
+	set.insert("other-prowjob")
+	if _, ok := set.names["deleted-prowjob"]; ok {
+		t.Error("Expected inserting a prowjob to forget expired prowjobs")
+	}
+
+	pruned := set.lastPruned
+	now = now.Add(30 * time.Minute)
+	set.insert("third-prowjob")
+	if !set.lastPruned.Equal(pruned) {
+		t.Error("Expected prowjobs not to be pruned again until a ttl has passed since they were last pruned")
+	}
+	if !set.has("other-prowjob") {
+		t.Error("Expected the newly inserted prowjob to be in the set")
+	}
+}
+
+func TestReportProwJobGzipThreshold(t *testing.T) {
+	pj := &prowv1.ProwJob{
+		Spec: prowv1.ProwJobSpec{
+			Type:  prowv1.PeriodicJob,
+			Agent: prowv1.KubernetesAgent,
+			Job:   "my-little-job",
+		},
+		Status: prowv1.ProwJobStatus{
+			State:     prowv1.PendingState,
+			StartTime: metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)},
+			PodName:   "some-pod",
+			BuildID:   "123",
+		},
+	}
+	marshaled, err := json.Marshal(pj)
+	if err != nil {
+		t.Fatalf("Failed to marshal prowjob: %v", err)
+	}
+	size := len(marshaled)
+
+	tests := []struct {
+		name           string
+		threshold      int
+		expectCompress bool
+	}{
+		{
+			name:      "zero threshold disables compression",
+			threshold: 0,
+		},
+		{
+			name:      "prowjob smaller than threshold is not compressed",
+			threshold: size + 1,
+		},
+		{
+			name:      "prowjob exactly at threshold is not compressed",
+			threshold: size,
+		},
+		{
+			name:           "prowjob one byte above threshold is compressed",
+			threshold:      size - 1,
+			expectCompress: true,
+		},
+		{
+			name:           "prowjob far above threshold is compressed",
+			threshold:      1,
+			expectCompress: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testutil.Fca{C: config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{
+						DefaultDecorationConfigs: map[string]*prowv1.DecorationConfig{"*": {
+							GCSConfiguration: &prowv1.GCSConfiguration{
+								Bucket:       "kubernetes-jenkins",
+								PathPrefix:   "some-prefix",
+								PathStrategy: prowv1.PathStrategyLegacy,
+								DefaultOrg:   "kubernetes",
+								DefaultRepo:  "kubernetes",
+							},
+						}},
+					},
+				},
+			}}.Config
+			ta := &testutil.TestAuthor{}
+			reporter := newWithAuthor(cfg, ta, false)
+			reporter.prowjobGzipThreshold = tc.threshold
+
+			if err := reporter.reportProwjob(ctx, pj); err != nil {
+				t.Fatalf("Unexpected error calling reportProwjob: %v", err)
+			}
+
+			content := ta.Content
+			if tc.expectCompress {
+				if ta.ContentEncoding != "gzip" {
+					t.Fatalf("Expected prowjob.json to be written with gzip encoding, but got %q", ta.ContentEncoding)
+				}
+				r, err := gzip.NewReader(bytes.NewReader(ta.Content))
+				if err != nil {
+					t.Fatalf("Couldn't read prowjob.json as gzip: %v", err)
+				}
+				if content, err = ioutil.ReadAll(r); err != nil {
+					t.Fatalf("Couldn't decompress prowjob.json: %v", err)
+				}
+			} else if ta.ContentEncoding != "" {
+				t.Fatalf("Expected prowjob.json to be written without a content encoding, but got %q", ta.ContentEncoding)
+			}
+
+			var result prowv1.ProwJob
+			if err := json.Unmarshal(content, &result); err != nil {
+				t.Fatalf("Couldn't unmarshal prowjob.json: %v", err)
+			}
+			if !cmp.Equal(*pj, result) {
+				t.Fatalf("Input prowjob mismatches output prowjob:\n%s", cmp.Diff(*pj, result))
+			}
+		})
+	}
+}
+
+func TestReportLatestPassingBuild(t *testing.T) {
+	tests := []struct {
+		name        string
+		state       prowv1.ProwJobState
+		expectWrite bool
+	}{
+		{
+			name:        "successful job updates the pointer",
+			state:       prowv1.SuccessState,
+			expectWrite: true,
+		},
+		{
+			name:  "failed job does not update the pointer",
+			state: prowv1.FailureState,
+		},
+		{
+			name:  "aborted job does not update the pointer",
+			state: prowv1.AbortedState,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testutil.Fca{C: config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{
+						DefaultDecorationConfigs: map[string]*prowv1.DecorationConfig{"*": {
+							GCSConfiguration: &prowv1.GCSConfiguration{
+								Bucket:       "kubernetes-jenkins",
+								PathPrefix:   "some-prefix",
+								PathStrategy: prowv1.PathStrategyLegacy,
+								DefaultOrg:   "kubernetes",
+								DefaultRepo:  "kubernetes",
+							},
+						}},
+					},
+				},
+			}}.Config
+			ta := &testutil.TestAuthor{}
+			reporter := newWithAuthor(cfg, ta, false)
+			reporter.writeLatestPassing = true
+
+			pj := &prowv1.ProwJob{
+				Spec: prowv1.ProwJobSpec{
+					Type:  prowv1.PeriodicJob,
+					Agent: prowv1.KubernetesAgent,
+					Job:   "my-little-job",
+				},
+				Status: prowv1.ProwJobStatus{
+					State:          tc.state,
+					StartTime:      metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)},
+					CompletionTime: &metav1.Time{Time: time.Date(2010, 10, 10, 19, 00, 0, 0, time.UTC)},
+					PodName:        "some-pod",
+					BuildID:        "123",
+				},
+			}
+
+			if err := reporter.reportLatestPassingBuild(ctx, pj); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !tc.expectWrite {
+				if ta.AlreadyUsed {
+					t.Errorf("Expected nothing to be written, but %q was written", ta.Path)
+				}
+				return
+			}
+			if expected := "some-prefix/logs/my-little-job/latest-passing-build.txt"; ta.Path != expected {
+				t.Errorf("Expected pointer to be written to %q, but got %q", expected, ta.Path)
+			}
+			if !ta.Overwrite {
+				t.Errorf("Expected pointer to be written with overwrite enabled, but it was not.")
+			}
+			if string(ta.Content) != "123" {
+				t.Errorf("Expected pointer to contain %q, but got %q", "123", string(ta.Content))
+			}
+		})
+	}
+}
+
+func TestReportManifest(t *testing.T) {
+	tests := []struct {
+		name          string
+		dryRun        bool
+		expectedNames []string
+	}{
+		{
+			name: "manifest lists all objects written",
+			expectedNames: []string{
+				"some-prefix/logs/my-little-job/123/finished.json",
+				"some-prefix/logs/my-little-job/123/prowjob.json",
+				"some-prefix/logs/my-little-job/123/started.json",
+			},
+		},
+		{
+			name:   "dry run writes nothing",
+			dryRun: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testutil.Fca{C: config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{
+						DefaultDecorationConfigs: map[string]*prowv1.DecorationConfig{"*": {
+							GCSConfiguration: &prowv1.GCSConfiguration{
+								Bucket:       "kubernetes-jenkins",
+								PathPrefix:   "some-prefix",
+								PathStrategy: prowv1.PathStrategyLegacy,
+								DefaultOrg:   "kubernetes",
+								DefaultRepo:  "kubernetes",
+							},
+						}},
+					},
+				},
+			}}.Config
+			ta := &testutil.TestMultiAuthor{}
+			reporter := newWithAuthor(cfg, ta, tc.dryRun)
+			reporter.writeManifest = true
+
+			pj := &prowv1.ProwJob{
+				Spec: prowv1.ProwJobSpec{
+					Type:  prowv1.PeriodicJob,
+					Agent: prowv1.KubernetesAgent,
+					Job:   "my-little-job",
+				},
+				Status: prowv1.ProwJobStatus{
+					State:          prowv1.SuccessState,
+					StartTime:      metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)},
+					CompletionTime: &metav1.Time{Time: time.Date(2010, 10, 10, 19, 00, 0, 0, time.UTC)},
+					PodName:        "some-pod",
+					BuildID:        "123",
+				},
+			}
+
+			if _, err := reporter.Report(pj); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if tc.dryRun {
+				if len(ta.Objects) != 0 {
+					t.Errorf("Expected nothing to be written in dry run, but got %d objects", len(ta.Objects))
+				}
+				return
+			}
+
+			content, ok := ta.Objects["some-prefix/logs/my-little-job/123/manifest.json"]
+			if !ok {
+				t.Fatalf("Expected manifest.json to be written, but it was not")
+			}
+			var result manifest
+			if err := json.Unmarshal(content, &result); err != nil {
+				t.Fatalf("Couldn't unmarshal manifest.json: %v", err)
+			}
+			var names []string
+			for _, object := range result.Objects {
+				names = append(names, object.Name)
+				if written := int64(len(ta.Objects[object.Name])); object.Size != written {
+					t.Errorf("Expected size of %q to be %d, but got %d", object.Name, written, object.Size)
+				}
+				if object.Timestamp == 0 {
+					t.Errorf("Expected %q to have a timestamp, but it did not", object.Name)
+				}
+			}
+			if !cmp.Equal(names, tc.expectedNames) {
+				t.Errorf("Manifest lists the wrong objects:\n%s", cmp.Diff(tc.expectedNames, names))
+			}
+		})
+	}
+}
+
+func TestNewUploadTimeout(t *testing.T) {
+	tests := []struct {
+		name          string
+		uploadTimeout time.Duration
+		expected      time.Duration
+	}{
+		{
+			name:     "no timeout uses the default",
+			expected: defaultUploadTimeout,
+		},
+		{
+			name:          "negative timeout uses the default",
+			uploadTimeout: -time.Second,
+			expected:      defaultUploadTimeout,
+		},
+		{
+			name:          "positive timeout is used",
+			uploadTimeout: time.Minute,
+			expected:      time.Minute,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			gr := New(ctx, testutil.Fca{}.Config, nil, Options{UploadTimeout: tc.uploadTimeout})
+			if gr.uploadTimeout != tc.expected {
+				t.Errorf("Expected upload timeout %v, but got %v", tc.expected, gr.uploadTimeout)
+			}
+			if gr.ctx != ctx {
+				t.Error("Expected the reporter to use the given context as the parent of its reports")
+			}
+		})
+	}
+}
+
+func TestNewWithAuthor(t *testing.T) {
+	type replacingAuthor struct {
+		*testutil.TestMultiAuthor
+		*fakeReplacer
+	}
+	tests := []struct {
+		name           string
+		author         Author
+		expectReplacer bool
+	}{
+		{
+			name:   "author that cannot replace objects leaves the replacer unset",
+			author: &testutil.TestMultiAuthor{},
+		},
+		{
+			name:           "author that can replace objects is used as the replacer",
+			author:         replacingAuthor{&testutil.TestMultiAuthor{}, &fakeReplacer{}},
+			expectReplacer: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gr := NewWithAuthor(context.Background(), testutil.Fca{}.Config, tc.author, Options{OverwriteCrierStarted: true})
+			if gr.author != tc.author {
+				t.Error("Expected the reporter to upload with the given author")
+			}
+			if hasReplacer := gr.replacer != nil; hasReplacer != tc.expectReplacer {
+				t.Errorf("Expected having a replacer to be %v, but got %v", tc.expectReplacer, hasReplacer)
+			}
+		})
+	}
+}
+
+func TestNewVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		expected string
+	}{
+		{
+			name:     "no version uses the default",
+			expected: defaultVersion,
+		},
+		{
+			name:     "given version is used",
+			version:  "v20201015-abcdef",
+			expected: "v20201015-abcdef",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gr := New(context.Background(), testutil.Fca{}.Config, nil, Options{Version: tc.version})
+			if gr.version != tc.expected {
+				t.Errorf("Expected version %q, but got %q", tc.expected, gr.version)
+			}
+		})
+	}
+}
+
+func TestJobDestination(t *testing.T) {
+	tests := []struct {
+		name         string
+		gcsConfig    *prowv1.GCSConfiguration
+		expectBucket string
+		expectDir    string
+		expectErr    bool
+	}{
+		{
+			name: "job with a GCS configuration has a destination",
+			gcsConfig: &prowv1.GCSConfiguration{
+				Bucket:       "kubernetes-jenkins",
+				PathPrefix:   "some-prefix",
+				PathStrategy: prowv1.PathStrategyLegacy,
+				DefaultOrg:   "kubernetes",
+				DefaultRepo:  "kubernetes",
+			},
+			expectBucket: "kubernetes-jenkins",
+			expectDir:    "some-prefix/logs/my-little-job/123",
+		},
+		{
+			name:      "job without a GCS configuration has no destination",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pj := &prowv1.ProwJob{
+				Spec: prowv1.ProwJobSpec{
+					Type:             prowv1.PeriodicJob,
+					Agent:            prowv1.KubernetesAgent,
+					Job:              "my-little-job",
+					DecorationConfig: &prowv1.DecorationConfig{GCSConfiguration: tc.gcsConfig},
+				},
+				Status: prowv1.ProwJobStatus{
+					State:     prowv1.PendingState,
+					StartTime: metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)},
+					BuildID:   "123",
+				},
+			}
+
+			bucket, dir, err := JobDestination(testutil.Fca{}.Config, pj)
+			if err != nil {
+				if !tc.expectErr {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			} else if tc.expectErr {
+				t.Fatalf("Expected an error, but didn't get one; instead got gs://%q/%q", bucket, dir)
+			}
+			if bucket != tc.expectBucket {
+				t.Errorf("Expected bucket %q, but got %q", tc.expectBucket, bucket)
+			}
+			if dir != tc.expectDir {
+				t.Errorf("Expected dir %q, but got %q", tc.expectDir, dir)
+			}
+		})
+	}
+}
+
+func TestObjectPrefix(t *testing.T) {
+	tests := []struct {
+		name          string
+		objectPrefix  string
+		expectedNames []string
+	}{
+		{
+			name: "no prefix writes to the job destination",
+			expectedNames: []string{
+				"some-prefix/logs/my-little-job/123/finished.json",
+				"some-prefix/logs/my-little-job/123/prowjob.json",
+				"some-prefix/logs/my-little-job/123/started.json",
+			},
+		},
+		{
+			name:         "prefix is prepended to every object",
+			objectPrefix: "instance-a",
+			expectedNames: []string{
+				"instance-a/some-prefix/logs/my-little-job/123/finished.json",
+				"instance-a/some-prefix/logs/my-little-job/123/prowjob.json",
+				"instance-a/some-prefix/logs/my-little-job/123/started.json",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testutil.Fca{C: config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{
+						DefaultDecorationConfigs: map[string]*prowv1.DecorationConfig{"*": {
+							GCSConfiguration: &prowv1.GCSConfiguration{
+								Bucket:       "kubernetes-jenkins",
+								PathPrefix:   "some-prefix",
+								PathStrategy: prowv1.PathStrategyLegacy,
+								DefaultOrg:   "kubernetes",
+								DefaultRepo:  "kubernetes",
+							},
+						}},
+					},
+				},
+			}}.Config
+			ta := &testutil.TestMultiAuthor{}
+			reporter := newWithAuthor(cfg, ta, false)
+			reporter.objectPrefix = tc.objectPrefix
+
+			pj := &prowv1.ProwJob{
+				Spec: prowv1.ProwJobSpec{
+					Type:  prowv1.PeriodicJob,
+					Agent: prowv1.KubernetesAgent,
+					Job:   "my-little-job",
+				},
+				Status: prowv1.ProwJobStatus{
+					State:          prowv1.SuccessState,
+					StartTime:      metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)},
+					CompletionTime: &metav1.Time{Time: time.Date(2010, 10, 10, 19, 00, 0, 0, time.UTC)},
+					PodName:        "some-pod",
+					BuildID:        "123",
+				},
+			}
+
+			if _, err := reporter.Report(pj); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var names []string
+			for name := range ta.Objects {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if !cmp.Equal(names, tc.expectedNames) {
+				t.Errorf("Wrote the wrong objects:\n%s", cmp.Diff(tc.expectedNames, names))
+			}
+		})
+	}
+}
+
+// flakyAuthor fails the first few writes with an error before writing like a TestMultiAuthor.
+type flakyAuthor struct {
+	testutil.TestMultiAuthor
+	failures int
+	err      error
+	attempts int
+}
+
+type failingWriteCloser struct {
+	err error
+}
+
+func (wc *failingWriteCloser) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (wc *failingWriteCloser) Close() error {
+	return wc.err
+}
+
+func (fa *flakyAuthor) NewWriter(ctx context.Context, bucket, path string, overwrite bool, contentEncoding string) io.WriteCloser {
+	fa.attempts++
+	if fa.attempts <= fa.failures {
+		return &failingWriteCloser{err: fa.err}
+	}
+	return fa.TestMultiAuthor.NewWriter(ctx, bucket, path, overwrite, contentEncoding)
+}
+
+func TestWriteRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		failures         int
+		err              error
+		writeRetries     int
+		expectedAttempts int
+		expectErr        bool
+	}{
+		{
+			name:             "successful write is not retried",
+			writeRetries:     3,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "transient failures are retried until the write succeeds",
+			failures:         2,
+			err:              &googleapi.Error{Code: http.StatusServiceUnavailable},
+			writeRetries:     3,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "transient failures are retried only as often as configured",
+			failures:         2,
+			err:              &googleapi.Error{Code: http.StatusServiceUnavailable},
+			writeRetries:     1,
+			expectedAttempts: 2,
+			expectErr:        true,
+		},
+		{
+			name:             "permanent failures are not retried",
+			failures:         1,
+			err:              &googleapi.Error{Code: http.StatusForbidden},
+			writeRetries:     3,
+			expectedAttempts: 1,
+			expectErr:        true,
+		},
+		{
+			name:             "existing objects are not retried",
+			failures:         1,
+			err:              &googleapi.Error{Code: http.StatusPreconditionFailed},
+			writeRetries:     3,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testutil.Fca{C: config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{
+						DefaultDecorationConfigs: map[string]*prowv1.DecorationConfig{"*": {
+							GCSConfiguration: &prowv1.GCSConfiguration{
+								Bucket:       "kubernetes-jenkins",
+								PathPrefix:   "some-prefix",
+								PathStrategy: prowv1.PathStrategyLegacy,
+								DefaultOrg:   "kubernetes",
+								DefaultRepo:  "kubernetes",
+							},
+						}},
+					},
+				},
+			}}.Config
+			fa := &flakyAuthor{failures: tc.failures, err: tc.err}
+			reporter := newWithAuthor(cfg, fa, false)
+			reporter.writeRetries = tc.writeRetries
+			reporter.retryBackoff = time.Millisecond
+
+			pj := &prowv1.ProwJob{
+				Spec: prowv1.ProwJobSpec{
+					Type:  prowv1.PeriodicJob,
+					Agent: prowv1.KubernetesAgent,
+					Job:   "my-little-job",
+				},
+				Status: prowv1.ProwJobStatus{
+					State:     prowv1.PendingState,
+					StartTime: metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)},
+					PodName:   "some-pod",
+					BuildID:   "123",
+				},
+			}
+
+			err := reporter.reportStartedJob(context.Background(), pj)
+			if tc.expectErr && err == nil {
+				t.Error("Expected an error, but didn't get one")
+			}
+			if !tc.expectErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if fa.attempts != tc.expectedAttempts {
+				t.Errorf("Expected %d attempts to write started.json, but got %d", tc.expectedAttempts, fa.attempts)
+			}
+		})
+	}
+}
+
+// fakeReplacer holds a single existing object, recording how it gets replaced.
+type fakeReplacer struct {
+	existing           []byte
+	replaced           []byte
+	replacedGeneration int64
+}
+
+type fakeReplacerWriteCloser struct {
+	replacer *fakeReplacer
+}
+
+func (wc *fakeReplacerWriteCloser) Write(p []byte) (int, error) {
+	wc.replacer.replaced = append(wc.replacer.replaced, p...)
+	return len(p), nil
+}
+
+func (wc *fakeReplacerWriteCloser) Close() error {
+	return nil
+}
+
+func (fr *fakeReplacer) ReadObject(ctx context.Context, bucket, path string) ([]byte, int64, error) {
+	if fr.existing == nil {
+		return nil, 0, storage.ErrObjectNotExist
+	}
+	return fr.existing, 42, nil
+}
+
+func (fr *fakeReplacer) NewGenerationWriter(ctx context.Context, bucket, path string, generation int64) io.WriteCloser {
+	fr.replacedGeneration = generation
+	return &fakeReplacerWriteCloser{replacer: fr}
+}
+
+func TestOverwriteCrierStarted(t *testing.T) {
+	tests := []struct {
+		name                  string
+		overwriteCrierStarted bool
+		existing              string
+		expectWritten         bool
+		expectReplaced        bool
+	}{
+		{
+			name:          "without the option started.json is written without looking for an existing one",
+			existing:      `{"timestamp": 1, "metadata": {"uploader": "crier"}}`,
+			expectWritten: true,
+		},
+		{
+			name:                  "missing started.json is written",
+			overwriteCrierStarted: true,
+			expectWritten:         true,
+		},
+		{
+			name:                  "started.json uploaded by crier is replaced",
+			overwriteCrierStarted: true,
+			existing:              `{"timestamp": 1, "metadata": {"uploader": "crier"}}`,
+			expectReplaced:        true,
+		},
+		{
+			name:                  "started.json uploaded by the pod is kept",
+			overwriteCrierStarted: true,
+			existing:              `{"timestamp": 1, "repo-version": "abcdef"}`,
+		},
+		{
+			name:                  "unreadable started.json is kept",
+			overwriteCrierStarted: true,
+			existing:              `not json`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testutil.Fca{C: config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{
+						DefaultDecorationConfigs: map[string]*prowv1.DecorationConfig{"*": {
+							GCSConfiguration: &prowv1.GCSConfiguration{
+								Bucket:       "kubernetes-jenkins",
+								PathPrefix:   "some-prefix",
+								PathStrategy: prowv1.PathStrategyLegacy,
+								DefaultOrg:   "kubernetes",
+								DefaultRepo:  "kubernetes",
+							},
+						}},
+					},
+				},
+			}}.Config
+			ta := &testutil.TestMultiAuthor{}
+			fr := &fakeReplacer{}
+			if tc.existing != "" {
+				fr.existing = []byte(tc.existing)
+			}
+			reporter := newWithAuthor(cfg, ta, false)
+			reporter.overwriteCrierStarted = tc.overwriteCrierStarted
+			reporter.replacer = fr
+
+			pj := &prowv1.ProwJob{
+				Spec: prowv1.ProwJobSpec{
+					Type:  prowv1.PeriodicJob,
+					Agent: prowv1.KubernetesAgent,
+					Job:   "my-little-job",
+				},
+				Status: prowv1.ProwJobStatus{
+					State:     prowv1.PendingState,
+					StartTime: metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)},
+					PodName:   "some-pod",
+					BuildID:   "123",
+				},
+			}
+
+			if err := reporter.reportStartedJob(context.Background(), pj); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			_, written := ta.Objects["some-prefix/logs/my-little-job/123/started.json"]
+			if written != tc.expectWritten {
+				t.Errorf("Expected started.json to be written: %v, but it was: %v", tc.expectWritten, written)
+			}
+			replaced := fr.replaced != nil
+			if replaced != tc.expectReplaced {
+				t.Errorf("Expected started.json to be replaced: %v, but it was: %v", tc.expectReplaced, replaced)
+			}
+			if replaced && fr.replacedGeneration != 42 {
+				t.Errorf("Expected started.json to be replaced only at generation 42, but got %d", fr.replacedGeneration)
+			}
+		})
+	}
+}
+
+// failingAuthor fails writing objects whose name ends in failing, writing
+// everything else like a TestMultiAuthor.
+type failingAuthor struct {
+	testutil.TestMultiAuthor
+	failing string
+}
+
+func (fa *failingAuthor) NewWriter(ctx context.Context, bucket, path string, overwrite bool, contentEncoding string) io.WriteCloser {
+	if strings.HasSuffix(path, fa.failing) {
+		return &failingWriteCloser{err: &googleapi.Error{Code: http.StatusForbidden}}
+	}
+	return fa.TestMultiAuthor.NewWriter(ctx, bucket, path, overwrite, contentEncoding)
+}
+
+func TestReportAttemptsEveryUpload(t *testing.T) {
+	for _, failing := range []string{"started.json", "finished.json", "prowjob.json"} {
+		t.Run(fmt.Sprintf("failing %s", failing), func(t *testing.T) {
+			cfg := testutil.Fca{C: config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{
+						DefaultDecorationConfigs: map[string]*prowv1.DecorationConfig{"*": {
+							GCSConfiguration: &prowv1.GCSConfiguration{
+								Bucket:       "kubernetes-jenkins",
+								PathPrefix:   "some-prefix",
+								PathStrategy: prowv1.PathStrategyLegacy,
+								DefaultOrg:   "kubernetes",
+								DefaultRepo:  "kubernetes",
+							},
+						}},
+					},
+				},
+			}}.Config
+			fa := &failingAuthor{failing: failing}
+			reporter := newWithAuthor(cfg, fa, false)
+
+			pj := &prowv1.ProwJob{
+				Spec: prowv1.ProwJobSpec{
+					Type:  prowv1.PeriodicJob,
+					Agent: prowv1.KubernetesAgent,
+					Job:   "my-little-job",
+				},
+				Status: prowv1.ProwJobStatus{
+					State:          prowv1.SuccessState,
+					StartTime:      metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)},
+					CompletionTime: &metav1.Time{Time: time.Date(2010, 10, 10, 19, 00, 0, 0, time.UTC)},
+					PodName:        "some-pod",
+					BuildID:        "123",
+				},
+			}
+
+			if _, err := reporter.Report(pj); err == nil {
+				t.Errorf("Expected an error for failing to write %s, but didn't get one", failing)
+			}
+
+			var names []string
+			for name := range fa.Objects {
+				names = append(names, path.Base(name))
+			}
+			sort.Strings(names)
+			var expected []string
+			for _, name := range []string{"finished.json", "prowjob.json", "started.json"} {
+				if name != failing {
+					expected = append(expected, name)
+				}
+			}
+			if !cmp.Equal(names, expected) {
+				t.Errorf("Wrote the wrong objects:\n%s", cmp.Diff(expected, names))
+			}
+		})
+	}
+}
+
+func TestUploadMetrics(t *testing.T) {
+	observed := func(artifact string) (uint64, float64) {
+		h := &dto.Metric{}
+		if err := uploadDurations.WithLabelValues(artifact).(prometheus.Histogram).Write(h); err != nil {
+			t.Fatalf("Couldn't read duration metric: %v", err)
+		}
+		c := &dto.Metric{}
+		if err := uploadFailures.WithLabelValues(artifact).Write(c); err != nil {
+			t.Fatalf("Couldn't read failure metric: %v", err)
+		}
+		return h.GetHistogram().GetSampleCount(), c.GetCounter().GetValue()
+	}
+
+	cfg := testutil.Fca{C: config.Config{
+		ProwConfig: config.ProwConfig{
+			Plank: config.Plank{
+				DefaultDecorationConfigs: map[string]*prowv1.DecorationConfig{"*": {
+					GCSConfiguration: &prowv1.GCSConfiguration{
+						Bucket:       "kubernetes-jenkins",
+						PathPrefix:   "some-prefix",
+						PathStrategy: prowv1.PathStrategyLegacy,
+						DefaultOrg:   "kubernetes",
+						DefaultRepo:  "kubernetes",
+					},
+				}},
+			},
+		},
+	}}.Config
+	fa := &flakyAuthor{failures: 1, err: &googleapi.Error{Code: http.StatusServiceUnavailable}}
+	reporter := newWithAuthor(cfg, fa, false)
+	reporter.writeRetries = 3
+	reporter.retryBackoff = time.Millisecond
+
+	pj := &prowv1.ProwJob{
+		Spec: prowv1.ProwJobSpec{
+			Type:  prowv1.PeriodicJob,
+			Agent: prowv1.KubernetesAgent,
+			Job:   "my-little-job",
+		},
+		Status: prowv1.ProwJobStatus{
+			State:     prowv1.PendingState,
+			StartTime: metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)},
+			PodName:   "some-pod",
+			BuildID:   "123",
+		},
+	}
+
+	startedBefore, startedFailuresBefore := observed("started")
+	prowjobBefore, prowjobFailuresBefore := observed("prowjob")
+	if err := reporter.reportStartedJob(context.Background(), pj); err != nil {
+		t.Fatalf("Unexpected error reporting started.json: %v", err)
+	}
+	if err := reporter.reportProwjob(context.Background(), pj); err != nil {
+		t.Fatalf("Unexpected error reporting prowjob.json: %v", err)
+	}
+
+	startedAfter, startedFailuresAfter := observed("started")
+	if startedAfter != startedBefore+2 {
+		t.Errorf("Expected both attempts at started.json to be observed, but observations went from %d to %d", startedBefore, startedAfter)
+	}
+	if startedFailuresAfter != startedFailuresBefore+1 {
+		t.Errorf("Expected the failed attempt at started.json to be counted, but failures went from %v to %v", startedFailuresBefore, startedFailuresAfter)
+	}
+	prowjobAfter, prowjobFailuresAfter := observed("prowjob")
+	if prowjobAfter != prowjobBefore+1 {
+		t.Errorf("Expected the upload of prowjob.json to be observed once, but observations went from %d to %d", prowjobBefore, prowjobAfter)
+	}
+	if prowjobFailuresAfter != prowjobFailuresBefore {
+		t.Errorf("Expected no failed uploads of prowjob.json, but failures went from %v to %v", prowjobFailuresBefore, prowjobFailuresAfter)
+	}
+}
+
+func TestReportWithoutDestination(t *testing.T) {
+	counted := func() float64 {
+		m := &dto.Metric{}
+		if err := jobsWithoutDestination.WithLabelValues(string(prowv1.PeriodicJob)).Write(m); err != nil {
+			t.Fatalf("Couldn't read metric: %v", err)
+		}
+		return m.GetCounter().GetValue()
+	}
+
+	ta := &testutil.TestMultiAuthor{}
+	reporter := newWithAuthor(testutil.Fca{}.Config, ta, false)
+	pj := &prowv1.ProwJob{
+		Spec: prowv1.ProwJobSpec{
+			Type:  prowv1.PeriodicJob,
+			Agent: prowv1.KubernetesAgent,
+			Job:   "my-little-job",
+		},
+		Status: prowv1.ProwJobStatus{
+			State:     prowv1.PendingState,
+			StartTime: metav1.Time{Time: time.Date(2010, 10, 10, 18, 30, 0, 0, time.UTC)},
+			BuildID:   "123",
+		},
+	}
+
+	before := counted()
+	if _, err := reporter.Report(pj); err != nil {
+		t.Fatalf("Expected a job without destination not to fail the report, but got: %v", err)
+	}
+	if len(ta.Objects) != 0 {
+		t.Errorf("Expected nothing to be written, but wrote %d objects", len(ta.Objects))
+	}
+	if after := counted(); after != before+1 {
+		t.Errorf("Expected the skipped report to be counted once, but the counter went from %v to %v", before, after)
+	}
+}
+
+func TestShouldReport(t *testing.T) {
+	tests := []struct {
+		name         string
+		buildID      string
+		filter       JobFilter
+		shouldReport bool
+	}{
+		{
+			name:         "tests with a build ID should be reported",
+			buildID:      "123",
+			shouldReport: true,
+		},
+		{
+			name:         "tests without a build ID should not be reported",
+			buildID:      "",
+			shouldReport: false,
+		},
+		{
+			name:         "tests of an excluded type should not be reported",
+			buildID:      "123",
+			filter:       JobFilter{ExcludedTypes: []prowv1.ProwJobType{prowv1.PeriodicJob, prowv1.PostsubmitJob}},
+			shouldReport: false,
+		},
+		{
+			name:         "tests of other types should be reported",
+			buildID:      "123",
+			filter:       JobFilter{ExcludedTypes: []prowv1.ProwJobType{prowv1.PeriodicJob}},
+			shouldReport: true,
+		},
+		{
+			name:         "tests with an excluded name should not be reported",
+			buildID:      "123",
+			filter:       JobFilter{ExcludedJobs: regexp.MustCompile(`^my-.*-job$`)},
+			shouldReport: false,
+		},
+		{
+			name:         "tests with other names should be reported",
+			buildID:      "123",
+			filter:       JobFilter{ExcludedJobs: regexp.MustCompile(`^your-`)},
+			shouldReport: true,
 		},
 	}
 	for _, tc := range tests {
@@ -300,6 +1565,7 @@ func TestShouldReport(t *testing.T) {
 				},
 			}
 			gr := newWithAuthor(testutil.Fca{}.Config, nil, false)
+			gr.filter = tc.filter
 			result := gr.ShouldReport(pj)
 			if result != tc.shouldReport {
 				t.Errorf("Got ShouldReport() returned %v, but expected %v", result, tc.shouldReport)
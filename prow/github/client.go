@@ -129,6 +129,7 @@ type PullRequestClient interface {
 // CommitClient interface for commit related API actions
 type CommitClient interface {
 	CreateStatus(org, repo, SHA string, s Status) error
+	CreateCheckRun(org, repo string, checkRun CheckRun) error
 	ListStatuses(org, repo, ref string) ([]Status, error)
 	GetSingleCommit(org, repo, SHA string) (SingleCommit, error)
 	GetCombinedStatus(org, repo, ref string) (*CombinedStatus, error)
@@ -1697,6 +1698,27 @@ func (c *client) CreateStatus(org, repo, SHA string, s Status) error {
 	return err
 }
 
+// CreateCheckRun creates a check run on a commit. The latest check run with a
+// given name is the one shown for the commit, so creating another check run
+// with the same name updates the result of the check.
+//
+// Check runs can only be created when authenticated as a GitHub App.
+//
+// See https://developer.github.com/v3/checks/runs/#create-a-check-run
+func (c *client) CreateCheckRun(org, repo string, checkRun CheckRun) error {
+	durationLogger := c.log("CreateCheckRun", org, repo, checkRun)
+	defer durationLogger()
+
+	_, err := c.request(&request{
+		method:      http.MethodPost,
+		path:        fmt.Sprintf("/repos/%s/%s/check-runs", org, repo),
+		accept:      "application/vnd.github.antiope-preview+json",
+		requestBody: &checkRun,
+		exitCodes:   []int{201},
+	}, nil)
+	return err
+}
+
 // ListStatuses gets commit statuses for a given ref.
 //
 // See https://developer.github.com/v3/repos/statuses/#list-statuses-for-a-specific-ref
@@ -458,6 +458,41 @@ func TestCreateStatus(t *testing.T) {
 	}
 }
 
+func TestCreateCheckRun(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/check-runs" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		if accept := r.Header.Get("Accept"); accept != "application/vnd.github.antiope-preview+json" {
+			t.Errorf("Bad Accept header: %s", accept)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var cr CheckRun
+		if err := json.Unmarshal(b, &cr); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if cr.Name != "c" || cr.HeadSHA != "abcdef" || cr.Output == nil || cr.Output.Summary != "s" {
+			t.Errorf("Wrong check run: %#v", cr)
+		}
+		http.Error(w, "201 Created", http.StatusCreated)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.CreateCheckRun("k8s", "kuber", CheckRun{
+		Name:       "c",
+		HeadSHA:    "abcdef",
+		Conclusion: CheckRunConclusionSuccess,
+		Output:     &CheckRunOutput{Title: "t", Summary: "s"},
+	}); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
 func TestListIssues(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
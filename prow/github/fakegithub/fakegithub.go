@@ -49,6 +49,7 @@ type FakeClient struct {
 	Reviews             map[int][]github.Review
 	CombinedStatuses    map[string]*github.CombinedStatus
 	CreatedStatuses     map[string][]github.Status
+	CreatedCheckRuns    map[string][]github.CheckRun
 	IssueEvents         map[int][]github.ListedIssueEvent
 	Commits             map[string]github.SingleCommit
 
@@ -296,6 +297,26 @@ func (f *FakeClient) CreateStatus(owner, repo, SHA string, s github.Status) erro
 	return nil
 }
 
+// CreateCheckRun adds a check run to a commit, replacing any with the same name.
+func (f *FakeClient) CreateCheckRun(owner, repo string, checkRun github.CheckRun) error {
+	if f.CreatedCheckRuns == nil {
+		f.CreatedCheckRuns = make(map[string][]github.CheckRun)
+	}
+	checkRuns := f.CreatedCheckRuns[checkRun.HeadSHA]
+	var updated bool
+	for i := range checkRuns {
+		if checkRuns[i].Name == checkRun.Name {
+			checkRuns[i] = checkRun
+			updated = true
+		}
+	}
+	if !updated {
+		checkRuns = append(checkRuns, checkRun)
+	}
+	f.CreatedCheckRuns[checkRun.HeadSHA] = checkRuns
+	return nil
+}
+
 // ListStatuses returns individual status contexts on a commit.
 func (f *FakeClient) ListStatuses(org, repo, ref string) ([]github.Status, error) {
 	return f.CreatedStatuses[ref], nil
@@ -67,6 +67,13 @@ const (
 	StatusFailure = "failure"
 )
 
+// These are possible Conclusion entries for a CheckRun.
+const (
+	CheckRunConclusionSuccess = "success"
+	CheckRunConclusionFailure = "failure"
+	CheckRunConclusionNeutral = "neutral"
+)
+
 // Possible contents for reactions.
 const (
 	ReactionThumbsUp                  = "+1"
@@ -147,6 +154,23 @@ type Status struct {
 	Context     string `json:"context,omitempty"`
 }
 
+// CheckRun is used to report the result of a check on a commit, with output
+// that is shown on the Checks tab of pull requests.
+type CheckRun struct {
+	Name       string          `json:"name"`
+	HeadSHA    string          `json:"head_sha"`
+	Status     string          `json:"status,omitempty"`
+	Conclusion string          `json:"conclusion,omitempty"`
+	Output     *CheckRunOutput `json:"output,omitempty"`
+}
+
+// CheckRunOutput is the Markdown output of a CheckRun.
+type CheckRunOutput struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+	Text    string `json:"text,omitempty"`
+}
+
 // CombinedStatus is the latest statuses for a ref.
 type CombinedStatus struct {
 	SHA      string   `json:"sha"`
@@ -18,17 +18,24 @@ limitations under the License.
 package bugzilla
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	githubql "github.com/shurcooL/githubv4"
 	"github.com/sirupsen/logrus"
 
+	"k8s.io/apimachinery/pkg/util/sets"
+
 	"k8s.io/test-infra/prow/bugzilla"
 	"k8s.io/test-infra/prow/config"
 	"k8s.io/test-infra/prow/github"
@@ -41,6 +48,9 @@ var (
 	titleMatch          = regexp.MustCompile(`(?i)^.*?Bug ([0-9]+):`)
 	refreshCommandMatch = regexp.MustCompile(`(?mi)^/bugzilla refresh\s*$`)
 	qaCommandMatch      = regexp.MustCompile(`(?mi)^/bugzilla assign-qa\s*$`)
+	ccQaCommandMatch    = regexp.MustCompile(`(?mi)^/bugzilla cc-qa\s*$`)
+	cherrypickMatch     = regexp.MustCompile(`(?mi)^/bugzilla cherrypick(?:[ \t]+(\S+))?[ \t]*$`)
+	unlinkCommandMatch  = regexp.MustCompile(`(?mi)^/bugzilla unlink\s*$`)
 )
 
 const (
@@ -48,6 +58,127 @@ const (
 	bugLink    = `[Bugzilla bug %d](%s/show_bug.cgi?id=%d)`
 )
 
+var (
+	defaultValidCommentTemplate   = template.Must(template.New("valid").Parse(`This pull request references {{.BugLink}}{{with .Summary}}: "{{.}}"{{end}}, which is valid.`))
+	defaultInvalidCommentTemplate = template.Must(template.New("invalid").Parse(`This pull request references {{.BugLink}}{{with .Summary}}: "{{.}}"{{end}}, which is invalid:
+{{range .Reasons}} - {{.}}
+{{end}}
+Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.`))
+)
+
+// maxSummaryLength is the number of characters of a bug's summary that are
+// quoted in comments; longer summaries are truncated
+const maxSummaryLength = 100
+
+// maxStatusDescriptionLength is the longest description GitHub accepts for a
+// commit status; longer descriptions are truncated
+const maxStatusDescriptionLength = 140
+
+// checkRunName is the name of the check run reporting the verdict, if enabled
+const checkRunName = "bugzilla"
+
+// markdownLink matches links in validation messages, which have no place in
+// the plain text description of a commit status
+var markdownLink = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+
+// retryInitialBackoff is the time waited before the first retry of a transient
+// Bugzilla error; the wait doubles with every subsequent attempt
+var retryInitialBackoff = 1 * time.Second
+
+// defaultTimeout bounds the time spent talking to Bugzilla while handling an
+// event when the branch options do not configure a timeout
+const defaultTimeout = 2 * time.Minute
+
+// defaultPersistentInvalidThreshold is the number of consecutive failed validations
+// after which a bug is commented on when the branch options do not configure one
+const defaultPersistentInvalidThreshold = 3
+
+// persistentInvalid tracks the consecutive failed validations of bugs
+var persistentInvalid = newInvalidTracker()
+
+// invalidTrackerTTL is how long the tracker remembers a bug that has not
+// failed validation again, so that bugs whose pull requests are closed or
+// abandoned do not accumulate forever
+const invalidTrackerTTL = 7 * 24 * time.Hour
+
+// invalidTracker counts the consecutive failed validations of every bug and
+// remembers the bugs that have been commented on, so that each bug is only
+// commented on once. Bugs are forgotten once they have not failed validation
+// for invalidTrackerTTL.
+type invalidTracker struct {
+	lock    sync.Mutex
+	records map[string]*invalidRecord
+	now     func() time.Time
+}
+
+// invalidRecord holds what the tracker knows about a single bug
+type invalidRecord struct {
+	failures int
+	notified bool
+	lastSeen time.Time
+}
+
+func newInvalidTracker() *invalidTracker {
+	return &invalidTracker{records: map[string]*invalidRecord{}, now: time.Now}
+}
+
+func trackerKey(endpoint string, bugId int) string {
+	return fmt.Sprintf("%s#%d", endpoint, bugId)
+}
+
+// prune forgets the bugs that have not failed validation recently; callers
+// must hold the lock
+func (t *invalidTracker) prune(now time.Time) {
+	for key, record := range t.records {
+		if now.Sub(record.lastSeen) > invalidTrackerTTL {
+			delete(t.records, key)
+		}
+	}
+}
+
+// recordInvalid records a failed validation of the bug and determines
+// if the bug needs to be commented on now
+func (t *invalidTracker) recordInvalid(endpoint string, bugId, threshold int) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := t.now()
+	t.prune(now)
+	key := trackerKey(endpoint, bugId)
+	record, ok := t.records[key]
+	if !ok {
+		record = &invalidRecord{}
+		t.records[key] = record
+	}
+	record.failures++
+	record.lastSeen = now
+	return record.failures >= threshold && !record.notified
+}
+
+// recordValid resets the count of consecutive failed validations of the bug
+func (t *invalidTracker) recordValid(endpoint string, bugId int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	key := trackerKey(endpoint, bugId)
+	record, ok := t.records[key]
+	if !ok {
+		return
+	}
+	if !record.notified {
+		delete(t.records, key)
+		return
+	}
+	record.failures = 0
+}
+
+// recordNotified records that the bug was commented on
+func (t *invalidTracker) recordNotified(endpoint string, bugId int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if record, ok := t.records[trackerKey(endpoint, bugId)]; ok {
+		record.notified = true
+	}
+}
+
 func init() {
 	plugins.RegisterGenericCommentHandler(PluginName, handleGenericComment, helpProvider)
 	plugins.RegisterPullRequestHandler(PluginName, handlePullRequest, helpProvider)
@@ -84,22 +215,49 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 					conditions = append(conditions, "be closed")
 				}
 			}
-			if opts[branch].TargetRelease != nil {
-				conditions = append(conditions, fmt.Sprintf("target the %q release", *opts[branch].TargetRelease))
+			if releases := targetReleases(opts[branch]); len(releases) > 0 {
+				conditions = append(conditions, fmt.Sprintf("target %s", describeReleases(releases)))
+			} else if opts[branch].RequireTargetReleaseSet != nil && *opts[branch].RequireTargetReleaseSet {
+				conditions = append(conditions, "have a target release set")
 			}
-			if opts[branch].ValidStates != nil && len(*opts[branch].ValidStates) > 0 {
-				pretty := strings.Join(prettyStates(*opts[branch].ValidStates), ", ")
+			if states := validStates(opts[branch]); len(states) > 0 {
+				pretty := strings.Join(prettyStates(states), ", ")
 				conditions = append(conditions, fmt.Sprintf("be in one of the following states: %s", pretty))
 			}
-			if opts[branch].DependentBugStates != nil || opts[branch].DependentBugTargetRelease != nil {
+			if opts[branch].DependentBugStates != nil || len(dependentTargetReleases(opts[branch])) > 0 {
 				conditions = append(conditions, "depend on at least one other bug")
 			}
 			if opts[branch].DependentBugStates != nil {
 				pretty := strings.Join(prettyStates(*opts[branch].DependentBugStates), ", ")
 				conditions = append(conditions, fmt.Sprintf("have all dependent bugs in one of the following states: %s", pretty))
 			}
-			if opts[branch].DependentBugTargetRelease != nil {
-				conditions = append(conditions, fmt.Sprintf("have all dependent bugs target the %q release", *opts[branch].DependentBugTargetRelease))
+			if releases := dependentTargetReleases(opts[branch]); len(releases) > 0 {
+				conditions = append(conditions, fmt.Sprintf("have all dependent bugs target %s", describeReleases(releases)))
+			}
+			if opts[branch].SummaryMustMatch != nil {
+				conditions = append(conditions, fmt.Sprintf("have a summary matching the regular expression %q", *opts[branch].SummaryMustMatch))
+			}
+			if opts[branch].ValidateComponentFromChangedFiles != nil {
+				conditions = append(conditions, "be filed against a component matching the files changed in the pull request")
+			}
+			if opts[branch].MinDependentBugs != nil || opts[branch].MaxDependentBugs != nil {
+				conditions = append(conditions, fmt.Sprintf("depend on %s other bug(s)", dependentCountRange(opts[branch].MinDependentBugs, opts[branch].MaxDependentBugs)))
+			}
+			if opts[branch].HardBlockStatuses != nil && len(*opts[branch].HardBlockStatuses) > 0 {
+				pretty := strings.Join(prettyStates(*opts[branch].HardBlockStatuses), ", ")
+				conditions = append(conditions, fmt.Sprintf("not be in any of the following states, which require a manual override: %s", pretty))
+			}
+			if opts[branch].RequireAssignee != nil && *opts[branch].RequireAssignee {
+				conditions = append(conditions, "be assigned to someone")
+			}
+			if opts[branch].RequireActiveAssignee != nil && *opts[branch].RequireActiveAssignee {
+				conditions = append(conditions, "be assigned to an active member of the GitHub organization")
+			}
+			if opts[branch].RequireAuthorIsAssignee != nil && *opts[branch].RequireAuthorIsAssignee {
+				conditions = append(conditions, "be assigned to the author of the pull request")
+			}
+			if len(opts[branch].RequiredFlags) > 0 {
+				conditions = append(conditions, fmt.Sprintf("have the following flags set to \"+\": %s", strings.Join(opts[branch].RequiredFlags, ", ")))
 			}
 			switch len(conditions) {
 			case 0:
@@ -112,6 +270,13 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 				conditions[len(conditions)-1] = fmt.Sprintf("and %s", conditions[len(conditions)-1])
 				message += strings.Join(conditions, ", ")
 			}
+			if ids := opts[branch].AlwaysValidBugIDs; len(ids) > 0 {
+				var pretty []string
+				for _, id := range ids {
+					pretty = append(pretty, strconv.Itoa(id))
+				}
+				message += fmt.Sprintf(", although the following bugs are always valid: %s", strings.Join(pretty, ", "))
+			}
 			var updates []string
 			if opts[branch].StateAfterValidation != nil {
 				updates = append(updates, fmt.Sprintf("moved to the %s state", opts[branch].StateAfterValidation))
@@ -121,6 +286,9 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 			}
 			if opts[branch].StateAfterMerge != nil {
 				updates = append(updates, fmt.Sprintf("moved to the %s state when all linked pull requests are merged", opts[branch].StateAfterMerge))
+				if opts[branch].AddCommentOnMerge != nil && *opts[branch].AddCommentOnMerge {
+					updates = append(updates, "commented on with a link to the pull request that moved them")
+				}
 			}
 
 			if len(updates) > 0 {
@@ -136,6 +304,15 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 				updates[len(updates)-1] = fmt.Sprintf("and %s", updates[len(updates)-1])
 				message += strings.Join(updates, ", ")
 			}
+			if len(opts[branch].ExcludedLogins) > 0 {
+				message += fmt.Sprintf(". Pull requests opened by and commands from the following users are ignored: %s", strings.Join(opts[branch].ExcludedLogins, ", "))
+			}
+			if opts[branch].GracePeriodMinutes != nil && *opts[branch].GracePeriodMinutes > 0 {
+				message += fmt.Sprintf(". Pull requests that do not reference a bug are not flagged until they are %d minute(s) old", *opts[branch].GracePeriodMinutes)
+			}
+			if opts[branch].ValidateOnPush != nil && *opts[branch].ValidateOnPush {
+				message += ". Bugs are validated again whenever new commits are pushed to the pull request"
+			}
 			configInfoStrings = append(configInfoStrings, "<li>"+message+".</li>")
 		}
 		configInfoStrings = append(configInfoStrings, "</ul>")
@@ -148,7 +325,7 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 	}
 	pluginHelp.AddCommand(pluginhelp.Command{
 		Usage:       "/bugzilla refresh",
-		Description: "Check Bugzilla for a valid bug referenced in the PR title",
+		Description: "Check Bugzilla for a valid bug referenced in the PR title. Also recognized when added to the PR description",
 		Featured:    false,
 		WhoCanUse:   "Anyone",
 		Examples:    []string{"/bugzilla refresh"},
@@ -160,18 +337,77 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 		WhoCanUse:   "Anyone",
 		Examples:    []string{"/bugzilla assign-qa"},
 	})
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/bugzilla cc-qa",
+		Description: "Request review from the QA contact specified in Bugzilla without assigning the PR to them",
+		Featured:    false,
+		WhoCanUse:   "Anyone",
+		Examples:    []string{"/bugzilla cc-qa"},
+	})
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/bugzilla cherrypick <branch>",
+		Description: "Clone the Bugzilla bug referenced in the PR title for the target branch of a cherry-pick",
+		Featured:    false,
+		WhoCanUse:   "Anyone",
+		Examples:    []string{"/bugzilla cherrypick release-4.12"},
+	})
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/bugzilla unlink",
+		Description: "Remove the PR from the external tracker bugs of the Bugzilla bug referenced in the PR title",
+		Featured:    false,
+		WhoCanUse:   "Anyone",
+		Examples:    []string{"/bugzilla unlink"},
+	})
 	return pluginHelp, nil
 }
 
 type githubClient interface {
 	GetPullRequest(org, repo string, number int) (*github.PullRequest, error)
+	GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error)
 	CreateComment(owner, repo string, number int, comment string) error
 	GetIssueLabels(org, repo string, number int) ([]github.Label, error)
 	AddLabel(owner, repo string, number int, label string) error
+	IsMember(org, user string) (bool, error)
 	RemoveLabel(owner, repo string, number int, label string) error
+	CreateStatus(org, repo, SHA string, s github.Status) error
+	CreateCheckRun(org, repo string, checkRun github.CheckRun) error
 	Query(ctx context.Context, q interface{}, vars map[string]interface{}) error
 }
 
+// userResolver resolves an email address to the logins of the users on the
+// code hosting service that list it publicly
+type userResolver interface {
+	ResolveEmail(email string) ([]string, error)
+	// WithContext returns a resolver that bounds its queries by the context
+	WithContext(ctx context.Context) userResolver
+}
+
+// githubUserResolver resolves emails to GitHub logins using a GraphQL search
+type githubUserResolver struct {
+	gc  githubClient
+	ctx context.Context
+}
+
+func (r githubUserResolver) WithContext(ctx context.Context) userResolver {
+	r.ctx = ctx
+	return r
+}
+
+func (r githubUserResolver) ResolveEmail(email string) ([]string, error) {
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	query := &emailToLoginQuery{}
+	queryVars := map[string]interface{}{
+		"email": githubql.String(email),
+	}
+	if err := r.gc.Query(ctx, query, queryVars); err != nil {
+		return nil, err
+	}
+	return loginsFromQuery(query), nil
+}
+
 func handleGenericComment(pc plugins.Agent, e github.GenericCommentEvent) error {
 	event, err := digestComment(pc.GitHubClient, pc.Logger, e)
 	if err != nil {
@@ -179,33 +415,60 @@ func handleGenericComment(pc plugins.Agent, e github.GenericCommentEvent) error
 	}
 	if event != nil {
 		options := pc.PluginConfig.Bugzilla.OptionsForBranch(event.org, event.repo, event.baseRef)
-		return handle(*event, pc.GitHubClient, pc.BugzillaClient, options, pc.Logger)
+		if isExcludedLogin(event.login, options.ExcludedLogins) {
+			pc.Logger.Debugf("Ignoring command from excluded login %s.", event.login)
+			return nil
+		}
+		if event.cherrypickTo != "" {
+			targetOptions := pc.PluginConfig.Bugzilla.OptionsForBranch(event.org, event.repo, event.cherrypickTo)
+			ctx, cancel := context.WithTimeout(context.Background(), timeoutFor(options))
+			defer cancel()
+			return handleCherrypick(ctx, *event, pc.GitHubClient, pc.BugzillaClient, options, targetOptions, pc.Logger)
+		}
+		if event.unlink {
+			ctx, cancel := context.WithTimeout(context.Background(), timeoutFor(options))
+			defer cancel()
+			return handleUnlink(ctx, *event, pc.GitHubClient, pc.BugzillaClient, options, pc.Logger)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeoutFor(options))
+		defer cancel()
+		return handle(ctx, *event, pc.GitHubClient, githubUserResolver{gc: pc.GitHubClient}, pc.BugzillaClient, options, pc.Logger)
 	}
 	return nil
 }
 
 func handlePullRequest(pc plugins.Agent, pre github.PullRequestEvent) error {
 	options := pc.PluginConfig.Bugzilla.OptionsForBranch(pre.PullRequest.Base.Repo.Owner.Login, pre.PullRequest.Base.Repo.Name, pre.PullRequest.Base.Ref)
-	event, err := digestPR(pc.Logger, pre, options.ValidateByDefault)
+	event, err := digestPR(pc.Logger, pre, options.ValidateByDefault, options.ValidateOnPush, options.ExcludedLogins)
 	if err != nil {
 		return err
 	}
 	if event != nil {
-		return handle(*event, pc.GitHubClient, pc.BugzillaClient, options, pc.Logger)
+		ctx, cancel := context.WithTimeout(context.Background(), timeoutFor(options))
+		defer cancel()
+		return handle(ctx, *event, pc.GitHubClient, githubUserResolver{gc: pc.GitHubClient}, pc.BugzillaClient, options, pc.Logger)
 	}
 	return nil
 }
 
 // digestPR determines if any action is necessary and creates the objects for handle() if it is
-func digestPR(log *logrus.Entry, pre github.PullRequestEvent, validateByDefault *bool) (*event, error) {
-	// These are the only actions indicating the PR title may have changed or that the PR merged
+func digestPR(log *logrus.Entry, pre github.PullRequestEvent, validateByDefault, validateOnPush *bool, excludedLogins []string) (*event, error) {
+	// These are the only actions indicating the PR title may have changed or that the PR merged,
+	// unless new commits are configured to trigger validation as well
 	if pre.Action != github.PullRequestActionOpened &&
 		pre.Action != github.PullRequestActionReopened &&
 		pre.Action != github.PullRequestActionEdited &&
+		!(pre.Action == github.PullRequestActionSynchronize && validateOnPush != nil && *validateOnPush) &&
 		!(pre.Action == github.PullRequestActionClosed && pre.PullRequest.Merged) {
 		return nil, nil
 	}
 
+	// Pull requests from excluded users, like bots, are neither labeled nor commented on
+	if isExcludedLogin(pre.PullRequest.User.Login, excludedLogins) {
+		log.Debugf("Ignoring pull request from excluded login %s.", pre.PullRequest.User.Login)
+		return nil, nil
+	}
+
 	var (
 		org     = pre.PullRequest.Base.Repo.Owner.Login
 		repo    = pre.PullRequest.Base.Repo.Name
@@ -215,8 +478,8 @@ func digestPR(log *logrus.Entry, pre github.PullRequestEvent, validateByDefault
 	)
 
 	// Make sure the PR title is referencing a bug
-	e := &event{org: org, repo: repo, baseRef: baseRef, number: number, merged: pre.PullRequest.Merged, state: pre.PullRequest.State, body: title, htmlUrl: pre.PullRequest.HTMLURL, login: pre.PullRequest.User.Login}
-	mat := titleMatch.FindStringSubmatch(title)
+	e := &event{org: org, repo: repo, baseRef: baseRef, headSHA: pre.PullRequest.Head.SHA, number: number, merged: pre.PullRequest.Merged, state: pre.PullRequest.State, body: title, htmlUrl: pre.PullRequest.HTMLURL, login: pre.PullRequest.User.Login}
+	mat := titleMatch.FindStringSubmatch(normalizeTitle(title))
 	if mat == nil {
 		// in the case that the title used to reference a bug and no longer does we
 		// want to handle this to remove labels
@@ -244,12 +507,23 @@ func digestPR(log *logrus.Entry, pre github.PullRequestEvent, validateByDefault
 		Title struct {
 			From string `json:"from"`
 		} `json:"title"`
+		Body *struct {
+			From string `json:"from"`
+		} `json:"body"`
 	}
 	if err := json.Unmarshal(pre.Changes, &changes); err != nil {
 		// we're detecting this best-effort so we can handle it anyway
 		return intermediate, nil
 	}
-	prevMat := titleMatch.FindStringSubmatch(changes.Title.From)
+
+	// A refresh command newly added to the description is handled like a comment
+	// requesting one. Commands in the description of a new pull request already
+	// reach us as comments, so only edits are considered here.
+	if pre.Action == github.PullRequestActionEdited && changes.Body != nil &&
+		refreshCommandMatch.MatchString(pre.PullRequest.Body) && !refreshCommandMatch.MatchString(changes.Body.From) {
+		return e, nil
+	}
+	prevMat := titleMatch.FindStringSubmatch(normalizeTitle(changes.Title.From))
 	if prevMat == nil {
 		// title did not previously reference a bug
 		return intermediate, nil
@@ -263,7 +537,7 @@ func digestPR(log *logrus.Entry, pre github.PullRequestEvent, validateByDefault
 
 	// if the referenced bug has not changed in the update, ignore it
 	if prevId == e.bugId {
-		logrus.Debugf("Referenced Bugzilla ID (%d) has not changed, not handling event.", e.bugId)
+		log.Debugf("Referenced Bugzilla ID (%d) has not changed, not handling event.", e.bugId)
 		return nil, nil
 	}
 
@@ -273,6 +547,26 @@ func digestPR(log *logrus.Entry, pre github.PullRequestEvent, validateByDefault
 	return e, nil
 }
 
+// normalizeTitle folds look-alike characters that copying a title sometimes
+// introduces, so that they don't keep a bug reference from matching: colon
+// variants become ASCII colons, full-width digits become ASCII digits,
+// invisible characters are dropped and runs of whitespace, like non-breaking
+// spaces, become single spaces.
+func normalizeTitle(title string) string {
+	folded := strings.Map(func(r rune) rune {
+		switch {
+		case r == '\uFF1A' || r == '\uFE55' || r == '\uFE13' || r == '\u2236':
+			return ':'
+		case r >= '\uFF10' && r <= '\uFF19':
+			return '0' + (r - '\uFF10')
+		case r == '\u200B' || r == '\u200C' || r == '\u200D' || r == '\u2060' || r == '\uFEFF':
+			return -1
+		}
+		return r
+	}, title)
+	return strings.Join(strings.Fields(folded), " ")
+}
+
 // digestComment determines if any action is necessary and creates the objects for handle() if it is
 func digestComment(gc githubClient, log *logrus.Entry, gce github.GenericCommentEvent) (*event, error) {
 	// Only consider new comments.
@@ -280,12 +574,20 @@ func digestComment(gc githubClient, log *logrus.Entry, gce github.GenericComment
 		return nil, nil
 	}
 	// Make sure they are requesting a valid command
-	var assign bool
+	var assign, cc, cherrypick, unlink bool
+	var cherrypickTo string
 	switch {
 	case refreshCommandMatch.MatchString(gce.Body):
 		assign = false
 	case qaCommandMatch.MatchString(gce.Body):
 		assign = true
+	case ccQaCommandMatch.MatchString(gce.Body):
+		cc = true
+	case cherrypickMatch.MatchString(gce.Body):
+		cherrypick = true
+		cherrypickTo = cherrypickMatch.FindStringSubmatch(gce.Body)[1]
+	case unlinkCommandMatch.MatchString(gce.Body):
+		unlink = true
 	default:
 		return nil, nil
 	}
@@ -301,14 +603,19 @@ func digestComment(gc githubClient, log *logrus.Entry, gce github.GenericComment
 		return nil, gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(gce.Body, gce.HTMLURL, gce.User.Login, `Bugzilla bug referencing is only supported for Pull Requests, not issues.`))
 	}
 
+	if cherrypick && cherrypickTo == "" {
+		log.Debug("Bugzilla cherrypick command requested without a target branch, ignoring")
+		return nil, gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(gce.Body, gce.HTMLURL, gce.User.Login, `A target branch is required to clone a bug for a cherry-pick, for example: <code>/bugzilla cherrypick release-4.12</code>.`))
+	}
+
 	// Make sure the PR title is referencing a bug
 	pr, err := gc.GetPullRequest(org, repo, number)
 	if err != nil {
 		return nil, err
 	}
 
-	e := &event{org: org, repo: repo, baseRef: pr.Base.Ref, number: number, merged: pr.Merged, state: pr.State, body: gce.Body, htmlUrl: gce.HTMLURL, login: gce.User.Login, assign: assign}
-	mat := titleMatch.FindStringSubmatch(pr.Title)
+	e := &event{org: org, repo: repo, baseRef: pr.Base.Ref, headSHA: pr.Head.SHA, number: number, merged: pr.Merged, state: pr.State, body: gce.Body, htmlUrl: gce.HTMLURL, login: gce.User.Login, assign: assign, cc: cc, cherrypickTo: cherrypickTo, unlink: unlink}
+	mat := titleMatch.FindStringSubmatch(normalizeTitle(pr.Title))
 	if mat == nil {
 		e.missing = true
 		return e, nil
@@ -324,13 +631,37 @@ func digestComment(gc githubClient, log *logrus.Entry, gce github.GenericComment
 	return e, nil
 }
 
+// isExcludedLogin determines if the login is one of the excluded logins, ignoring case
+func isExcludedLogin(login string, excludedLogins []string) bool {
+	for _, excluded := range excludedLogins {
+		if strings.EqualFold(login, excluded) {
+			return true
+		}
+	}
+	return false
+}
+
 type event struct {
 	org, repo, baseRef   string
+	headSHA              string
 	number, bugId        int
 	missing, merged      bool
 	state                string
 	body, htmlUrl, login string
-	assign               bool
+	assign, cc, unlink   bool
+	cherrypickTo         string
+}
+
+// logger returns a logger with fields identifying the pull request of the event
+// and the bug it references, or noting that it references none
+func (e *event) logger(log *logrus.Entry) *logrus.Entry {
+	fields := logrus.Fields{github.OrgLogField: e.org, github.RepoLogField: e.repo, github.PrLogField: e.number}
+	if e.missing {
+		fields["bugMissing"] = true
+	} else {
+		fields["bug"] = e.bugId
+	}
+	return log.WithFields(fields)
 }
 
 func (e *event) comment(gc githubClient) func(body string) error {
@@ -372,49 +703,99 @@ type emailToLoginQuery struct {
 	Search querySearch `graphql:"search(type:USER query:$email first:5)"`
 }
 
-// processQueryResult generates a response based on a populated emailToLoginQuery
-func processQuery(query *emailToLoginQuery, email string, log *logrus.Entry) string {
-	switch len(query.Search.Edges) {
+// skippedQAAction describes the QA contact action that is skipped when no
+// single GitHub user can be identified
+func skippedQAAction(cc bool) string {
+	if cc {
+		return "skipping review request"
+	}
+	return "skipping assignment"
+}
+
+// loginsFromQuery extracts the logins from a populated emailToLoginQuery
+func loginsFromQuery(query *emailToLoginQuery) []string {
+	var logins []string
+	for _, edge := range query.Search.Edges {
+		logins = append(logins, string(edge.Node.User.Login))
+	}
+	return logins
+}
+
+// processLogins generates a response based on the logins matching an email,
+// either assigning or CCing the matching user
+func processLogins(logins []string, email, role string, cc bool, log *logrus.Entry) string {
+	skipping := skippedQAAction(cc)
+	switch len(logins) {
 	case 0:
-		return fmt.Sprintf("No GitHub users were found matching the public email listed for the QA contact in Bugzilla (%s), skipping assignment.", email)
+		return fmt.Sprintf("No GitHub users were found matching the public email listed for the %s in Bugzilla (%s), %s.", role, email, skipping)
 	case 1:
-		return fmt.Sprintf("Assigning the QA contact for review:\n/assign @%s", query.Search.Edges[0].Node.User.Login)
+		if cc {
+			return fmt.Sprintf("Requesting review from %s:\n/cc @%s", role, logins[0])
+		}
+		return fmt.Sprintf("Assigning the %s for review:\n/assign @%s", role, logins[0])
 	default:
-		response := fmt.Sprintf("Multiple GitHub users were found matching the public email listed for the QA contact in Bugzilla (%s), skipping assignment. List of users with matching email:", email)
-		for _, edge := range query.Search.Edges {
-			response += fmt.Sprintf("\n\t- %s", edge.Node.User.Login)
+		response := fmt.Sprintf("Multiple GitHub users were found matching the public email listed for the %s in Bugzilla (%s), %s. List of users with matching email:", role, email, skipping)
+		for _, login := range logins {
+			response += fmt.Sprintf("\n\t- %s", login)
 		}
 		return response
 	}
 }
 
-func handle(e event, gc githubClient, bc bugzilla.Client, options plugins.BugzillaBranchOptions, log *logrus.Entry) error {
+// timeoutFor determines the time allowed for talking to Bugzilla while handling
+// an event; the configured timeout is validated when the configuration is loaded
+func timeoutFor(options plugins.BugzillaBranchOptions) time.Duration {
+	if options.Timeout != nil {
+		if timeout, err := time.ParseDuration(*options.Timeout); err == nil {
+			return timeout
+		}
+	}
+	return defaultTimeout
+}
+
+func handle(ctx context.Context, e event, gc githubClient, ur userResolver, bc bugzilla.Client, options plugins.BugzillaBranchOptions, log *logrus.Entry) error {
+	log = e.logger(log)
 	comment := e.comment(gc)
+	bc = bc.WithContext(ctx)
+	ur = ur.WithContext(ctx)
 	// merges follow a different pattern from the normal validation
 	if e.merged {
-		return handleMerge(e, gc, bc, options, log)
+		return handleMerge(ctx, e, gc, bc, options, log)
 	}
 
 	var needsValidLabel, needsInvalidLabel bool
-	var response string
+	var response, description string
+	if e.missing && inGracePeriod(e, gc, options, log) {
+		log.Debug("No bug referenced, but the pull request is within the grace period.")
+		return nil
+	}
 	if e.missing {
-		log.WithField("bugMissing", true)
 		log.Debug("No bug referenced.")
 		needsValidLabel, needsInvalidLabel = false, false
 		response = `No Bugzilla bug is referenced in the title of this pull request.
 To reference a bug, add 'Bug XXX:' to the title of this pull request and request another bug refresh with <code>/bugzilla refresh</code>.`
+		description = "No Bugzilla bug is referenced in the title of this pull request."
 	} else {
-		log = log.WithField("bugId", e.bugId)
-
-		bug, err := getBug(bc, e.bugId, log, comment)
+		bug, err := getBug(ctx, bc, e.bugId, options.BugRetries, log, comment)
 		if err != nil || bug == nil {
 			return err
 		}
 
+		// allowlisted bugs are valid without looking at anything else
+		allowlisted := alwaysValid(e.bugId, options)
+
 		var dependents []bugzilla.Bug
-		if options.DependentBugStates != nil || options.DependentBugTargetRelease != nil {
-			for _, id := range bug.DependsOn {
-				dependent, err := bc.GetBug(id)
+		if !allowlisted && (options.DependentBugStates != nil || len(dependentTargetReleases(options)) > 0) {
+			dependentClient := bc
+			if options.DependentBugEndpoint != nil {
+				dependentClient = bc.ForEndpoint(*options.DependentBugEndpoint)
+			}
+			for _, id := range dependentIDs(bug, options) {
+				dependent, err := getBugWithRetries(ctx, dependentClient, id, options.BugRetries, log)
+				if err != nil && options.DependentBugEndpoint != nil {
+					log.WithError(err).Warn("Unexpected error searching for dependent bug on remote Bugzilla server.")
+					return comment(formatError(fmt.Sprintf("searching for dependent bug %d on the separate Bugzilla server at %s", id, *options.DependentBugEndpoint), bc.Endpoint(), e.bugId, err))
+				}
 				if err != nil {
 					return comment(formatError(fmt.Sprintf("searching for dependent bug %d", id), bc.Endpoint(), e.bugId, err))
 				}
@@ -422,11 +803,58 @@ To reference a bug, add 'Bug XXX:' to the title of this pull request and request
 			}
 		}
 
-		valid, validationsRun, why := validateBug(*bug, dependents, options, bc.Endpoint())
+		var changedFiles []string
+		if !allowlisted && options.ValidateComponentFromChangedFiles != nil {
+			changes, err := gc.GetPullRequestChanges(e.org, e.repo, e.number)
+			if err != nil {
+				log.WithError(err).Warn("Unexpected error listing pull request changes.")
+				return comment(formatError("listing the files changed in this pull request", bc.Endpoint(), e.bugId, err))
+			}
+			for _, change := range changes {
+				changedFiles = append(changedFiles, change.Filename)
+			}
+		}
+
+		result, err := validateBug(*bug, dependents, changedFiles, options, bc.Endpoint())
+		if err != nil {
+			log.WithError(err).Warn("Unexpected error validating Bugzilla bug.")
+			return comment(formatError("validating", bc.Endpoint(), e.bugId, err))
+		}
+		valid, validationsRun, why := result.Valid, result.Validations, result.Errors
+		if !allowlisted && options.RequireActiveAssignee != nil && *options.RequireActiveAssignee {
+			validation, reason := validateActiveAssignee(bug, e.org, gc, ur, log)
+			if validation != "" {
+				validationsRun = append(validationsRun, validation)
+			}
+			if reason != "" {
+				valid = false
+				why = append(why, reason)
+			}
+		}
+		if !allowlisted && options.RequireAuthorIsAssignee != nil && *options.RequireAuthorIsAssignee {
+			validation, reason := validateAuthorIsAssignee(bug, e, gc, ur, log)
+			if validation != "" {
+				validationsRun = append(validationsRun, validation)
+			}
+			if reason != "" {
+				valid = false
+				why = append(why, reason)
+			}
+		}
 		needsValidLabel, needsInvalidLabel = valid, !valid
+		data := plugins.BugzillaCommentTemplateData{
+			Bug:         bug,
+			BugLink:     fmt.Sprintf(bugLink, e.bugId, bc.Endpoint(), e.bugId),
+			Summary:     truncateSummary(bug.Summary),
+			Endpoint:    bc.Endpoint(),
+			Validations: validationsRun,
+			Reasons:     why,
+		}
 		if valid {
 			log.Debug("Valid bug found.")
-			response = fmt.Sprintf(`This pull request references `+bugLink+`, which is valid.`, e.bugId, bc.Endpoint(), e.bugId)
+			persistentInvalid.recordValid(bc.Endpoint(), e.bugId)
+			response = renderComment(options.ValidCommentTemplate, defaultValidCommentTemplate, data, log)
+			description = fmt.Sprintf("Bug %d is valid.", e.bugId)
 			// if configured, move the bug to the new state
 			if update := options.StateAfterValidation.AsBugUpdate(bug); update != nil {
 				if err := bc.UpdateBug(e.bugId, *update); err != nil {
@@ -455,37 +883,37 @@ To reference a bug, add 'Bug XXX:' to the title of this pull request and request
 			for _, validation := range validationsRun {
 				response += fmt.Sprint("\n* ", validation)
 			}
+			// Bugzilla returns the target release as a list, but only the first
+			// entry is considered when validating, so call out any others
+			if len(bug.TargetRelease) > 1 {
+				response += fmt.Sprintf("\n\n**Warning:** the bug has multiple target releases (%s); only the first (%s) was considered.", strings.Join(bug.TargetRelease, ", "), bug.TargetRelease[0])
+			}
 			response += "</details>"
 
-			// if bug is valid and qa command was used, identify qa contact via email
-			if e.assign {
-				if bug.QAContactDetail == nil {
-					response += fmt.Sprintf(bugLink+" does not have a QA contact, skipping assignment", e.bugId, bc.Endpoint(), e.bugId)
-				} else if bug.QAContactDetail.Email == "" {
-					response += fmt.Sprintf("QA contact for "+bugLink+" does not have a listed email, skipping assignment", e.bugId, bc.Endpoint(), e.bugId)
-				} else {
-					query := &emailToLoginQuery{}
-					email := bug.QAContactDetail.Email
-					queryVars := map[string]interface{}{
-						"email": githubql.String(email),
-					}
-					err := gc.Query(context.Background(), query, queryVars)
-					if err != nil {
-						log.WithError(err).Error("Failed to run graphql github query")
-						return comment(formatError(fmt.Sprintf("querying GitHub for users with public email (%s)", email), bc.Endpoint(), e.bugId, err))
+			if options.WarnOnUnassignedBug != nil && *options.WarnOnUnassignedBug && isUnassigned(bug) {
+				response += "\n\n**Warning:** " + fmt.Sprintf(bugLink, e.bugId, bc.Endpoint(), e.bugId) + " is not assigned to anyone. Please make sure that an assignee is set in Bugzilla so that the bug is triaged."
+			}
+
+			// if bug is valid and a qa command was used, identify qa contact via email
+			if e.assign || e.cc {
+				assignReporter := options.AssignReporterIfNoQA != nil && *options.AssignReporterIfNoQA
+				qaResponse, email, err := qaContactResponse(e.bugId, bug, e.cc, assignReporter, ur, bc.Endpoint(), log)
+				if err != nil {
+					if errors.Is(err, context.DeadlineExceeded) || ctx.Err() == context.DeadlineExceeded {
+						log.WithError(err).Warn("Timed out resolving the QA contact.")
+						return comment(fmt.Sprintf("Timed out resolving the QA contact of "+bugLink+" to a GitHub user. Please try again with <code>%s</code>.", e.bugId, bc.Endpoint(), e.bugId, qaCommand(e.cc)))
 					}
-					response += fmt.Sprint("\n\n", processQuery(query, email, log))
+					return comment(formatError(fmt.Sprintf("querying GitHub for users with public email (%s)", email), bc.Endpoint(), e.bugId, err))
 				}
+				response += qaResponse
 			}
 		} else {
 			log.Debug("Invalid bug found.")
-			var formattedReasons string
-			for _, reason := range why {
-				formattedReasons += fmt.Sprintf(" - %s\n", reason)
+			response = renderComment(options.InvalidCommentTemplate, defaultInvalidCommentTemplate, data, log)
+			description = invalidStatusDescription(e.bugId, why)
+			if options.NotifyBugOnPersistentInvalid != nil && *options.NotifyBugOnPersistentInvalid {
+				notifyPersistentInvalid(e, bc, options, why, log)
 			}
-			response = fmt.Sprintf(`This pull request references `+bugLink+`, which is invalid:
-%s
-Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.`, e.bugId, bc.Endpoint(), e.bugId, formattedReasons)
 		}
 	}
 
@@ -496,39 +924,425 @@ Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the
 	if err != nil {
 		log.WithError(err).Warn("Could not list labels on PR")
 	}
+	validLabel, invalidLabel := bugLabels(options)
 	var hasValidLabel, hasInvalidLabel bool
 	for _, l := range currentLabels {
-		if l.Name == labels.ValidBug {
+		if l.Name == validLabel {
 			hasValidLabel = true
 		}
-		if l.Name == labels.InvalidBug {
+		if l.Name == invalidLabel {
 			hasInvalidLabel = true
 		}
 	}
 
-	if needsValidLabel && !hasValidLabel {
-		if err := gc.AddLabel(e.org, e.repo, e.number, labels.ValidBug); err != nil {
-			log.WithError(err).Error("Failed to add valid bug label.")
+	// when re-validating, call out whether the verdict changed so reviewers
+	// don't have to compare the comment with the previous one
+	if previous, current := verdict(hasValidLabel, hasInvalidLabel), verdict(needsValidLabel, needsInvalidLabel); previous != "" && current != "" {
+		if previous == current {
+			response = "Status unchanged.\n\n" + response
+		} else {
+			response = fmt.Sprintf("Status changed from %s to %s.\n\n", previous, current) + response
+		}
+	}
+
+	// compute the final set of labels managed by the plugin once, so that
+	// only the labels that actually change are written
+	current, desired := sets.NewString(), sets.NewString()
+	if hasValidLabel {
+		current.Insert(validLabel)
+	}
+	if hasInvalidLabel {
+		current.Insert(invalidLabel)
+	}
+	if needsValidLabel {
+		desired.Insert(validLabel)
+	}
+	if needsInvalidLabel {
+		desired.Insert(invalidLabel)
+	}
+	for _, label := range desired.Difference(current).List() {
+		if err := gc.AddLabel(e.org, e.repo, e.number, label); err != nil {
+			log.WithError(err).WithField("label", label).Error("Failed to add bug label.")
+		}
+	}
+	for _, label := range current.Difference(desired).List() {
+		if err := gc.RemoveLabel(e.org, e.repo, e.number, label); err != nil {
+			log.WithError(err).WithField("label", label).Error("Failed to remove bug label.")
+		}
+	}
+
+	// like labels, the status is best-effort and errors are not propagated
+	if options.StatusContext != nil {
+		state := github.StatusFailure
+		if needsValidLabel {
+			state = github.StatusSuccess
 		}
-	} else if !needsValidLabel && hasValidLabel {
-		if err := gc.RemoveLabel(e.org, e.repo, e.number, labels.ValidBug); err != nil {
-			log.WithError(err).Error("Failed to remove valid bug label.")
+		status := github.Status{State: state, Context: *options.StatusContext, Description: truncateStatusDescription(description)}
+		if err := gc.CreateStatus(e.org, e.repo, e.headSHA, status); err != nil {
+			log.WithError(err).WithField("context", *options.StatusContext).Error("Failed to set bug status.")
 		}
 	}
 
-	if needsInvalidLabel && !hasInvalidLabel {
-		if err := gc.AddLabel(e.org, e.repo, e.number, labels.InvalidBug); err != nil {
-			log.WithError(err).Error("Failed to add invalid bug label.")
+	// the check run replaces the comment, unless it cannot be created. QA commands
+	// are always answered in a comment, as the /assign and /cc commands in the
+	// response only take effect when commented.
+	if options.ReportCheckRun != nil && *options.ReportCheckRun {
+		conclusion := github.CheckRunConclusionFailure
+		if needsValidLabel {
+			conclusion = github.CheckRunConclusionSuccess
+		}
+		checkRun := github.CheckRun{
+			Name:       checkRunName,
+			HeadSHA:    e.headSHA,
+			Conclusion: conclusion,
+			Output:     &github.CheckRunOutput{Title: description, Summary: response},
 		}
-	} else if !needsInvalidLabel && hasInvalidLabel {
-		if err := gc.RemoveLabel(e.org, e.repo, e.number, labels.InvalidBug); err != nil {
-			log.WithError(err).Error("Failed to remove invalid bug label.")
+		if err := gc.CreateCheckRun(e.org, e.repo, checkRun); err != nil {
+			log.WithError(err).Warn("Could not create check run, commenting instead.")
+		} else if !e.assign && !e.cc {
+			return nil
 		}
 	}
 
 	return comment(response)
 }
 
+// inGracePeriod determines if the pull request is younger than the configured
+// grace period. Errors are not propagated; if the age of the pull request cannot
+// be determined, it is handled as if there were no grace period.
+func inGracePeriod(e event, gc githubClient, options plugins.BugzillaBranchOptions, log *logrus.Entry) bool {
+	if options.GracePeriodMinutes == nil || *options.GracePeriodMinutes <= 0 {
+		return false
+	}
+	pr, err := gc.GetPullRequest(e.org, e.repo, e.number)
+	if err != nil {
+		log.WithError(err).Warn("Could not get pull request to check its age, not applying the grace period.")
+		return false
+	}
+	return time.Since(pr.CreatedAt) < time.Duration(*options.GracePeriodMinutes)*time.Minute
+}
+
+// qaContactResponse looks up the GitHub user with the public email of the bug's
+// QA contact and generates a response that assigns or CCs them. The email that
+// was looked up is returned as well, so that failures can mention it.
+func qaContactResponse(bugId int, bug *bugzilla.Bug, cc, assignReporter bool, ur userResolver, endpoint string, log *logrus.Entry) (string, string, error) {
+	if bug.QAContactDetail == nil {
+		if assignReporter && !cc {
+			return reporterResponse(bugId, bug, ur, endpoint, log)
+		}
+		return fmt.Sprintf(bugLink+" does not have a QA contact, %s", bugId, endpoint, bugId, skippedQAAction(cc)), "", nil
+	}
+	if bug.QAContactDetail.Email == "" {
+		return fmt.Sprintf("QA contact for "+bugLink+" does not have a listed email, %s", bugId, endpoint, bugId, skippedQAAction(cc)), "", nil
+	}
+	email := bug.QAContactDetail.Email
+	logins, err := ur.ResolveEmail(email)
+	if err != nil {
+		log.WithError(err).Error("Failed to resolve the QA contact email to users")
+		return "", email, err
+	}
+	return fmt.Sprint("\n\n", processLogins(logins, email, "QA contact", cc, log)), email, nil
+}
+
+// qaCommand is the command that requests the QA contact be assigned or CCed
+func qaCommand(cc bool) string {
+	if cc {
+		return "/bugzilla cc-qa"
+	}
+	return "/bugzilla assign-qa"
+}
+
+// reporterResponse looks up the GitHub user with the public email of the bug's
+// reporter and generates a response that assigns them, for bugs without a QA contact.
+// The email that was looked up is returned as well, so that failures can mention it.
+func reporterResponse(bugId int, bug *bugzilla.Bug, ur userResolver, endpoint string, log *logrus.Entry) (string, string, error) {
+	fallback := fmt.Sprintf(bugLink+" does not have a QA contact, falling back to the bug reporter.", bugId, endpoint, bugId)
+	// the creator is identified by their login name, which is usually their email
+	email := bug.Creator
+	if bug.CreatorDetail != nil && bug.CreatorDetail.Email != "" {
+		email = bug.CreatorDetail.Email
+	}
+	if email == "" {
+		return fmt.Sprintf("\n\n%s The bug reporter does not have a listed email, %s.", fallback, skippedQAAction(false)), "", nil
+	}
+	logins, err := ur.ResolveEmail(email)
+	if err != nil {
+		log.WithError(err).Error("Failed to resolve the bug reporter email to users")
+		return "", email, err
+	}
+	return fmt.Sprintf("\n\n%s\n%s", fallback, processLogins(logins, email, "bug reporter", false, log)), email, nil
+}
+
+// targetReleases lists the releases a bug may target to be valid,
+// starting with the singular target release if one is configured
+func targetReleases(options plugins.BugzillaBranchOptions) []string {
+	return combineReleases(options.TargetRelease, options.TargetReleases)
+}
+
+// dependentTargetReleases lists the releases the dependents of a bug may target
+// to be valid, starting with the singular target release if one is configured
+func dependentTargetReleases(options plugins.BugzillaBranchOptions) []string {
+	return combineReleases(options.DependentBugTargetRelease, options.DependentBugTargetReleases)
+}
+
+// combineReleases lists the singular release, if set, followed by the other
+// releases, without duplicates
+func combineReleases(release *string, others *[]string) []string {
+	var releases []string
+	seen := sets.NewString()
+	if release != nil {
+		releases = append(releases, *release)
+		seen.Insert(*release)
+	}
+	if others != nil {
+		for _, other := range *others {
+			if !seen.Has(other) {
+				releases = append(releases, other)
+				seen.Insert(other)
+			}
+		}
+	}
+	return releases
+}
+
+// describeReleases formats acceptable target releases for user-facing messages
+func describeReleases(releases []string) string {
+	if len(releases) == 1 {
+		return fmt.Sprintf("the %q release", releases[0])
+	}
+	var quoted []string
+	for _, release := range releases {
+		quoted = append(quoted, strconv.Quote(release))
+	}
+	return fmt.Sprintf("one of the following releases: %s", strings.Join(quoted, ", "))
+}
+
+// truncateSummary shortens the summary of a bug to at most maxSummaryLength
+// characters, marking when it was truncated
+func truncateSummary(summary string) string {
+	runes := []rune(strings.TrimSpace(summary))
+	if len(runes) <= maxSummaryLength {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:maxSummaryLength-3])) + "..."
+}
+
+// invalidStatusDescription summarizes why a bug is invalid in plain text, using
+// the first reason and counting any others
+func invalidStatusDescription(bugId int, why []string) string {
+	description := fmt.Sprintf("Bug %d is invalid", bugId)
+	if len(why) == 0 {
+		return description + "."
+	}
+	description += ": " + markdownLink.ReplaceAllString(why[0], "$1")
+	if len(why) > 1 {
+		description += fmt.Sprintf(" (and %d more)", len(why)-1)
+	}
+	return description
+}
+
+// truncateStatusDescription shortens a commit status description to the
+// length GitHub accepts, marking when it was truncated
+func truncateStatusDescription(description string) string {
+	runes := []rune(description)
+	if len(runes) <= maxStatusDescriptionLength {
+		return description
+	}
+	return strings.TrimSpace(string(runes[:maxStatusDescriptionLength-3])) + "..."
+}
+
+// renderComment renders the configured comment template, falling back to the
+// default template when none is configured or the configured one fails
+func renderComment(configured *string, fallback *template.Template, data plugins.BugzillaCommentTemplateData, log *logrus.Entry) string {
+	tmpl := fallback
+	if configured != nil {
+		custom, err := template.New(fallback.Name()).Parse(*configured)
+		if err != nil {
+			log.WithError(err).Warn("Could not parse comment template, using the default.")
+		} else {
+			tmpl = custom
+		}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.WithError(err).Warn("Could not render comment template, using the default.")
+		buf.Reset()
+		if err := fallback.Execute(&buf, data); err != nil {
+			log.WithError(err).Error("Could not render the default comment template.")
+		}
+	}
+	return buf.String()
+}
+
+// notifyPersistentInvalid comments on the bug to prompt triage once the pull
+// request referencing it has failed validation enough times in a row. Errors
+// are not propagated, as the comment is only a courtesy to the bug's owners.
+func notifyPersistentInvalid(e event, bc bugzilla.Client, options plugins.BugzillaBranchOptions, why []string, log *logrus.Entry) {
+	threshold := defaultPersistentInvalidThreshold
+	if options.PersistentInvalidThreshold != nil {
+		threshold = *options.PersistentInvalidThreshold
+	}
+	if !persistentInvalid.recordInvalid(bc.Endpoint(), e.bugId, threshold) {
+		return
+	}
+	message := fmt.Sprintf("Pull request %s/%s#%d references this bug, but has failed validation %d times in a row:\n", e.org, e.repo, e.number, threshold)
+	for _, reason := range why {
+		message += fmt.Sprintf(" - %s\n", reason)
+	}
+	message += "Please triage this bug so that the pull request can be validated."
+	if _, err := bc.CreateBugComment(e.bugId, message); err != nil {
+		log.WithError(err).Warn("Unexpected error commenting on persistently invalid Bugzilla bug.")
+		return
+	}
+	persistentInvalid.recordNotified(bc.Endpoint(), e.bugId)
+}
+
+// bugLabels determines the labels used to mark pull requests as referencing
+// a valid or an invalid bug, defaulting to the standard labels
+func bugLabels(options plugins.BugzillaBranchOptions) (string, string) {
+	validLabel, invalidLabel := labels.ValidBug, labels.InvalidBug
+	if options.ValidLabel != nil {
+		validLabel = *options.ValidLabel
+	}
+	if options.InvalidLabel != nil {
+		invalidLabel = *options.InvalidLabel
+	}
+	return validLabel, invalidLabel
+}
+
+// verdict describes the validity of a bug given the labels for it,
+// or is empty if the labels do not indicate either
+func verdict(valid, invalid bool) string {
+	switch {
+	case valid && !invalid:
+		return "valid"
+	case invalid && !valid:
+		return "invalid"
+	default:
+		return ""
+	}
+}
+
+// alwaysValid determines if the bug is allowlisted to be valid regardless of
+// any other conditions
+func alwaysValid(bugId int, options plugins.BugzillaBranchOptions) bool {
+	return sets.NewInt(options.AlwaysValidBugIDs...).Has(bugId)
+}
+
+// validateActiveAssignee checks that the assignee of the bug maps to a GitHub user
+// who is a member of the organization. It returns a validation that was run or a
+// reason the bug is invalid; both are empty if the check could not be done.
+func validateActiveAssignee(bug *bugzilla.Bug, org string, gc githubClient, ur userResolver, log *logrus.Entry) (string, string) {
+	if isUnassigned(bug) {
+		return "", ""
+	}
+	email := bug.AssignedTo
+	if bug.AssignedToDetail != nil && bug.AssignedToDetail.Email != "" {
+		email = bug.AssignedToDetail.Email
+	}
+	logins, err := ur.ResolveEmail(email)
+	if err != nil {
+		log.WithError(err).Warnf("Could not resolve bug assignee %s to a GitHub user, not checking organization membership.", email)
+		return "", ""
+	}
+	if len(logins) == 0 {
+		log.Debugf("No GitHub user found for bug assignee %s, not checking organization membership.", email)
+		return "", ""
+	}
+	for _, login := range logins {
+		member, err := gc.IsMember(org, login)
+		if err != nil {
+			log.WithError(err).Warnf("Could not check whether %s is a member of %s, not checking organization membership.", login, org)
+			return "", ""
+		}
+		if member {
+			return fmt.Sprintf("bug assignee (%s) is an active member of the %s organization as %s", email, org, login), ""
+		}
+	}
+	return "", fmt.Sprintf("expected the bug assignee (%s) to be an active member of the %s organization, but %s is not; reassign the bug in Bugzilla", email, org, strings.Join(logins, ", "))
+}
+
+// validateAuthorIsAssignee checks that the assignee of the bug maps to the GitHub
+// user who authored the pull request. It returns a validation that was run or a
+// reason the bug is invalid. Unlike the organization membership check, it fails
+// closed: a bug is invalid when the author cannot be shown to be the assignee.
+func validateAuthorIsAssignee(bug *bugzilla.Bug, e event, gc githubClient, ur userResolver, log *logrus.Entry) (string, string) {
+	pr, err := gc.GetPullRequest(e.org, e.repo, e.number)
+	if err != nil {
+		log.WithError(err).Warn("Could not get pull request to determine its author.")
+		return "", "expected the bug to be assigned to the author of the pull request, but the author could not be determined; request a bug refresh to try again"
+	}
+	author := pr.User.Login
+	if isUnassigned(bug) {
+		return "", fmt.Sprintf("expected the bug to be assigned to the author of the pull request (@%s), but it is not assigned to anyone", author)
+	}
+	email := bug.AssignedTo
+	if bug.AssignedToDetail != nil && bug.AssignedToDetail.Email != "" {
+		email = bug.AssignedToDetail.Email
+	}
+	logins, err := ur.ResolveEmail(email)
+	if err != nil {
+		log.WithError(err).Warnf("Could not resolve bug assignee %s to a GitHub user.", email)
+		return "", fmt.Sprintf("expected the bug assignee (%s) to be the author of the pull request (@%s), but the assignee could not be resolved to a GitHub user; request a bug refresh to try again", email, author)
+	}
+	if len(logins) == 0 {
+		return "", fmt.Sprintf("expected the bug assignee (%s) to be the author of the pull request (@%s), but no GitHub user lists that email publicly; if @%s is the assignee, add the email to their public GitHub profile", email, author, author)
+	}
+	for _, login := range logins {
+		if github.NormLogin(login) == github.NormLogin(author) {
+			return fmt.Sprintf("bug assignee (%s) is the author of the pull request (@%s)", email, author), ""
+		}
+	}
+	return "", fmt.Sprintf("expected the bug assignee (%s) to be the author of the pull request (@%s), but that email belongs to the GitHub user(s) %s instead; reassign the bug in Bugzilla or have the assignee open the pull request", email, author, strings.Join(logins, ", "))
+}
+
+// dependentFailures groups the dependent bugs that fail a check for the same
+// reason, so that the reason is reported once instead of once per dependent
+type dependentFailures struct {
+	// reasons are the singular forms of the reasons, in the order first seen
+	reasons []string
+	plural  map[string]string
+	ids     map[string][]int
+}
+
+// add records that the dependent bug failed for the reason, given
+// in both the singular and plural forms
+func (f *dependentFailures) add(id int, singular, plural string) {
+	if f.ids == nil {
+		f.ids = map[string][]int{}
+		f.plural = map[string]string{}
+	}
+	if _, seen := f.ids[singular]; !seen {
+		f.reasons = append(f.reasons, singular)
+		f.plural[singular] = plural
+	}
+	f.ids[singular] = append(f.ids[singular], id)
+}
+
+// render formats one error for every reason a dependent failed
+func (f *dependentFailures) render(endpoint string) []string {
+	var errors []string
+	for _, reason := range f.reasons {
+		ids := f.ids[reason]
+		if len(ids) == 1 {
+			errors = append(errors, fmt.Sprintf("expected dependent "+bugLink+" %s", ids[0], endpoint, ids[0], reason))
+			continue
+		}
+		var links []string
+		for _, id := range ids {
+			links = append(links, fmt.Sprintf(bugLink, id, endpoint, id))
+		}
+		errors = append(errors, fmt.Sprintf("expected dependent bugs %s %s", strings.Join(links, ", "), f.plural[reason]))
+	}
+	return errors
+}
+
+// isUnassigned determines if nobody is assigned to the bug. Bugzilla instances
+// commonly use a placeholder `nobody@` account as the default assignee.
+func isUnassigned(bug *bugzilla.Bug) bool {
+	return bug.AssignedTo == "" || strings.HasPrefix(bug.AssignedTo, "nobody@")
+}
+
 func bugMatchesStates(bug *bugzilla.Bug, states []plugins.BugzillaBugState) bool {
 	for _, state := range states {
 		if (&state).Matches(bug) {
@@ -538,16 +1352,65 @@ func bugMatchesStates(bug *bugzilla.Bug, states []plugins.BugzillaBugState) bool
 	return false
 }
 
+// validStates returns the configured valid states, including a CLOSED state for
+// every valid closed resolution
+func validStates(options plugins.BugzillaBranchOptions) []plugins.BugzillaBugState {
+	var states []plugins.BugzillaBugState
+	if options.ValidStates != nil {
+		states = append(states, *options.ValidStates...)
+	}
+	for _, resolution := range options.ValidClosedResolutions {
+		states = append(states, plugins.BugzillaBugState{Status: "CLOSED", Resolution: resolution})
+	}
+	return states
+}
+
+// prettyStates formats the states for user-facing messages, dropping duplicates,
+// like a state after validation that is also a valid state, and otherwise keeping
+// the order in which they are configured
 func prettyStates(statuses []plugins.BugzillaBugState) []string {
 	pretty := make([]string, 0, len(statuses))
+	seen := sets.NewString()
 	for _, status := range statuses {
-		pretty = append(pretty, bugzilla.PrettyStatus(status.Status, status.Resolution))
+		formatted := bugzilla.PrettyStatus(status.Status, status.Resolution)
+		if seen.Has(formatted) {
+			continue
+		}
+		seen.Insert(formatted)
+		pretty = append(pretty, formatted)
 	}
 	return pretty
 }
 
+// ValidationResult describes the outcome of validating a bug
+type ValidationResult struct {
+	// Valid is whether the bug passed every validation
+	Valid bool
+	// Validations describe the checks that the bug passed
+	Validations []string
+	// Errors describe the checks that the bug failed
+	Errors []string
+}
+
+// ValidateBug determines if the bug and its dependents match the options, for
+// use outside of the plugin. The endpoint of the Bugzilla server is used to link
+// to bugs. Checks that need the files changed in a pull request are skipped.
+func ValidateBug(bug bugzilla.Bug, dependents []bugzilla.Bug, options plugins.BugzillaBranchOptions, endpoint string) (ValidationResult, error) {
+	return validateBug(bug, dependents, nil, options, endpoint)
+}
+
 // validateBug determines if the bug matches the options and returns a description of why not
-func validateBug(bug bugzilla.Bug, dependents []bugzilla.Bug, options plugins.BugzillaBranchOptions, endpoint string) (bool, []string, []string) {
+func validateBug(bug bugzilla.Bug, dependents []bugzilla.Bug, changedFiles []string, options plugins.BugzillaBranchOptions, endpoint string) (ValidationResult, error) {
+	// allowlisted bugs bypass every other validation
+	if alwaysValid(bug.ID, options) {
+		return ValidationResult{Valid: true, Validations: []string{"bug is configured to always be valid, so no other validations were run"}}, nil
+	}
+
+	// no other validation can make a hard-blocked bug valid
+	if options.HardBlockStatuses != nil && bugMatchesStates(&bug, *options.HardBlockStatuses) {
+		return ValidationResult{Valid: false, Errors: []string{fmt.Sprintf("the bug is in the %s state, which is never automatically validated; a manual override is required to merge this pull request", bugzilla.PrettyStatus(bug.Status, bug.Resolution))}}, nil
+	}
+
 	valid := true
 	var errors []string
 	var validations []string
@@ -572,24 +1435,31 @@ func validateBug(bug bugzilla.Bug, dependents []bugzilla.Bug, options plugins.Bu
 		validations = append(validations, fmt.Sprintf("bug %s open, matching expected state (%s)", was, expected))
 	}
 
-	if options.TargetRelease != nil {
+	if releases := targetReleases(options); len(releases) > 0 {
 		if len(bug.TargetRelease) == 0 {
 			valid = false
-			errors = append(errors, fmt.Sprintf("expected the bug to target the %q release, but no target release was set", *options.TargetRelease))
-		} else if *options.TargetRelease != bug.TargetRelease[0] {
+			errors = append(errors, fmt.Sprintf("expected the bug to target %s, but no target release was set", describeReleases(releases)))
+		} else if !sets.NewString(releases...).Has(bug.TargetRelease[0]) {
 			// the BugZilla web UI shows one option for target release, but returns the
 			// field as a list in the REST API. We only care for the first item and it's
 			// not even clear if the list can have more than one item in the response
 			valid = false
-			errors = append(errors, fmt.Sprintf("expected the bug to target the %q release, but it targets %q instead", *options.TargetRelease, bug.TargetRelease[0]))
+			errors = append(errors, fmt.Sprintf("expected the bug to target %s, but it targets %q instead", describeReleases(releases), bug.TargetRelease[0]))
+		} else {
+			validations = append(validations, fmt.Sprintf("bug target release (%s) matches configured target release for branch (%s)", bug.TargetRelease[0], strings.Join(releases, ", ")))
+		}
+	} else if options.RequireTargetReleaseSet != nil && *options.RequireTargetReleaseSet {
+		// an untriaged bug shows the placeholder target release in the BugZilla web UI
+		if len(bug.TargetRelease) == 0 || bug.TargetRelease[0] == "" || bug.TargetRelease[0] == "---" {
+			valid = false
+			errors = append(errors, "expected the bug to have a target release set, but no target release was set")
 		} else {
-			validations = append(validations, fmt.Sprintf("bug target release (%s) matches configured target release for branch (%s)", bug.TargetRelease[0], *options.TargetRelease))
+			validations = append(validations, fmt.Sprintf("bug has a target release set (%s)", bug.TargetRelease[0]))
 		}
 	}
 
-	if options.ValidStates != nil {
-		var allowed []plugins.BugzillaBugState
-		allowed = append(allowed, *options.ValidStates...)
+	if options.ValidStates != nil || len(options.ValidClosedResolutions) > 0 {
+		allowed := validStates(options)
 		if options.StateAfterValidation != nil {
 			allowed = append(allowed, *options.StateAfterValidation)
 		}
@@ -601,59 +1471,242 @@ func validateBug(bug bugzilla.Bug, dependents []bugzilla.Bug, options plugins.Bu
 		}
 	}
 
+	if options.SummaryMustMatch != nil {
+		// the expression is validated when the plugin configuration is
+		// loaded, but callers outside of the plugin may not have done so
+		matched, err := regexp.MatchString(*options.SummaryMustMatch, bug.Summary)
+		if err != nil {
+			return ValidationResult{}, fmt.Errorf("failed to compile summary_must_match regexp %q: %v", *options.SummaryMustMatch, err)
+		}
+		if !matched {
+			valid = false
+			errors = append(errors, fmt.Sprintf("expected the bug summary to match the regular expression %q, but it is %q instead; update the summary of the bug in Bugzilla to conform", *options.SummaryMustMatch, bug.Summary))
+		} else {
+			validations = append(validations, fmt.Sprintf("bug summary matches the required regular expression (%s)", *options.SummaryMustMatch))
+		}
+	}
+
+	if options.RequireAssignee != nil && *options.RequireAssignee {
+		if isUnassigned(&bug) {
+			valid = false
+			errors = append(errors, "expected the bug to be assigned to someone, but it is not assigned; set an assignee in Bugzilla")
+		} else {
+			validations = append(validations, fmt.Sprintf("bug is assigned to %s", bug.AssignedTo))
+		}
+	}
+
+	if options.RequiredFlags != nil {
+		set := sets.NewString()
+		for _, flag := range bug.Flags {
+			if flag.Status == "+" {
+				set.Insert(flag.Name)
+			}
+		}
+		var missing []string
+		for _, flag := range options.RequiredFlags {
+			if !set.Has(flag) {
+				missing = append(missing, flag)
+			}
+		}
+		if len(missing) > 0 {
+			valid = false
+			errors = append(errors, fmt.Sprintf("expected the bug to have the following flags set to \"+\": %s, but these are missing: %s", strings.Join(options.RequiredFlags, ", "), strings.Join(missing, ", ")))
+		} else if len(options.RequiredFlags) > 0 {
+			validations = append(validations, fmt.Sprintf("bug has the required flags set to \"+\": %s", strings.Join(options.RequiredFlags, ", ")))
+		}
+	}
+
+	if options.ValidateComponentFromChangedFiles != nil {
+		// if none of the changed files map to a component, there is nothing to check
+		if expected := componentsForFiles(changedFiles, options.ValidateComponentFromChangedFiles); expected.Len() > 0 {
+			if len(bug.Component) == 0 {
+				valid = false
+				errors = append(errors, fmt.Sprintf("expected the bug to be filed against one of the components for the files changed in this pull request (%s), but no component was set", strings.Join(expected.List(), ", ")))
+			} else if !expected.HasAny(bug.Component...) {
+				valid = false
+				errors = append(errors, fmt.Sprintf("expected the bug to be filed against one of the components for the files changed in this pull request (%s), but it is filed against %s instead", strings.Join(expected.List(), ", "), strings.Join(bug.Component, ", ")))
+			} else {
+				validations = append(validations, fmt.Sprintf("bug component (%s) matches one of the components for the files changed in this pull request (%s)", strings.Join(bug.Component, ", "), strings.Join(expected.List(), ", ")))
+			}
+		}
+	}
+
+	// dependent bugs may live on a separate Bugzilla server
+	dependentEndpoint := endpoint
+	if options.DependentBugEndpoint != nil {
+		dependentEndpoint = *options.DependentBugEndpoint
+	}
+
 	if options.DependentBugStates != nil {
+		failures := &dependentFailures{}
 		for _, bug := range dependents {
 			if !bugMatchesStates(&bug, *options.DependentBugStates) {
 				valid = false
 				expected := strings.Join(prettyStates(*options.DependentBugStates), ", ")
 				actual := bugzilla.PrettyStatus(bug.Status, bug.Resolution)
-				errors = append(errors, fmt.Sprintf("expected dependent "+bugLink+" to be in one of the following states: %s, but it is %s instead", bug.ID, endpoint, bug.ID, expected, actual))
+				failures.add(bug.ID,
+					fmt.Sprintf("to be in one of the following states: %s, but it is %s instead", expected, actual),
+					fmt.Sprintf("to be in one of the following states: %s, but they are %s instead", expected, actual))
 			} else {
-				validations = append(validations, fmt.Sprintf("dependent bug "+bugLink+" is in the state %s, which is one of the valid states (%s)", bug.ID, endpoint, bug.ID, bugzilla.PrettyStatus(bug.Status, bug.Resolution), strings.Join(prettyStates(*options.DependentBugStates), ", ")))
+				validations = append(validations, fmt.Sprintf("dependent bug "+bugLink+" is in the state %s, which is one of the valid states (%s)", bug.ID, dependentEndpoint, bug.ID, bugzilla.PrettyStatus(bug.Status, bug.Resolution), strings.Join(prettyStates(*options.DependentBugStates), ", ")))
 			}
 		}
+		errors = append(errors, failures.render(dependentEndpoint)...)
 	}
 
-	if options.DependentBugTargetRelease != nil {
+	dependentReleases := dependentTargetReleases(options)
+	if len(dependentReleases) > 0 {
+		expected := describeReleases(dependentReleases)
+		failures := &dependentFailures{}
 		for _, bug := range dependents {
 			if len(bug.TargetRelease) == 0 {
 				valid = false
-				errors = append(errors, fmt.Sprintf("expected dependent "+bugLink+" to target the %q release, but no target release was set", bug.ID, endpoint, bug.ID, *options.DependentBugTargetRelease))
-			} else if *options.DependentBugTargetRelease != bug.TargetRelease[0] {
+				failures.add(bug.ID,
+					fmt.Sprintf("to target %s, but no target release was set", expected),
+					fmt.Sprintf("to target %s, but no target release was set on them", expected))
+			} else if !sets.NewString(dependentReleases...).Has(bug.TargetRelease[0]) {
 				// the BugZilla web UI shows one option for target release, but returns the
 				// field as a list in the REST API. We only care for the first item and it's
 				// not even clear if the list can have more than one item in the response
 				valid = false
-				errors = append(errors, fmt.Sprintf("expected dependent "+bugLink+" to target the %q release, but it targets %q instead", bug.ID, endpoint, bug.ID, *options.DependentBugTargetRelease, bug.TargetRelease[0]))
+				failures.add(bug.ID,
+					fmt.Sprintf("to target %s, but it targets %q instead", expected, bug.TargetRelease[0]),
+					fmt.Sprintf("to target %s, but they target %q instead", expected, bug.TargetRelease[0]))
 			} else {
-				validations = append(validations, fmt.Sprintf("dependent "+bugLink+" targets the %q release, matching the expected (%s) release", bug.ID, endpoint, bug.ID, bug.TargetRelease[0], *options.DependentBugTargetRelease))
+				validations = append(validations, fmt.Sprintf("dependent "+bugLink+" targets the %q release, matching the expected (%s) release", bug.ID, dependentEndpoint, bug.ID, bug.TargetRelease[0], strings.Join(dependentReleases, ", ")))
 			}
 		}
+		errors = append(errors, failures.render(dependentEndpoint)...)
+	}
+
+	dependentCountBounded := options.MinDependentBugs != nil || options.MaxDependentBugs != nil
+	if dependentCountBounded {
+		count := len(dependentIDs(&bug, options))
+		expected := dependentCountRange(options.MinDependentBugs, options.MaxDependentBugs)
+		if (options.MinDependentBugs != nil && count < *options.MinDependentBugs) || (options.MaxDependentBugs != nil && count > *options.MaxDependentBugs) {
+			valid = false
+			errors = append(errors, fmt.Sprintf("expected "+bugLink+" to depend on %s bug(s), but it depends on %d", bug.ID, endpoint, bug.ID, expected, count))
+		} else {
+			validations = append(validations, fmt.Sprintf("bug depends on %d bug(s), matching the expected number (%s)", count, expected))
+		}
 	}
 
 	if len(dependents) == 0 {
 		switch {
-		case options.DependentBugStates != nil && options.DependentBugTargetRelease != nil:
+		case options.DependentBugStates != nil && len(dependentReleases) > 0:
 			valid = false
 			expected := strings.Join(prettyStates(*options.DependentBugStates), ", ")
-			errors = append(errors, fmt.Sprintf("expected "+bugLink+" to depend on a bug targeting the %q release and in one of the following states: %s, but no dependents were found", bug.ID, endpoint, bug.ID, *options.DependentBugTargetRelease, expected))
+			errors = append(errors, fmt.Sprintf("expected "+bugLink+" to depend on a bug targeting %s and in one of the following states: %s, but no dependents were found", bug.ID, endpoint, bug.ID, describeReleases(dependentReleases), expected))
 		case options.DependentBugStates != nil:
 			valid = false
 			expected := strings.Join(prettyStates(*options.DependentBugStates), ", ")
 			errors = append(errors, fmt.Sprintf("expected "+bugLink+" to depend on a bug in one of the following states: %s, but no dependents were found", bug.ID, endpoint, bug.ID, expected))
-		case options.DependentBugTargetRelease != nil:
+		case len(dependentReleases) > 0:
 			valid = false
-			errors = append(errors, fmt.Sprintf("expected "+bugLink+" to depend on a bug targeting the %q release, but no dependents were found", bug.ID, endpoint, bug.ID, *options.DependentBugTargetRelease))
+			errors = append(errors, fmt.Sprintf("expected "+bugLink+" to depend on a bug targeting %s, but no dependents were found", bug.ID, endpoint, bug.ID, describeReleases(dependentReleases)))
 		default:
 		}
-	} else {
+	} else if !dependentCountBounded {
 		validations = append(validations, "bug has dependents")
 	}
 
-	return valid, validations, errors
+	return ValidationResult{Valid: valid, Validations: validations, Errors: errors}, nil
+}
+
+// dependentIDs determines the IDs of the bugs that are validated as the bug's
+// dependents, following the configured direction of the relationship
+func dependentIDs(bug *bugzilla.Bug, options plugins.BugzillaBranchOptions) []int {
+	if options.DependentDirection != nil && *options.DependentDirection == plugins.BugzillaDependentDirectionBlocks {
+		return bug.Blocks
+	}
+	return bug.DependsOn
+}
+
+// dependentCountRange describes the allowed number of dependent bugs
+func dependentCountRange(min, max *int) string {
+	switch {
+	case min != nil && max != nil && *min == *max:
+		return fmt.Sprintf("exactly %d", *min)
+	case min != nil && max != nil:
+		return fmt.Sprintf("between %d and %d", *min, *max)
+	case min != nil:
+		return fmt.Sprintf("at least %d", *min)
+	default:
+		return fmt.Sprintf("at most %d", *max)
+	}
+}
+
+// handleCherrypick clones the referenced bug for the branch that a cherry-pick
+// of the pull request targets, so the cherry-pick can reference the clone
+func handleCherrypick(ctx context.Context, e event, gc githubClient, bc bugzilla.Client, options, targetOptions plugins.BugzillaBranchOptions, log *logrus.Entry) error {
+	log = e.logger(log).WithField("cherrypickTo", e.cherrypickTo)
+	comment := e.comment(gc)
+	bc = bc.WithContext(ctx)
+	if e.missing {
+		return comment(fmt.Sprintf(`No Bugzilla bug is referenced in the title of this pull request, so there is no bug to clone for the %s branch.
+To reference a bug, add 'Bug XXX:' to the title of this pull request and request another clone with <code>/bugzilla cherrypick %s</code>.`, e.cherrypickTo, e.cherrypickTo))
+	}
+	bug, err := getBug(ctx, bc, e.bugId, options.BugRetries, log, comment)
+	if err != nil || bug == nil {
+		return err
+	}
+
+	clone := bugzilla.CloneBugStruct(bug)
+	if releases := targetReleases(targetOptions); len(releases) > 0 {
+		clone.TargetRelease = []string{releases[0]}
+	}
+	cloneId, err := bc.CreateBug(clone)
+	if err != nil {
+		log.WithError(err).Warn("Unexpected error cloning Bugzilla bug.")
+		return comment(formatError(fmt.Sprintf("cloning for the %s branch", e.cherrypickTo), bc.Endpoint(), e.bugId, err))
+	}
+	log.WithField("cloneId", cloneId).Debug("Cloned Bugzilla bug.")
+	return comment(fmt.Sprintf(bugLink+` has been cloned as `+bugLink+` for the %s branch.
+Title the cherry-pick pull request against the %s branch <code>Bug %d: %s</code> to reference the clone.`,
+		e.bugId, bc.Endpoint(), e.bugId, cloneId, bc.Endpoint(), cloneId, e.cherrypickTo, e.cherrypickTo, cloneId, bug.Summary))
+}
+
+// handleUnlink removes the pull request from the external tracker bugs of the
+// referenced bug, along with the labels that mark it as referencing the bug
+func handleUnlink(ctx context.Context, e event, gc githubClient, bc bugzilla.Client, options plugins.BugzillaBranchOptions, log *logrus.Entry) error {
+	log = e.logger(log)
+	comment := e.comment(gc)
+	bc = bc.WithContext(ctx)
+	if e.missing {
+		return comment(`No Bugzilla bug is referenced in the title of this pull request, so there is no external tracker link to remove.`)
+	}
+
+	changed, err := bc.RemoveExternalBugFromBug(e.bugId, e.org, e.repo, e.number)
+	if err != nil {
+		log.WithError(err).Warn("Unexpected error removing external tracker bug from Bugzilla bug.")
+		return comment(formatError("removing this pull request from the external tracker bugs", bc.Endpoint(), e.bugId, err))
+	}
+
+	// the labels no longer reflect a link to the bug. Do not propagate errors
+	// as it is more important to report to the user than to fail early on a
+	// label check.
+	currentLabels, err := gc.GetIssueLabels(e.org, e.repo, e.number)
+	if err != nil {
+		log.WithError(err).Warn("Could not list labels on PR")
+	}
+	validLabel, invalidLabel := bugLabels(options)
+	for _, l := range currentLabels {
+		if l.Name != validLabel && l.Name != invalidLabel {
+			continue
+		}
+		if err := gc.RemoveLabel(e.org, e.repo, e.number, l.Name); err != nil {
+			log.WithError(err).WithField("label", l.Name).Error("Failed to remove bug label.")
+		}
+	}
+
+	if !changed {
+		return comment(fmt.Sprintf(bugLink+" has no external tracker link to this pull request, so there is nothing to remove.", e.bugId, bc.Endpoint(), e.bugId))
+	}
+	return comment(fmt.Sprintf(`This pull request has been removed from the external tracker bugs of `+bugLink+`.
+Edit the title of this pull request to reference the correct bug.`, e.bugId, bc.Endpoint(), e.bugId))
 }
 
-func handleMerge(e event, gc githubClient, bc bugzilla.Client, options plugins.BugzillaBranchOptions, log *logrus.Entry) error {
+func handleMerge(ctx context.Context, e event, gc githubClient, bc bugzilla.Client, options plugins.BugzillaBranchOptions, log *logrus.Entry) error {
 	comment := e.comment(gc)
 
 	if options.StateAfterMerge == nil {
@@ -662,13 +1715,15 @@ func handleMerge(e event, gc githubClient, bc bugzilla.Client, options plugins.B
 	if e.missing {
 		return nil
 	}
+	var bug *bugzilla.Bug
 	if options.ValidStates != nil || options.StateAfterValidation != nil {
 		// we should only migrate if we can be fairly certain that the bug
 		// is not in a state that required human intervention to get to.
 		// For instance, if a bug is closed after a PR merges it should not
 		// be possible for /bugzilla refresh to move it back to the post-merge
 		// state.
-		bug, err := getBug(bc, e.bugId, log, comment)
+		var err error
+		bug, err = getBug(ctx, bc, e.bugId, options.BugRetries, log, comment)
 		if err != nil || bug == nil {
 			return err
 		}
@@ -691,7 +1746,8 @@ func handleMerge(e event, gc githubClient, bc bugzilla.Client, options plugins.B
 		return comment(formatError("searching for external tracker bugs", bc.Endpoint(), e.bugId, err))
 	}
 	shouldMigrate := true
-	var mergedPRs []bugzilla.ExternalBug
+	reportAll := options.ReportAllUnmergedPRs != nil && *options.ReportAllUnmergedPRs
+	var mergedPRs, unmergedPRs []bugzilla.ExternalBug
 	unmergedPrStates := map[bugzilla.ExternalBug]string{}
 	for _, item := range prs {
 		var merged bool
@@ -703,7 +1759,7 @@ func handleMerge(e event, gc githubClient, bc bugzilla.Client, options plugins.B
 			pr, err := gc.GetPullRequest(item.Org, item.Repo, item.Num)
 			if err != nil {
 				log.WithError(err).Warn("Unexpected error checking merge state of related pull request.")
-				return comment(formatError(fmt.Sprintf("checking the state of a related pull request at https://github.com/%s/%s/pull/%d", item.Org, item.Repo, item.Num), bc.Endpoint(), e.bugId, err))
+				return comment(formatError(fmt.Sprintf("checking the state of related pull request %s/%s#%d", item.Org, item.Repo, item.Num), bc.Endpoint(), e.bugId, err))
 			}
 			merged = pr.Merged
 			state = pr.State
@@ -711,14 +1767,15 @@ func handleMerge(e event, gc githubClient, bc bugzilla.Client, options plugins.B
 		if merged {
 			mergedPRs = append(mergedPRs, item)
 		} else {
+			unmergedPRs = append(unmergedPRs, item)
 			unmergedPrStates[item] = state
 		}
 		// only update Bugzilla bug status if all PRs have merged
 		shouldMigrate = shouldMigrate && merged
-		if !shouldMigrate {
+		if !shouldMigrate && !reportAll {
 			// we could give more complete feedback to the user by checking all PRs
 			// but we save tokens by exiting when we find an unmerged one, so we
-			// prefer to do that
+			// prefer to do that unless configured otherwise
 			break
 		}
 	}
@@ -736,10 +1793,10 @@ func handleMerge(e event, gc githubClient, bc bugzilla.Client, options plugins.B
 	}
 
 	var statements []string
-	for bug, state := range unmergedPrStates {
-		statements = append(statements, fmt.Sprintf("\n * %s is %s", link(bug), state))
+	for _, bug := range unmergedPRs {
+		statements = append(statements, fmt.Sprintf("\n * %s is %s", link(bug), unmergedPrStates[bug]))
 	}
-	unmergedMessage := fmt.Sprintf(`The following pull requests linked via external trackers have not merged:%s`, strings.Join(statements, "\n"))
+	unmergedMessage := fmt.Sprintf(`The following pull requests linked via external trackers have not merged:%s`, strings.Join(statements, ""))
 
 	outcomeMessage := func(action string) string {
 		return fmt.Sprintf(bugLink+" has %sbeen moved to the %s state.", e.bugId, bc.Endpoint(), e.bugId, action, options.StateAfterMerge)
@@ -752,17 +1809,76 @@ func handleMerge(e event, gc githubClient, bc bugzilla.Client, options plugins.B
 	}
 
 	if shouldMigrate {
+		if bug == nil {
+			bug, err = getBug(ctx, bc, e.bugId, options.BugRetries, log, comment)
+			if err != nil || bug == nil {
+				return err
+			}
+		}
+		// avoid a needless write to the bug if nothing would change
+		if options.StateAfterMerge.Matches(bug) {
+			return comment(fmt.Sprintf("%s "+bugLink+" is already in the %s state; no update needed.", mergedMessage("All"), e.bugId, bc.Endpoint(), e.bugId, options.StateAfterMerge))
+		}
 		if err := bc.UpdateBug(e.bugId, *update); err != nil {
 			log.WithError(err).Warn("Unexpected error updating Bugzilla bug.")
 			return comment(formatError(fmt.Sprintf("updating to the %s state", options.StateAfterMerge), bc.Endpoint(), e.bugId, err))
 		}
+		if options.AddCommentOnMerge != nil && *options.AddCommentOnMerge {
+			notifyMerge(e, bc, options, log)
+		}
 		return comment(fmt.Sprintf("%s %s", mergedMessage("All"), outcomeMessage("")))
 	}
 	return comment(fmt.Sprintf("%s %s\n%s", mergedMessage("Some"), unmergedMessage, outcomeMessage("")))
 }
 
-func getBug(bc bugzilla.Client, bugId int, log *logrus.Entry, comment func(string) error) (*bugzilla.Bug, error) {
-	bug, err := bc.GetBug(bugId)
+// notifyMerge comments on the bug to link back to the pull request whose merge
+// moved the bug to the post-merge state. Errors are not propagated, as the bug
+// has already been updated and the comment is only a courtesy to its owners.
+func notifyMerge(e event, bc bugzilla.Client, options plugins.BugzillaBranchOptions, log *logrus.Entry) {
+	message := fmt.Sprintf("Pull request %s/%s#%d has merged, moving this bug to the %s state.", e.org, e.repo, e.number, options.StateAfterMerge)
+	if _, err := bc.CreateBugComment(e.bugId, message); err != nil {
+		log.WithError(err).Warn("Unexpected error commenting on Bugzilla bug about the merged pull request.")
+	}
+}
+
+// getBugWithRetries fetches the bug, retrying with exponential backoff up to the
+// configured number of times while the Bugzilla server responds with transient errors,
+// giving up early if the context is done
+func getBugWithRetries(ctx context.Context, bc bugzilla.Client, bugId int, retries *int, log *logrus.Entry) (*bugzilla.Bug, error) {
+	var maxRetries int
+	if retries != nil {
+		maxRetries = *retries
+	}
+	backoff := retryInitialBackoff
+	for attempt := 0; ; attempt++ {
+		bug, err := bc.GetBug(bugId)
+		if err == nil || !bugzilla.IsRetryable(err) || attempt >= maxRetries {
+			return bug, err
+		}
+		log.WithError(err).Debugf("Transient error searching for Bugzilla bug %d, retrying in %s.", bugId, backoff)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func getBug(ctx context.Context, bc bugzilla.Client, bugId int, retries *int, log *logrus.Entry, comment func(string) error) (*bugzilla.Bug, error) {
+	bug, err := getBugWithRetries(ctx, bc, bugId, retries, log)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		log.WithError(err).Warn("Timed out searching for Bugzilla bug.")
+		return nil, comment(fmt.Sprintf(`Timed out searching for bug %d on the Bugzilla server at %s.
+Please try again later by requesting a bug refresh with <code>/bugzilla refresh</code>.`,
+			bugId, bc.Endpoint()))
+	}
+	if bugzilla.IsAccessDenied(err) {
+		log.WithError(err).Info("Not authorized to access Bugzilla bug.")
+		return nil, comment(fmt.Sprintf(`Bugzilla bug %d could not be accessed in the tracker at %s. The bug may be private.
+Please contact an administrator to grant the bot access to the bug, then request a bug refresh with <code>/bugzilla refresh</code>.`,
+			bugId, bc.Endpoint()))
+	}
 	if err != nil && !bugzilla.IsNotFound(err) {
 		log.WithError(err).Warn("Unexpected error searching for Bugzilla bug.")
 		return nil, comment(formatError("searching", bc.Endpoint(), bugId, err))
@@ -776,6 +1892,24 @@ Once a valid bug is referenced in the title of this pull request, request a bug
 	return bug, nil
 }
 
+// componentsForFiles determines the set of components that the files map to,
+// using the longest path prefix in the mapping that matches each file.
+func componentsForFiles(files []string, mapping map[string]string) sets.String {
+	components := sets.NewString()
+	for _, file := range files {
+		var longest string
+		for prefix := range mapping {
+			if strings.HasPrefix(file, prefix) && len(prefix) > len(longest) {
+				longest = prefix
+			}
+		}
+		if longest != "" {
+			components.Insert(mapping[longest])
+		}
+	}
+	return components
+}
+
 func formatError(action, endpoint string, bugId int, err error) string {
 	return fmt.Sprintf(`An error was encountered %s for bug %d on the Bugzilla server at %s:
 > %v
@@ -17,10 +17,17 @@ limitations under the License.
 package bugzilla
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/diff"
@@ -65,11 +72,18 @@ orgs:
             - status: VALIDATED
           "my-repo-branch":
             target_release: my-repo-branch
+            target_releases:
+            - my-repo-branch-z
             valid_states:
             - status: MODIFIED
             add_external_link: true
             state_after_merge:
               status: MODIFIED
+            excluded_logins:
+            - some-bot
+            require_assignee: true
+            required_flags:
+            - qe_test_coverage
           "branch-that-likes-closed-bugs":
             valid_states:
             - status: VERIFIED
@@ -116,13 +130,13 @@ orgs:
 <li>by default, valid bugs must be closed, target the "my-repo-default" release, and be in one of the following states: VALIDATED. After being linked to a pull request, bugs will be moved to the PRE state.</li>
 <li>on the "branch-that-likes-closed-bugs" branch, valid bugs must be closed, target the "my-repo-default" release, be in one of the following states: VERIFIED, CLOSED (ERRATA), depend on at least one other bug, and have all dependent bugs in one of the following states: CLOSED (ERRATA). After being linked to a pull request, bugs will be moved to the CLOSED (VALIDATED) state and moved to the CLOSED (FIXED) state when all linked pull requests are merged.</li>
 <li>on the "my-org-branch" branch, valid bugs must be closed, target the "my-repo-default" release, and be in one of the following states: VALIDATED. After being linked to a pull request, bugs will be moved to the POST state and updated to refer to the pull request using the external bug tracker.</li>
-<li>on the "my-repo-branch" branch, valid bugs must be closed, target the "my-repo-branch" release, and be in one of the following states: MODIFIED. After being linked to a pull request, bugs will be moved to the PRE state, updated to refer to the pull request using the external bug tracker, and moved to the MODIFIED state when all linked pull requests are merged.</li>
+<li>on the "my-repo-branch" branch, valid bugs must be closed, target one of the following releases: "my-repo-branch", "my-repo-branch-z", be in one of the following states: MODIFIED, be assigned to someone, and have the following flags set to "+": qe_test_coverage. After being linked to a pull request, bugs will be moved to the PRE state, updated to refer to the pull request using the external bug tracker, and moved to the MODIFIED state when all linked pull requests are merged. Pull requests opened by and commands from the following users are ignored: some-bot.</li>
 </ul>`,
 		},
 		Commands: []pluginhelp.Command{
 			{
 				Usage:       "/bugzilla refresh",
-				Description: "Check Bugzilla for a valid bug referenced in the PR title",
+				Description: "Check Bugzilla for a valid bug referenced in the PR title. Also recognized when added to the PR description",
 				Featured:    false,
 				WhoCanUse:   "Anyone",
 				Examples:    []string{"/bugzilla refresh"},
@@ -132,6 +146,24 @@ orgs:
 				Featured:    false,
 				WhoCanUse:   "Anyone",
 				Examples:    []string{"/bugzilla assign-qa"},
+			}, {
+				Usage:       "/bugzilla cc-qa",
+				Description: "Request review from the QA contact specified in Bugzilla without assigning the PR to them",
+				Featured:    false,
+				WhoCanUse:   "Anyone",
+				Examples:    []string{"/bugzilla cc-qa"},
+			}, {
+				Usage:       "/bugzilla cherrypick <branch>",
+				Description: "Clone the Bugzilla bug referenced in the PR title for the target branch of a cherry-pick",
+				Featured:    false,
+				WhoCanUse:   "Anyone",
+				Examples:    []string{"/bugzilla cherrypick release-4.12"},
+			}, {
+				Usage:       "/bugzilla unlink",
+				Description: "Remove the PR from the external tracker bugs of the Bugzilla bug referenced in the PR title",
+				Featured:    false,
+				WhoCanUse:   "Anyone",
+				Examples:    []string{"/bugzilla unlink"},
 			},
 		},
 	}
@@ -147,9 +179,36 @@ func TestDigestPR(t *testing.T) {
 		name              string
 		pre               github.PullRequestEvent
 		validateByDefault *bool
+		validateOnPush    *bool
+		excludedLogins    []string
 		expected          *event
 		expectedErr       bool
 	}{
+		{
+			name: "pull request from excluded login gets ignored",
+			pre: github.PullRequestEvent{
+				Action: github.PullRequestActionOpened,
+				PullRequest: github.PullRequest{
+					Base: github.PullRequestBranch{
+						Repo: github.Repo{
+							Owner: github.User{
+								Login: "org",
+							},
+							Name: "repo",
+						},
+						Ref: "branch",
+					},
+					Number: 1,
+					Title:  "bump dependencies",
+					State:  "open",
+					User: github.User{
+						Login: "Dependency-Bot",
+					},
+				},
+			},
+			validateByDefault: &yes,
+			excludedLogins:    []string{"dependency-bot"},
+		},
 		{
 			name: "unrelated event gets ignored",
 			pre: github.PullRequestEvent{
@@ -245,6 +304,85 @@ func TestDigestPR(t *testing.T) {
 				org: "org", repo: "repo", baseRef: "branch", number: 1, state: "open", bugId: 123, body: "Bug 123: fixed it!", htmlUrl: "http.com", login: "user",
 			},
 		},
+		{
+			name: "title referencing bug with a non-breaking space and full-width colon gets an event",
+			pre: github.PullRequestEvent{
+				Action: github.PullRequestActionOpened,
+				PullRequest: github.PullRequest{
+					Base: github.PullRequestBranch{
+						Repo: github.Repo{
+							Owner: github.User{
+								Login: "org",
+							},
+							Name: "repo",
+						},
+						Ref: "branch",
+					},
+					Number:  1,
+					Title:   "Bug\u00a0123\uff1a fixed it!",
+					State:   "open",
+					HTMLURL: "http.com",
+					User: github.User{
+						Login: "user",
+					},
+				},
+			},
+			expected: &event{
+				org: "org", repo: "repo", baseRef: "branch", number: 1, state: "open", bugId: 123, body: "Bug\u00a0123\uff1a fixed it!", htmlUrl: "http.com", login: "user",
+			},
+		},
+		{
+			name: "new commits on a pull request referencing a bug get ignored by default",
+			pre: github.PullRequestEvent{
+				Action: github.PullRequestActionSynchronize,
+				PullRequest: github.PullRequest{
+					Base: github.PullRequestBranch{
+						Repo: github.Repo{
+							Owner: github.User{
+								Login: "org",
+							},
+							Name: "repo",
+						},
+						Ref: "branch",
+					},
+					Number:  1,
+					Title:   "Bug 123: fixed it!",
+					State:   "open",
+					HTMLURL: "http.com",
+					User: github.User{
+						Login: "user",
+					},
+				},
+			},
+		},
+		{
+			name: "new commits on a pull request referencing a bug get an event when validating on push",
+			pre: github.PullRequestEvent{
+				Action: github.PullRequestActionSynchronize,
+				PullRequest: github.PullRequest{
+					Base: github.PullRequestBranch{
+						Repo: github.Repo{
+							Owner: github.User{
+								Login: "org",
+							},
+							Name: "repo",
+						},
+						Ref: "branch",
+					},
+					Number:  1,
+					Title:   "Bug 123: fixed it!",
+					State:   "open",
+					HTMLURL: "http.com",
+					User: github.User{
+						Login: "user",
+					},
+				},
+			},
+			validateOnPush: &yes,
+			expected: &event{
+				org: "org", repo: "repo", baseRef: "branch", number: 1, state: "open", bugId: 123, body: "Bug 123: fixed it!", htmlUrl: "http.com", login: "user",
+			},
+		},
 		{
 			name: "title referencing bug gets an event on PR merge",
 			pre: github.PullRequestEvent{
@@ -272,6 +410,112 @@ func TestDigestPR(t *testing.T) {
 				org: "org", repo: "repo", baseRef: "branch", number: 1, merged: true, bugId: 123, body: "Bug 123: fixed it!", htmlUrl: "http.com", login: "user",
 			},
 		},
+		{
+			name: "refresh command added to the description gets an event",
+			pre: github.PullRequestEvent{
+				Action: github.PullRequestActionEdited,
+				PullRequest: github.PullRequest{
+					Base: github.PullRequestBranch{
+						Repo: github.Repo{
+							Owner: github.User{
+								Login: "org",
+							},
+							Name: "repo",
+						},
+						Ref: "branch",
+					},
+					Number:  1,
+					Title:   "fixing a typo",
+					Body:    "Fixes things.\n/bugzilla refresh",
+					State:   "open",
+					HTMLURL: "http.com",
+					User: github.User{
+						Login: "user",
+					},
+				},
+				Changes: []byte(`{"body":{"from":"Fixes things."}}`),
+			},
+			expected: &event{
+				org: "org", repo: "repo", baseRef: "branch", number: 1, state: "open", missing: true, body: "fixing a typo", htmlUrl: "http.com", login: "user",
+			},
+		},
+		{
+			name: "description edit keeping an existing refresh command gets no event",
+			pre: github.PullRequestEvent{
+				Action: github.PullRequestActionEdited,
+				PullRequest: github.PullRequest{
+					Base: github.PullRequestBranch{
+						Repo: github.Repo{
+							Owner: github.User{
+								Login: "org",
+							},
+							Name: "repo",
+						},
+						Ref: "branch",
+					},
+					Number:  1,
+					Title:   "fixing a typo",
+					Body:    "Fixes more things.\n/bugzilla refresh",
+					State:   "open",
+					HTMLURL: "http.com",
+					User: github.User{
+						Login: "user",
+					},
+				},
+				Changes: []byte(`{"body":{"from":"Fixes things.\\n/bugzilla refresh"}}`),
+			},
+		},
+		{
+			name: "title edit with a refresh command in the description gets no event",
+			pre: github.PullRequestEvent{
+				Action: github.PullRequestActionEdited,
+				PullRequest: github.PullRequest{
+					Base: github.PullRequestBranch{
+						Repo: github.Repo{
+							Owner: github.User{
+								Login: "org",
+							},
+							Name: "repo",
+						},
+						Ref: "branch",
+					},
+					Number:  1,
+					Title:   "fixing a typo",
+					Body:    "/bugzilla refresh",
+					State:   "open",
+					HTMLURL: "http.com",
+					User: github.User{
+						Login: "user",
+					},
+				},
+				Changes: []byte(`{"title":{"from":"fixing typos"}}`),
+			},
+		},
+		{
+			name: "refresh command in the description of a new pull request gets no event",
+			pre: github.PullRequestEvent{
+				Action: github.PullRequestActionOpened,
+				PullRequest: github.PullRequest{
+					Base: github.PullRequestBranch{
+						Repo: github.Repo{
+							Owner: github.User{
+								Login: "org",
+							},
+							Name: "repo",
+						},
+						Ref: "branch",
+					},
+					Number:  1,
+					Title:   "fixing a typo",
+					Body:    "/bugzilla refresh",
+					State:   "open",
+					HTMLURL: "http.com",
+					User: github.User{
+						Login: "user",
+					},
+				},
+			},
+		},
 		{
 			name: "title change referencing same bug gets no event",
 			pre: github.PullRequestEvent{
@@ -378,7 +622,7 @@ func TestDigestPR(t *testing.T) {
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			event, err := digestPR(logrus.WithField("testCase", testCase.name), testCase.pre, testCase.validateByDefault)
+			event, err := digestPR(logrus.WithField("testCase", testCase.name), testCase.pre, testCase.validateByDefault, testCase.validateOnPush, testCase.excludedLogins)
 			if err == nil && testCase.expectedErr {
 				t.Errorf("%s: expected an error but got none", testCase.name)
 			}
@@ -539,71 +783,195 @@ Instructions for interacting with me using PR comments are available [here](http
 				org: "org", repo: "repo", baseRef: "branch", number: 1, bugId: 123, body: "/bugzilla assign-qa", htmlUrl: "www.com", login: "user", assign: true,
 			},
 		},
-	}
-
-	for _, testCase := range testCases {
-		t.Run(testCase.name, func(t *testing.T) {
-			client := fakegithub.FakeClient{
-				PullRequests: map[int]*github.PullRequest{
-					1: {Base: github.PullRequestBranch{Ref: "branch"}, Title: testCase.title, Merged: testCase.merged},
-				},
-				IssueComments: map[int][]github.IssueComment{},
-			}
-			event, err := digestComment(&client, logrus.WithField("testCase", testCase.name), testCase.e)
-			if err == nil && testCase.expectedErr {
-				t.Errorf("%s: expected an error but got none", testCase.name)
-			}
-			if err != nil && !testCase.expectedErr {
-				t.Errorf("%s: expected no error but got one: %v", testCase.name, err)
-			}
-
-			if actual, expected := event, testCase.expected; !reflect.DeepEqual(actual, expected) {
-				t.Errorf("%s: did not get correct event: %v", testCase.name, diff.ObjectReflectDiff(actual, expected))
-			}
-
-			checkComments(client, testCase.name, testCase.expectedComment, t)
-		})
-	}
-}
-
-func TestHandle(t *testing.T) {
-	yes := true
-	open := true
-	v1 := "v1"
-	v2 := "v2"
-	updated := plugins.BugzillaBugState{Status: "UPDATED"}
-	modified := plugins.BugzillaBugState{Status: "MODIFIED"}
-	verified := []plugins.BugzillaBugState{{Status: "VERIFIED"}}
-	base := &event{
-		org: "org", repo: "repo", baseRef: "branch", number: 1, bugId: 123, body: "Bug 123: fixed it!", htmlUrl: "http.com", login: "user",
-	}
-	var testCases = []struct {
-		name                 string
-		labels               []string
-		missing              bool
-		merged               bool
-		externalBugs         []bugzilla.ExternalBug
-		prs                  []github.PullRequest
-		bugs                 []bugzilla.Bug
-		bugErrors            []int
-		options              plugins.BugzillaBranchOptions
-		expectedLabels       []string
-		expectedComment      string
-		expectedBug          *bugzilla.Bug
-		expectedExternalBugs []bugzilla.ExternalBug
-	}{
 		{
-			name: "no bug found leaves a comment",
-			expectedComment: `org/repo#1:@user: No Bugzilla bug with ID 123 exists in the tracker at www.bugzilla.
-Once a valid bug is referenced in the title of this pull request, request a bug refresh with <code>/bugzilla refresh</code>.
-
-<details>
-
-In response to [this](http.com):
-
->Bug 123: fixed it!
-
-
+			name: "cc-qa comment event has cc bool set to true",
+			e: github.GenericCommentEvent{
+				Action: github.GenericCommentActionCreated,
+				IsPR:   true,
+				Body:   "/bugzilla cc-qa",
+				Repo: github.Repo{
+					Owner: github.User{
+						Login: "org",
+					},
+					Name: "repo",
+				},
+				Number: 1,
+				User: github.User{
+					Login: "user",
+				},
+				HTMLURL: "www.com",
+			},
+			title: "Bug 123: oopsie doopsie",
+			expected: &event{
+				org: "org", repo: "repo", baseRef: "branch", number: 1, bugId: 123, body: "/bugzilla cc-qa", htmlUrl: "www.com", login: "user", cc: true,
+			},
+		},
+		{
+			name: "unlink comment event has unlink bool set to true",
+			e: github.GenericCommentEvent{
+				Action: github.GenericCommentActionCreated,
+				IsPR:   true,
+				Body:   "/bugzilla unlink",
+				Repo: github.Repo{
+					Owner: github.User{
+						Login: "org",
+					},
+					Name: "repo",
+				},
+				Number: 1,
+				User: github.User{
+					Login: "user",
+				},
+				HTMLURL: "www.com",
+			},
+			title: "Bug 123: oopsie doopsie",
+			expected: &event{
+				org: "org", repo: "repo", baseRef: "branch", number: 1, bugId: 123, body: "/bugzilla unlink", htmlUrl: "www.com", login: "user", unlink: true,
+			},
+		},
+		{
+			name: "cherrypick comment event has target branch set",
+			e: github.GenericCommentEvent{
+				Action: github.GenericCommentActionCreated,
+				IsPR:   true,
+				Body:   "/bugzilla cherrypick release-4.12",
+				Repo: github.Repo{
+					Owner: github.User{
+						Login: "org",
+					},
+					Name: "repo",
+				},
+				Number: 1,
+				User: github.User{
+					Login: "user",
+				},
+				HTMLURL: "www.com",
+			},
+			title: "Bug 123: oopsie doopsie",
+			expected: &event{
+				org: "org", repo: "repo", baseRef: "branch", number: 1, bugId: 123, body: "/bugzilla cherrypick release-4.12", htmlUrl: "www.com", login: "user", cherrypickTo: "release-4.12",
+			},
+		},
+		{
+			name: "cherrypick comment without target branch gets no event but a comment",
+			e: github.GenericCommentEvent{
+				Action: github.GenericCommentActionCreated,
+				IsPR:   true,
+				Body:   "/bugzilla cherrypick",
+				Repo: github.Repo{
+					Owner: github.User{
+						Login: "org",
+					},
+					Name: "repo",
+				},
+				Number: 1,
+				User: github.User{
+					Login: "user",
+				},
+				HTMLURL: "www.com",
+			},
+			title: "Bug 123: oopsie doopsie",
+			expectedComment: `org/repo#1:@user: A target branch is required to clone a bug for a cherry-pick, for example: <code>/bugzilla cherrypick release-4.12</code>.
+
+<details>
+
+In response to [this](www.com):
+
+>/bugzilla cherrypick
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := fakegithub.FakeClient{
+				PullRequests: map[int]*github.PullRequest{
+					1: {Base: github.PullRequestBranch{Ref: "branch"}, Title: testCase.title, Merged: testCase.merged},
+				},
+				IssueComments: map[int][]github.IssueComment{},
+			}
+			event, err := digestComment(&client, logrus.WithField("testCase", testCase.name), testCase.e)
+			if err == nil && testCase.expectedErr {
+				t.Errorf("%s: expected an error but got none", testCase.name)
+			}
+			if err != nil && !testCase.expectedErr {
+				t.Errorf("%s: expected no error but got one: %v", testCase.name, err)
+			}
+
+			if actual, expected := event, testCase.expected; !reflect.DeepEqual(actual, expected) {
+				t.Errorf("%s: did not get correct event: %v", testCase.name, diff.ObjectReflectDiff(actual, expected))
+			}
+
+			checkComments(client, testCase.name, testCase.expectedComment, t)
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	yes := true
+	open := true
+	v1 := "v1"
+	v2 := "v2"
+	updated := plugins.BugzillaBugState{Status: "UPDATED"}
+	modified := plugins.BugzillaBugState{Status: "MODIFIED"}
+	verified := []plugins.BugzillaBugState{{Status: "VERIFIED"}}
+	one, two := 1, 2
+	gracePeriod := 30
+	yesComment := true
+	blocks := plugins.BugzillaDependentDirectionBlocks
+	base := &event{
+		org: "org", repo: "repo", baseRef: "branch", number: 1, bugId: 123, body: "Bug 123: fixed it!", htmlUrl: "http.com", login: "user",
+	}
+	// don't wait between retries of transient errors
+	retryInitialBackoff = 0
+	componentsByPath := map[string]string{"pkg/network/": "Networking", "pkg/storage/": "Storage"}
+	validTemplate := `{{.BugLink}} ({{.Bug.Summary}}) is good to go, see {{.Endpoint}} for details.`
+	invalidTemplate := `{{.BugLink}} needs work:{{range .Reasons}}
+* {{.}}{{end}}
+Ask in #team-channel if you need help.`
+	brokenTemplate := `{{.Bug.NoSuchField}}`
+	upstream := "www.upstream"
+	customValid, customInvalid := "bug/ok", "bug/not-ok"
+	var testCases = []struct {
+		name                 string
+		labels               []string
+		missing              bool
+		merged               bool
+		externalBugs         []bugzilla.ExternalBug
+		prs                  []github.PullRequest
+		bugs                 []bugzilla.Bug
+		bugErrors            []int
+		remoteBugs           []bugzilla.Bug
+		remoteBugErrors      []int
+		transientBugErrors   map[int]int
+		privateBugs          []int
+		changes              []github.PullRequestChange
+		assign               bool
+		emailLogins          map[string][]string
+		emailErr             error
+		orgMembers           map[string][]string
+		options              plugins.BugzillaBranchOptions
+		expectedLabels       []string
+		expectedComment      string
+		expectedBug          *bugzilla.Bug
+		expectedExternalBugs []bugzilla.ExternalBug
+		expectedBugComments  []string
+	}{
+		{
+			name: "no bug found leaves a comment",
+			expectedComment: `org/repo#1:@user: No Bugzilla bug with ID 123 exists in the tracker at www.bugzilla.
+Once a valid bug is referenced in the title of this pull request, request a bug refresh with <code>/bugzilla refresh</code>.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
 		},
@@ -621,6 +989,22 @@ In response to [this](http.com):
 >Bug 123: fixed it!
 
 
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:        "private bug leaves a comment asking for access",
+			privateBugs: []int{123},
+			expectedComment: `org/repo#1:@user: Bugzilla bug 123 could not be accessed in the tracker at www.bugzilla. The bug may be private.
+Please contact an administrator to grant the bot access to the bug, then request a bug refresh with <code>/bugzilla refresh</code>.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
 		},
@@ -630,7 +1014,9 @@ Instructions for interacting with me using PR comments are available [here](http
 			options:        plugins.BugzillaBranchOptions{}, // no requirements --> always valid
 			labels:         []string{"bugzilla/invalid-bug"},
 			expectedLabels: []string{"bugzilla/valid-bug"},
-			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+			expectedComment: `org/repo#1:@user: Status changed from invalid to valid.
+
+This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
 
 <details><summary>No validations were run on this bug</summary></details>
 
@@ -645,15 +1031,13 @@ Instructions for interacting with me using PR comments are available [here](http
 </details>`,
 		},
 		{
-			name:           "invalid bug adds invalid label, removes valid label and comments",
-			bugs:           []bugzilla.Bug{{ID: 123}},
-			options:        plugins.BugzillaBranchOptions{IsOpen: &open},
-			labels:         []string{"bugzilla/valid-bug"},
-			expectedLabels: []string{"bugzilla/invalid-bug"},
-			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is invalid:
- - expected the bug to be open, but it isn't
+			name:           "valid bug with a summary quotes the summary in the comment",
+			bugs:           []bugzilla.Bug{{ID: 123, Summary: "pods cannot reach the service"}},
+			options:        plugins.BugzillaBranchOptions{}, // no requirements --> always valid
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123): "pods cannot reach the service", which is valid.
 
-Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.
+<details><summary>No validations were run on this bug</summary></details>
 
 <details>
 
@@ -666,11 +1050,14 @@ Instructions for interacting with me using PR comments are available [here](http
 </details>`,
 		},
 		{
-			name:    "no bug removes all labels and comments",
-			missing: true,
-			labels:  []string{"bugzilla/valid-bug", "bugzilla/invalid-bug"},
-			expectedComment: `org/repo#1:@user: No Bugzilla bug is referenced in the title of this pull request.
-To reference a bug, add 'Bug XXX:' to the title of this pull request and request another bug refresh with <code>/bugzilla refresh</code>.
+			name:           "invalid bug with a summary quotes the summary in the comment",
+			bugs:           []bugzilla.Bug{{ID: 123, Summary: "pods cannot reach the service"}},
+			options:        plugins.BugzillaBranchOptions{IsOpen: &open},
+			expectedLabels: []string{"bugzilla/invalid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123): "pods cannot reach the service", which is invalid:
+ - expected the bug to be open, but it isn't
+
+Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.
 
 <details>
 
@@ -683,12 +1070,14 @@ Instructions for interacting with me using PR comments are available [here](http
 </details>`,
 		},
 		{
-			name:           "valid bug with status update removes invalid label, adds valid label, comments and updates status",
+			name:           "valid bug with custom labels removes configured invalid label and adds configured valid label",
 			bugs:           []bugzilla.Bug{{ID: 123}},
-			options:        plugins.BugzillaBranchOptions{StateAfterValidation: &updated}, // no requirements --> always valid
-			labels:         []string{"bugzilla/invalid-bug"},
-			expectedLabels: []string{"bugzilla/valid-bug"},
-			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid. The bug has been moved to the UPDATED state.
+			options:        plugins.BugzillaBranchOptions{ValidLabel: &customValid, InvalidLabel: &customInvalid},
+			labels:         []string{"bug/not-ok", "bugzilla/invalid-bug"},
+			expectedLabels: []string{"bug/ok", "bugzilla/invalid-bug"},
+			expectedComment: `org/repo#1:@user: Status changed from invalid to valid.
+
+This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
 
 <details><summary>No validations were run on this bug</summary></details>
 
@@ -701,17 +1090,19 @@ In response to [this](http.com):
 
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
-			expectedBug: &bugzilla.Bug{ID: 123, Status: "UPDATED"},
 		},
 		{
-			name:           "valid bug with status update removes invalid label, adds valid label, comments and updates status with resolution",
-			bugs:           []bugzilla.Bug{{ID: 123, Status: "MODIFIED"}},
-			options:        plugins.BugzillaBranchOptions{StateAfterValidation: &plugins.BugzillaBugState{Status: "CLOSED", Resolution: "VALIDATED"}}, // no requirements --> always valid
-			labels:         []string{"bugzilla/invalid-bug"},
-			expectedLabels: []string{"bugzilla/valid-bug"},
-			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid. The bug has been moved to the CLOSED (VALIDATED) state.
+			name:           "invalid bug with custom labels removes configured valid label and adds configured invalid label",
+			bugs:           []bugzilla.Bug{{ID: 123, IsOpen: false}},
+			options:        plugins.BugzillaBranchOptions{IsOpen: &open, ValidLabel: &customValid, InvalidLabel: &customInvalid},
+			labels:         []string{"bug/ok"},
+			expectedLabels: []string{"bug/not-ok"},
+			expectedComment: `org/repo#1:@user: Status changed from valid to invalid.
+
+This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is invalid:
+ - expected the bug to be open, but it isn't
 
-<details><summary>No validations were run on this bug</summary></details>
+Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.
 
 <details>
 
@@ -722,15 +1113,13 @@ In response to [this](http.com):
 
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
-			expectedBug: &bugzilla.Bug{ID: 123, Status: "CLOSED", Resolution: "VALIDATED"},
 		},
 		{
-			name:           "valid bug with status update removes invalid label, adds valid label, comments and does not update status when it is already correct",
-			bugs:           []bugzilla.Bug{{ID: 123, Status: "UPDATED"}},
-			options:        plugins.BugzillaBranchOptions{StateAfterValidation: &updated}, // no requirements --> always valid
-			labels:         []string{"bugzilla/invalid-bug"},
+			name:           "valid bug uses the configured comment template",
+			bugs:           []bugzilla.Bug{{ID: 123, Summary: "fix all the things"}},
+			options:        plugins.BugzillaBranchOptions{ValidCommentTemplate: &validTemplate},
 			expectedLabels: []string{"bugzilla/valid-bug"},
-			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+			expectedComment: `org/repo#1:@user: [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) (fix all the things) is good to go, see www.bugzilla for details.
 
 <details><summary>No validations were run on this bug</summary></details>
 
@@ -743,17 +1132,15 @@ In response to [this](http.com):
 
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
-			expectedBug: &bugzilla.Bug{ID: 123, Status: "UPDATED"},
 		},
 		{
-			name:           "valid bug with external link removes invalid label, adds valid label, comments, makes an external bug link",
+			name:           "invalid bug uses the configured comment template",
 			bugs:           []bugzilla.Bug{{ID: 123}},
-			options:        plugins.BugzillaBranchOptions{AddExternalLink: &yes}, // no requirements --> always valid
-			labels:         []string{"bugzilla/invalid-bug"},
-			expectedLabels: []string{"bugzilla/valid-bug"},
-			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid. The bug has been updated to refer to the pull request using the external bug tracker.
-
-<details><summary>No validations were run on this bug</summary></details>
+			options:        plugins.BugzillaBranchOptions{IsOpen: &open, InvalidCommentTemplate: &invalidTemplate},
+			expectedLabels: []string{"bugzilla/invalid-bug"},
+			expectedComment: `org/repo#1:@user: [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) needs work:
+* expected the bug to be open, but it isn't
+Ask in #team-channel if you need help.
 
 <details>
 
@@ -764,18 +1151,11 @@ In response to [this](http.com):
 
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
-			expectedBug:          &bugzilla.Bug{ID: 123},
-			expectedExternalBugs: []bugzilla.ExternalBug{{BugzillaBugID: 123, ExternalBugID: "org/repo/pull/1"}},
 		},
 		{
-			name: "valid bug with already existing external link removes invalid label, adds valid label, comments to say nothing changed",
-			bugs: []bugzilla.Bug{{ID: 123}},
-			externalBugs: []bugzilla.ExternalBug{{
-				BugzillaBugID: base.bugId,
-				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
-			}},
-			options:        plugins.BugzillaBranchOptions{AddExternalLink: &yes}, // no requirements --> always valid
-			labels:         []string{"bugzilla/invalid-bug"},
+			name:           "comment template that fails to render falls back to the default",
+			bugs:           []bugzilla.Bug{{ID: 123}},
+			options:        plugins.BugzillaBranchOptions{ValidCommentTemplate: &brokenTemplate},
 			expectedLabels: []string{"bugzilla/valid-bug"},
 			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
 
@@ -790,17 +1170,19 @@ In response to [this](http.com):
 
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
-			expectedBug:          &bugzilla.Bug{ID: 123},
-			expectedExternalBugs: []bugzilla.ExternalBug{{BugzillaBugID: 123, ExternalBugID: "org/repo/pull/1"}},
 		},
 		{
-			name:      "failure to fetch dependent bug results in a comment",
-			bugs:      []bugzilla.Bug{{ID: 123, DependsOn: []int{124}}},
-			bugErrors: []int{124},
-			options:   plugins.BugzillaBranchOptions{DependentBugStates: &verified},
-			expectedComment: `org/repo#1:@user: An error was encountered searching for dependent bug 124 for bug 123 on the Bugzilla server at www.bugzilla:
-> injected error getting bug
-Please contact an administrator to resolve this issue, then request a bug refresh with <code>/bugzilla refresh</code>.
+			name:           "bug assigned to an active organization member is valid",
+			bugs:           []bugzilla.Bug{{ID: 123, AssignedTo: "dev@example.com"}},
+			emailLogins:    map[string][]string{"dev@example.com": {"dev"}},
+			orgMembers:     map[string][]string{"org": {"dev"}},
+			options:        plugins.BugzillaBranchOptions{RequireActiveAssignee: &yes},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>1 validation(s) were run on this bug</summary>
+
+* bug assignee (dev@example.com) is an active member of the org organization as dev</details>
 
 <details>
 
@@ -813,20 +1195,16 @@ Instructions for interacting with me using PR comments are available [here](http
 </details>`,
 		},
 		{
-			name:           "valid bug with dependent bugs removes invalid label, adds valid label, comments",
-			bugs:           []bugzilla.Bug{{IsOpen: true, ID: 123, DependsOn: []int{124}, TargetRelease: []string{v1}}, {ID: 124, Status: "VERIFIED", TargetRelease: []string{v2}}},
-			options:        plugins.BugzillaBranchOptions{IsOpen: &yes, TargetRelease: &v1, DependentBugStates: &verified, DependentBugTargetRelease: &v2},
-			labels:         []string{"bugzilla/invalid-bug"},
-			expectedLabels: []string{"bugzilla/valid-bug"},
-			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
-
-<details><summary>5 validation(s) were run on this bug</summary>
+			name:           "bug assigned to someone outside the organization is invalid",
+			bugs:           []bugzilla.Bug{{ID: 123, AssignedTo: "departed@example.com"}},
+			emailLogins:    map[string][]string{"departed@example.com": {"departed"}},
+			orgMembers:     map[string][]string{"org": {"dev"}},
+			options:        plugins.BugzillaBranchOptions{RequireActiveAssignee: &yes},
+			expectedLabels: []string{"bugzilla/invalid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is invalid:
+ - expected the bug assignee (departed@example.com) to be an active member of the org organization, but departed is not; reassign the bug in Bugzilla
 
-* bug is open, matching expected state (open)
-* bug target release (v1) matches configured target release for branch (v1)
-* dependent bug [Bugzilla bug 124](www.bugzilla/show_bug.cgi?id=124) is in the state VERIFIED, which is one of the valid states (VERIFIED)
-* dependent [Bugzilla bug 124](www.bugzilla/show_bug.cgi?id=124) targets the "v2" release, matching the expected (v2) release
-* bug has dependents</details>
+Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.
 
 <details>
 
@@ -839,222 +1217,2084 @@ Instructions for interacting with me using PR comments are available [here](http
 </details>`,
 		},
 		{
-			name:   "valid bug on merged PR with one external link migrates to new state with resolution and comments",
-			merged: true,
-			bugs:   []bugzilla.Bug{{ID: 123, Status: "MODIFIED"}},
+			name:           "bug assignee that maps to no GitHub user is not checked",
+			bugs:           []bugzilla.Bug{{ID: 123, AssignedTo: "unknown@example.com"}},
+			orgMembers:     map[string][]string{"org": {"dev"}},
+			options:        plugins.BugzillaBranchOptions{RequireActiveAssignee: &yes},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "bug assigned to the pull request author is valid",
+			bugs:           []bugzilla.Bug{{ID: 123, AssignedTo: "dev@example.com"}},
+			prs:            []github.PullRequest{{Number: 1, User: github.User{Login: "User"}}},
+			emailLogins:    map[string][]string{"dev@example.com": {"user"}},
+			options:        plugins.BugzillaBranchOptions{RequireAuthorIsAssignee: &yes},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>1 validation(s) were run on this bug</summary>
+
+* bug assignee (dev@example.com) is the author of the pull request (@User)</details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "bug assigned to someone other than the pull request author is invalid",
+			bugs:           []bugzilla.Bug{{ID: 123, AssignedTo: "dev@example.com"}},
+			prs:            []github.PullRequest{{Number: 1, User: github.User{Login: "user"}}},
+			emailLogins:    map[string][]string{"dev@example.com": {"dev"}},
+			options:        plugins.BugzillaBranchOptions{RequireAuthorIsAssignee: &yes},
+			expectedLabels: []string{"bugzilla/invalid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is invalid:
+ - expected the bug assignee (dev@example.com) to be the author of the pull request (@user), but that email belongs to the GitHub user(s) dev instead; reassign the bug in Bugzilla or have the assignee open the pull request
+
+Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "bug assignee that maps to no GitHub user is not the pull request author",
+			bugs:           []bugzilla.Bug{{ID: 123, AssignedTo: "unknown@example.com"}},
+			prs:            []github.PullRequest{{Number: 1, User: github.User{Login: "user"}}},
+			options:        plugins.BugzillaBranchOptions{RequireAuthorIsAssignee: &yes},
+			expectedLabels: []string{"bugzilla/invalid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is invalid:
+ - expected the bug assignee (unknown@example.com) to be the author of the pull request (@user), but no GitHub user lists that email publicly; if @user is the assignee, add the email to their public GitHub profile
+
+Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "unassigned bug is not assigned to the pull request author",
+			bugs:           []bugzilla.Bug{{ID: 123}},
+			prs:            []github.PullRequest{{Number: 1, User: github.User{Login: "user"}}},
+			options:        plugins.BugzillaBranchOptions{RequireAuthorIsAssignee: &yes},
+			expectedLabels: []string{"bugzilla/invalid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is invalid:
+ - expected the bug to be assigned to the author of the pull request (@user), but it is not assigned to anyone
+
+Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "valid bug with existing valid label notes that status is unchanged",
+			bugs:           []bugzilla.Bug{{ID: 123}},
+			options:        plugins.BugzillaBranchOptions{}, // no requirements --> always valid
+			labels:         []string{"bugzilla/valid-bug"},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: Status unchanged.
+
+This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "invalid bug adds invalid label, removes valid label and comments",
+			bugs:           []bugzilla.Bug{{ID: 123}},
+			options:        plugins.BugzillaBranchOptions{IsOpen: &open},
+			labels:         []string{"bugzilla/valid-bug"},
+			expectedLabels: []string{"bugzilla/invalid-bug"},
+			expectedComment: `org/repo#1:@user: Status changed from valid to invalid.
+
+This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is invalid:
+ - expected the bug to be open, but it isn't
+
+Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:    "no bug removes all labels and comments",
+			missing: true,
+			labels:  []string{"bugzilla/valid-bug", "bugzilla/invalid-bug"},
+			expectedComment: `org/repo#1:@user: No Bugzilla bug is referenced in the title of this pull request.
+To reference a bug, add 'Bug XXX:' to the title of this pull request and request another bug refresh with <code>/bugzilla refresh</code>.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "no bug on a pull request within the grace period neither labels nor comments",
+			missing:        true,
+			labels:         []string{"bugzilla/invalid-bug"},
+			prs:            []github.PullRequest{{Number: base.number, CreatedAt: time.Now()}},
+			options:        plugins.BugzillaBranchOptions{GracePeriodMinutes: &gracePeriod},
+			expectedLabels: []string{"bugzilla/invalid-bug"},
+		},
+		{
+			name:    "no bug on a pull request older than the grace period removes all labels and comments",
+			missing: true,
+			labels:  []string{"bugzilla/valid-bug", "bugzilla/invalid-bug"},
+			prs:     []github.PullRequest{{Number: base.number, CreatedAt: time.Now().Add(-time.Hour)}},
+			options: plugins.BugzillaBranchOptions{GracePeriodMinutes: &gracePeriod},
+			expectedComment: `org/repo#1:@user: No Bugzilla bug is referenced in the title of this pull request.
+To reference a bug, add 'Bug XXX:' to the title of this pull request and request another bug refresh with <code>/bugzilla refresh</code>.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "valid bug with status update removes invalid label, adds valid label, comments and updates status",
+			bugs:           []bugzilla.Bug{{ID: 123}},
+			options:        plugins.BugzillaBranchOptions{StateAfterValidation: &updated}, // no requirements --> always valid
+			labels:         []string{"bugzilla/invalid-bug"},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: Status changed from invalid to valid.
+
+This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid. The bug has been moved to the UPDATED state.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug: &bugzilla.Bug{ID: 123, Status: "UPDATED"},
+		},
+		{
+			name:           "valid bug with status update removes invalid label, adds valid label, comments and updates status with resolution",
+			bugs:           []bugzilla.Bug{{ID: 123, Status: "MODIFIED"}},
+			options:        plugins.BugzillaBranchOptions{StateAfterValidation: &plugins.BugzillaBugState{Status: "CLOSED", Resolution: "VALIDATED"}}, // no requirements --> always valid
+			labels:         []string{"bugzilla/invalid-bug"},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: Status changed from invalid to valid.
+
+This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid. The bug has been moved to the CLOSED (VALIDATED) state.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug: &bugzilla.Bug{ID: 123, Status: "CLOSED", Resolution: "VALIDATED"},
+		},
+		{
+			name:           "valid bug with status update removes invalid label, adds valid label, comments and does not update status when it is already correct",
+			bugs:           []bugzilla.Bug{{ID: 123, Status: "UPDATED"}},
+			options:        plugins.BugzillaBranchOptions{StateAfterValidation: &updated}, // no requirements --> always valid
+			labels:         []string{"bugzilla/invalid-bug"},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: Status changed from invalid to valid.
+
+This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug: &bugzilla.Bug{ID: 123, Status: "UPDATED"},
+		},
+		{
+			name:           "valid bug with external link removes invalid label, adds valid label, comments, makes an external bug link",
+			bugs:           []bugzilla.Bug{{ID: 123}},
+			options:        plugins.BugzillaBranchOptions{AddExternalLink: &yes}, // no requirements --> always valid
+			labels:         []string{"bugzilla/invalid-bug"},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: Status changed from invalid to valid.
+
+This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid. The bug has been updated to refer to the pull request using the external bug tracker.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug:          &bugzilla.Bug{ID: 123},
+			expectedExternalBugs: []bugzilla.ExternalBug{{BugzillaBugID: 123, ExternalBugID: "org/repo/pull/1"}},
+		},
+		{
+			name: "valid bug with already existing external link removes invalid label, adds valid label, comments to say nothing changed",
+			bugs: []bugzilla.Bug{{ID: 123}},
 			externalBugs: []bugzilla.ExternalBug{{
 				BugzillaBugID: base.bugId,
 				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
-				Org:           base.org, Repo: base.repo, Num: base.number,
 			}},
-			prs:     []github.PullRequest{{Number: base.number, Merged: true}},
-			options: plugins.BugzillaBranchOptions{StateAfterMerge: &plugins.BugzillaBugState{Status: "CLOSED", Resolution: "MERGED"}}, // no requirements --> always valid
-			expectedComment: `org/repo#1:@user: All pull requests linked via external trackers have merged: [org/repo#1](https://github.com/org/repo/pull/1). [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been moved to the CLOSED (MERGED) state.
+			options:        plugins.BugzillaBranchOptions{AddExternalLink: &yes}, // no requirements --> always valid
+			labels:         []string{"bugzilla/invalid-bug"},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: Status changed from invalid to valid.
+
+This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug:          &bugzilla.Bug{ID: 123},
+			expectedExternalBugs: []bugzilla.ExternalBug{{BugzillaBugID: 123, ExternalBugID: "org/repo/pull/1"}},
+		},
+		{
+			name:           "valid unassigned bug with warning enabled adds a warning to the comment",
+			bugs:           []bugzilla.Bug{{ID: 123}},
+			options:        plugins.BugzillaBranchOptions{WarnOnUnassignedBug: &yes},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+**Warning:** [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) is not assigned to anyone. Please make sure that an assignee is set in Bugzilla so that the bug is triaged.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "valid bug assigned to the default user with warning enabled adds a warning to the comment",
+			bugs:           []bugzilla.Bug{{ID: 123, AssignedTo: "nobody@example.com"}},
+			options:        plugins.BugzillaBranchOptions{WarnOnUnassignedBug: &yes},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+**Warning:** [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) is not assigned to anyone. Please make sure that an assignee is set in Bugzilla so that the bug is triaged.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "valid assigned bug with warning enabled does not add a warning to the comment",
+			bugs:           []bugzilla.Bug{{ID: 123, AssignedTo: "engineer@example.com"}},
+			options:        plugins.BugzillaBranchOptions{WarnOnUnassignedBug: &yes},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "hard-blocked bug adds invalid label and comments that an override is required",
+			bugs:           []bugzilla.Bug{{ID: 123, Status: "CLOSED", Resolution: "WONTFIX"}},
+			options:        plugins.BugzillaBranchOptions{HardBlockStatuses: &[]plugins.BugzillaBugState{{Status: "CLOSED", Resolution: "WONTFIX"}}},
+			labels:         []string{"bugzilla/valid-bug"},
+			expectedLabels: []string{"bugzilla/invalid-bug"},
+			expectedComment: `org/repo#1:@user: Status changed from valid to invalid.
+
+This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is invalid:
+ - the bug is in the CLOSED (WONTFIX) state, which is never automatically validated; a manual override is required to merge this pull request
+
+Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug: &bugzilla.Bug{ID: 123, Status: "CLOSED", Resolution: "WONTFIX"},
+		},
+		{
+			name:     "timing out resolving the QA contact comments that the command should be tried again",
+			bugs:     []bugzilla.Bug{{ID: 123, QAContactDetail: &bugzilla.User{Email: "qa_tester@example.com"}}},
+			assign:   true,
+			emailErr: context.DeadlineExceeded,
+			expectedComment: `org/repo#1:@user: Timed out resolving the QA contact of [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) to a GitHub user. Please try again with <code>/bugzilla assign-qa</code>.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "valid bug with assign-qa assigns the QA contact resolved from their email",
+			bugs:           []bugzilla.Bug{{ID: 123, QAContactDetail: &bugzilla.User{Email: "qa_tester@example.com"}}},
+			assign:         true,
+			emailLogins:    map[string][]string{"qa_tester@example.com": {"qa-tester"}},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+Assigning the QA contact for review:
+/assign @qa-tester
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "valid bug without a QA contact with assign-qa falls back to assigning the reporter",
+			bugs:           []bugzilla.Bug{{ID: 123, Creator: "reporter@example.com", CreatorDetail: &bugzilla.User{Email: "reporter@example.com"}}},
+			assign:         true,
+			options:        plugins.BugzillaBranchOptions{AssignReporterIfNoQA: &yes},
+			emailLogins:    map[string][]string{"reporter@example.com": {"reporter"}},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+[Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) does not have a QA contact, falling back to the bug reporter.
+Assigning the bug reporter for review:
+/assign @reporter
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "valid bug without a QA contact or reporter email with assign-qa skips the fallback",
+			bugs:           []bugzilla.Bug{{ID: 123}},
+			assign:         true,
+			options:        plugins.BugzillaBranchOptions{AssignReporterIfNoQA: &yes},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+[Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) does not have a QA contact, falling back to the bug reporter. The bug reporter does not have a listed email, skipping assignment.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:     "failing to resolve the reporter of a valid bug without a QA contact comments the error",
+			bugs:     []bugzilla.Bug{{ID: 123, Creator: "reporter@example.com", CreatorDetail: &bugzilla.User{Email: "reporter@example.com"}}},
+			assign:   true,
+			options:  plugins.BugzillaBranchOptions{AssignReporterIfNoQA: &yes},
+			emailErr: errors.New("injected error"),
+			expectedComment: `org/repo#1:@user: An error was encountered querying GitHub for users with public email (reporter@example.com) for bug 123 on the Bugzilla server at www.bugzilla:
+> injected error
+Please contact an administrator to resolve this issue, then request a bug refresh with <code>/bugzilla refresh</code>.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "valid bug with a QA contact with assign-qa does not fall back to the reporter",
+			bugs:           []bugzilla.Bug{{ID: 123, Creator: "reporter@example.com", QAContactDetail: &bugzilla.User{Email: "qa_tester@example.com"}}},
+			assign:         true,
+			options:        plugins.BugzillaBranchOptions{AssignReporterIfNoQA: &yes},
+			emailLogins:    map[string][]string{"qa_tester@example.com": {"qa-tester"}, "reporter@example.com": {"reporter"}},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+Assigning the QA contact for review:
+/assign @qa-tester
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "bug filed against the component of the changed files is valid",
+			bugs:           []bugzilla.Bug{{ID: 123, Component: []string{"Networking"}}},
+			changes:        []github.PullRequestChange{{Filename: "pkg/network/service.go"}, {Filename: "README.md"}},
+			options:        plugins.BugzillaBranchOptions{ValidateComponentFromChangedFiles: componentsByPath},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>1 validation(s) were run on this bug</summary>
+
+* bug component (Networking) matches one of the components for the files changed in this pull request (Networking)</details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "bug filed against a different component than the changed files is invalid",
+			bugs:           []bugzilla.Bug{{ID: 123, Component: []string{"Networking"}}},
+			changes:        []github.PullRequestChange{{Filename: "pkg/storage/volume.go"}},
+			options:        plugins.BugzillaBranchOptions{ValidateComponentFromChangedFiles: componentsByPath},
+			expectedLabels: []string{"bugzilla/invalid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is invalid:
+ - expected the bug to be filed against one of the components for the files changed in this pull request (Storage), but it is filed against Networking instead
+
+Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:               "transient errors fetching bug are retried",
+			bugs:               []bugzilla.Bug{{ID: 123}},
+			transientBugErrors: map[int]int{123: 2},
+			options:            plugins.BugzillaBranchOptions{BugRetries: &two},
+			expectedLabels:     []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>No validations were run on this bug</summary></details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:               "transient errors fetching bug that outlast retries leave a comment",
+			bugs:               []bugzilla.Bug{{ID: 123}},
+			transientBugErrors: map[int]int{123: 2},
+			options:            plugins.BugzillaBranchOptions{BugRetries: &one},
+			expectedComment: `org/repo#1:@user: An error was encountered searching for bug 123 on the Bugzilla server at www.bugzilla:
+> injected rate limit error getting bug
+Please contact an administrator to resolve this issue, then request a bug refresh with <code>/bugzilla refresh</code>.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "dependent bugs are fetched from the bugs the bug blocks when configured",
+			bugs:           []bugzilla.Bug{{ID: 123, DependsOn: []int{124}, Blocks: []int{125}}, {ID: 124, Status: "NEW"}, {ID: 125, Status: "VERIFIED"}},
+			options:        plugins.BugzillaBranchOptions{DependentBugStates: &verified, DependentDirection: &blocks},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>2 validation(s) were run on this bug</summary>
+
+* dependent bug [Bugzilla bug 125](www.bugzilla/show_bug.cgi?id=125) is in the state VERIFIED, which is one of the valid states (VERIFIED)
+* bug has dependents</details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "dependent bugs the bug blocks are invalid with the usual reasons",
+			bugs:           []bugzilla.Bug{{ID: 123, DependsOn: []int{124}, Blocks: []int{125}}, {ID: 124, Status: "VERIFIED"}, {ID: 125, Status: "NEW"}},
+			options:        plugins.BugzillaBranchOptions{DependentBugStates: &verified, DependentDirection: &blocks},
+			expectedLabels: []string{"bugzilla/invalid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is invalid:
+ - expected dependent [Bugzilla bug 125](www.bugzilla/show_bug.cgi?id=125) to be in one of the following states: VERIFIED, but it is NEW instead
+
+Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:               "transient errors fetching dependent bug are retried",
+			bugs:               []bugzilla.Bug{{ID: 123, DependsOn: []int{124}}, {ID: 124, Status: "VERIFIED"}},
+			transientBugErrors: map[int]int{124: 1},
+			options:            plugins.BugzillaBranchOptions{DependentBugStates: &verified, BugRetries: &one},
+			expectedLabels:     []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>2 validation(s) were run on this bug</summary>
+
+* dependent bug [Bugzilla bug 124](www.bugzilla/show_bug.cgi?id=124) is in the state VERIFIED, which is one of the valid states (VERIFIED)
+* bug has dependents</details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "bug with multiple target releases warns that only the first was considered",
+			bugs:           []bugzilla.Bug{{ID: 123, TargetRelease: []string{"v1", "v2"}}},
+			options:        plugins.BugzillaBranchOptions{TargetRelease: &v1},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>1 validation(s) were run on this bug</summary>
+
+* bug target release (v1) matches configured target release for branch (v1)
+
+**Warning:** the bug has multiple target releases (v1, v2); only the first (v1) was considered.</details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:      "failure to fetch dependent bug results in a comment",
+			bugs:      []bugzilla.Bug{{ID: 123, DependsOn: []int{124}}},
+			bugErrors: []int{124},
+			options:   plugins.BugzillaBranchOptions{DependentBugStates: &verified},
+			expectedComment: `org/repo#1:@user: An error was encountered searching for dependent bug 124 for bug 123 on the Bugzilla server at www.bugzilla:
+> injected error getting bug
+Please contact an administrator to resolve this issue, then request a bug refresh with <code>/bugzilla refresh</code>.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "valid bug with dependent bugs on a separate server fetches them from that server",
+			bugs:           []bugzilla.Bug{{ID: 123, DependsOn: []int{124}}, {ID: 124, Status: "NEW"}},
+			remoteBugs:     []bugzilla.Bug{{ID: 124, Status: "VERIFIED"}},
+			options:        plugins.BugzillaBranchOptions{DependentBugStates: &verified, DependentBugEndpoint: &upstream},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>2 validation(s) were run on this bug</summary>
+
+* dependent bug [Bugzilla bug 124](www.upstream/show_bug.cgi?id=124) is in the state VERIFIED, which is one of the valid states (VERIFIED)
+* bug has dependents</details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:            "error fetching dependent bug from a separate server leaves a comment",
+			bugs:            []bugzilla.Bug{{ID: 123, DependsOn: []int{124}}},
+			remoteBugErrors: []int{124},
+			options:         plugins.BugzillaBranchOptions{DependentBugStates: &verified, DependentBugEndpoint: &upstream},
+			expectedComment: `org/repo#1:@user: An error was encountered searching for dependent bug 124 on the separate Bugzilla server at www.upstream for bug 123 on the Bugzilla server at www.bugzilla:
+> injected error getting bug
+Please contact an administrator to resolve this issue, then request a bug refresh with <code>/bugzilla refresh</code>.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:           "valid bug with dependent bugs removes invalid label, adds valid label, comments",
+			bugs:           []bugzilla.Bug{{IsOpen: true, ID: 123, DependsOn: []int{124}, TargetRelease: []string{v1}}, {ID: 124, Status: "VERIFIED", TargetRelease: []string{v2}}},
+			options:        plugins.BugzillaBranchOptions{IsOpen: &yes, TargetRelease: &v1, DependentBugStates: &verified, DependentBugTargetRelease: &v2},
+			labels:         []string{"bugzilla/invalid-bug"},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: Status changed from invalid to valid.
+
+This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.
+
+<details><summary>5 validation(s) were run on this bug</summary>
+
+* bug is open, matching expected state (open)
+* bug target release (v1) matches configured target release for branch (v1)
+* dependent bug [Bugzilla bug 124](www.bugzilla/show_bug.cgi?id=124) is in the state VERIFIED, which is one of the valid states (VERIFIED)
+* dependent [Bugzilla bug 124](www.bugzilla/show_bug.cgi?id=124) targets the "v2" release, matching the expected (v2) release
+* bug has dependents</details>
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:   "valid bug on merged PR with one external link migrates to new state with resolution and comments",
+			merged: true,
+			bugs:   []bugzilla.Bug{{ID: 123, Status: "MODIFIED"}},
+			externalBugs: []bugzilla.ExternalBug{{
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
+				Org:           base.org, Repo: base.repo, Num: base.number,
+			}},
+			prs:     []github.PullRequest{{Number: base.number, Merged: true}},
+			options: plugins.BugzillaBranchOptions{StateAfterMerge: &plugins.BugzillaBugState{Status: "CLOSED", Resolution: "MERGED"}}, // no requirements --> always valid
+			expectedComment: `org/repo#1:@user: All pull requests linked via external trackers have merged: [org/repo#1](https://github.com/org/repo/pull/1). [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been moved to the CLOSED (MERGED) state.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug: &bugzilla.Bug{ID: 123, Status: "CLOSED", Resolution: "MERGED"},
+		},
+		{
+			name:   "valid bug on merged PR with one external link already in the new state is not updated and comments",
+			merged: true,
+			bugs:   []bugzilla.Bug{{ID: 123, Status: "MODIFIED"}},
+			externalBugs: []bugzilla.ExternalBug{{
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
+				Org:           base.org, Repo: base.repo, Num: base.number,
+			}},
+			prs:     []github.PullRequest{{Number: base.number, Merged: true}},
+			options: plugins.BugzillaBranchOptions{StateAfterMerge: &modified}, // no requirements --> always valid
+			expectedComment: `org/repo#1:@user: All pull requests linked via external trackers have merged: [org/repo#1](https://github.com/org/repo/pull/1). [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) is already in the MODIFIED state; no update needed.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug: &bugzilla.Bug{ID: 123, Status: "MODIFIED"},
+		},
+		{
+			name:   "valid bug on merged PR with one external link migrates to new state and comments",
+			merged: true,
+			bugs:   []bugzilla.Bug{{ID: 123}},
+			externalBugs: []bugzilla.ExternalBug{{
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
+				Org:           base.org, Repo: base.repo, Num: base.number,
+			}},
+			prs:     []github.PullRequest{{Number: base.number, Merged: true}},
+			options: plugins.BugzillaBranchOptions{StateAfterMerge: &modified}, // no requirements --> always valid
+			expectedComment: `org/repo#1:@user: All pull requests linked via external trackers have merged: [org/repo#1](https://github.com/org/repo/pull/1). [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been moved to the MODIFIED state.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug: &bugzilla.Bug{ID: 123, Status: "MODIFIED"},
+		},
+		{
+			name:   "valid bug on merged PR migrates to new state and comments on the bug when configured",
+			merged: true,
+			bugs:   []bugzilla.Bug{{ID: 123}},
+			externalBugs: []bugzilla.ExternalBug{{
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
+				Org:           base.org, Repo: base.repo, Num: base.number,
+			}},
+			prs:     []github.PullRequest{{Number: base.number, Merged: true}},
+			options: plugins.BugzillaBranchOptions{StateAfterMerge: &modified, AddCommentOnMerge: &yesComment}, // no requirements --> always valid
+			expectedComment: `org/repo#1:@user: All pull requests linked via external trackers have merged: [org/repo#1](https://github.com/org/repo/pull/1). [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been moved to the MODIFIED state.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug:         &bugzilla.Bug{ID: 123, Status: "MODIFIED"},
+			expectedBugComments: []string{"Pull request org/repo#1 has merged, moving this bug to the MODIFIED state."},
+		},
+		{
+			name:   "valid bug on merged PR with many external links migrates to new state and comments",
+			merged: true,
+			bugs:   []bugzilla.Bug{{ID: 123}},
+			externalBugs: []bugzilla.ExternalBug{{
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
+				Org:           base.org, Repo: base.repo, Num: base.number,
+			}, {
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/22", base.org, base.repo),
+				Org:           base.org, Repo: base.repo, Num: 22,
+			}},
+			prs:     []github.PullRequest{{Number: base.number, Merged: true}, {Number: 22, Merged: true}},
+			options: plugins.BugzillaBranchOptions{StateAfterMerge: &modified}, // no requirements --> always valid
+			expectedComment: `org/repo#1:@user: All pull requests linked via external trackers have merged: [org/repo#1](https://github.com/org/repo/pull/1), [org/repo#22](https://github.com/org/repo/pull/22). [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been moved to the MODIFIED state.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug: &bugzilla.Bug{ID: 123, Status: "MODIFIED"},
+		},
+		{
+			name:   "valid bug on merged PR with several unmerged external links reports the first",
+			merged: true,
+			bugs:   []bugzilla.Bug{{ID: 123}},
+			externalBugs: []bugzilla.ExternalBug{{
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
+				Org:           base.org, Repo: base.repo, Num: base.number,
+			}, {
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/22", base.org, base.repo),
+				Org:           base.org, Repo: base.repo, Num: 22,
+			}, {
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/23", base.org, base.repo),
+				Org:           base.org, Repo: base.repo, Num: 23,
+			}},
+			prs:         []github.PullRequest{{Number: base.number, Merged: true}, {Number: 22, Merged: false, State: "open"}, {Number: 23, Merged: false, State: "closed"}},
+			options:     plugins.BugzillaBranchOptions{StateAfterMerge: &modified},
+			expectedBug: &bugzilla.Bug{ID: 123},
+			expectedComment: `org/repo#1:@user: Some pull requests linked via external trackers have merged: [org/repo#1](https://github.com/org/repo/pull/1). The following pull requests linked via external trackers have not merged:
+ * [org/repo#22](https://github.com/org/repo/pull/22) is open
+[Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been moved to the MODIFIED state.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:   "valid bug on merged PR with several unmerged external links reports all of them when configured",
+			merged: true,
+			bugs:   []bugzilla.Bug{{ID: 123}},
+			externalBugs: []bugzilla.ExternalBug{{
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
+				Org:           base.org, Repo: base.repo, Num: base.number,
+			}, {
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/22", base.org, base.repo),
+				Org:           base.org, Repo: base.repo, Num: 22,
+			}, {
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/23", base.org, base.repo),
+				Org:           base.org, Repo: base.repo, Num: 23,
+			}},
+			prs:         []github.PullRequest{{Number: base.number, Merged: true}, {Number: 22, Merged: false, State: "open"}, {Number: 23, Merged: false, State: "closed"}},
+			options:     plugins.BugzillaBranchOptions{StateAfterMerge: &modified, ReportAllUnmergedPRs: &yes},
+			expectedBug: &bugzilla.Bug{ID: 123},
+			expectedComment: `org/repo#1:@user: Some pull requests linked via external trackers have merged: [org/repo#1](https://github.com/org/repo/pull/1). The following pull requests linked via external trackers have not merged:
+ * [org/repo#22](https://github.com/org/repo/pull/22) is open
+ * [org/repo#23](https://github.com/org/repo/pull/23) is closed
+[Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been moved to the MODIFIED state.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:   "valid bug on merged PR with unmerged external links does nothing",
+			merged: true,
+			bugs:   []bugzilla.Bug{{ID: 123}},
+			externalBugs: []bugzilla.ExternalBug{{
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
+				Org:           base.org, Repo: base.repo, Num: base.number,
+			}, {
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/22", base.org, base.repo),
+				Org:           base.org, Repo: base.repo, Num: 22,
+			}},
+			prs:         []github.PullRequest{{Number: base.number, Merged: true}, {Number: 22, Merged: false, State: "open"}},
+			options:     plugins.BugzillaBranchOptions{StateAfterMerge: &modified}, // no requirements --> always valid
+			expectedBug: &bugzilla.Bug{ID: 123},
+			expectedComment: `org/repo#1:@user: Some pull requests linked via external trackers have merged: [org/repo#1](https://github.com/org/repo/pull/1). The following pull requests linked via external trackers have not merged:
+ * [org/repo#22](https://github.com/org/repo/pull/22) is open
+[Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been moved to the MODIFIED state.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+		},
+		{
+			name:   "valid bug on merged PR with one external link but no status after merge configured does nothing",
+			merged: true,
+			bugs:   []bugzilla.Bug{{ID: 123}},
+			externalBugs: []bugzilla.ExternalBug{{
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
+				Org:           base.org, Repo: base.repo, Num: base.number,
+			}},
+			prs:         []github.PullRequest{{Number: base.number, Merged: true}},
+			options:     plugins.BugzillaBranchOptions{}, // no requirements --> always valid
+			expectedBug: &bugzilla.Bug{ID: 123},
+		},
+		{
+			name:    "valid bug on merged PR with one external link but no referenced bug in the title does nothing",
+			merged:  true,
+			missing: true,
+			bugs:    []bugzilla.Bug{{ID: 123}},
+			externalBugs: []bugzilla.ExternalBug{{
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
+				Org:           base.org, Repo: base.repo, Num: base.number,
+			}},
+			prs:         []github.PullRequest{{Number: base.number, Merged: true}},
+			options:     plugins.BugzillaBranchOptions{StateAfterMerge: &modified}, // no requirements --> always valid
+			expectedBug: &bugzilla.Bug{ID: 123},
+		},
+		{
+			name:      "valid bug on merged PR with one external link fails to update bug and comments",
+			merged:    true,
+			bugs:      []bugzilla.Bug{{ID: 123}},
+			bugErrors: []int{123},
+			externalBugs: []bugzilla.ExternalBug{{
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
+				Org:           base.org, Repo: base.repo, Num: base.number,
+			}},
+			prs:     []github.PullRequest{{Number: base.number, Merged: true}},
+			options: plugins.BugzillaBranchOptions{StateAfterMerge: &modified}, // no requirements --> always valid
+			expectedComment: `org/repo#1:@user: An error was encountered searching for external tracker bugs for bug 123 on the Bugzilla server at www.bugzilla:
+> injected error adding external bug to bug
+Please contact an administrator to resolve this issue, then request a bug refresh with <code>/bugzilla refresh</code>.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug: &bugzilla.Bug{ID: 123},
+		},
+		{
+			name:   "valid bug on merged PR with merged external links but unknown status does not migrate to new state and comments",
+			merged: true,
+			bugs:   []bugzilla.Bug{{ID: 123, Status: "CLOSED"}},
+			externalBugs: []bugzilla.ExternalBug{{
+				BugzillaBugID: base.bugId,
+				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
+				Org:           base.org, Repo: base.repo, Num: base.number,
+			}},
+			prs:     []github.PullRequest{{Number: base.number, Merged: true}},
+			options: plugins.BugzillaBranchOptions{StateAfterValidation: &updated, StateAfterMerge: &modified}, // no requirements --> always valid
+			expectedComment: `org/repo#1:@user: [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) is in an unrecognized state (CLOSED) and will not be moved to the MODIFIED state.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedBug: &bugzilla.Bug{ID: 123, Status: "CLOSED"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			e := *base // copy so parallel tests don't collide
+			gc := fakegithub.FakeClient{
+				IssueLabelsExisting: []string{},
+				IssueComments:       map[int][]github.IssueComment{},
+				PullRequests:        map[int]*github.PullRequest{},
+				PullRequestChanges:  map[int][]github.PullRequestChange{e.number: testCase.changes},
+				OrgMembers:          testCase.orgMembers,
+			}
+			for _, label := range testCase.labels {
+				gc.IssueLabelsExisting = append(gc.IssueLabelsExisting, fmt.Sprintf("%s/%s#%d:%s", e.org, e.repo, e.number, label))
+			}
+			for _, pr := range testCase.prs {
+				gc.PullRequests[pr.Number] = &pr
+			}
+			bc := bugzilla.Fake{
+				EndpointString:     "www.bugzilla",
+				Bugs:               map[int]bugzilla.Bug{},
+				BugErrors:          sets.NewInt(),
+				ExternalBugs:       map[int][]bugzilla.ExternalBug{},
+				TransientBugErrors: map[int]int{},
+				PrivateBugs:        sets.NewInt(testCase.privateBugs...),
+			}
+			for _, bug := range testCase.bugs {
+				bc.Bugs[bug.ID] = bug
+			}
+			bc.BugErrors.Insert(testCase.bugErrors...)
+			remote := &bugzilla.Fake{
+				EndpointString: "www.upstream",
+				Bugs:           map[int]bugzilla.Bug{},
+				BugErrors:      sets.NewInt(testCase.remoteBugErrors...),
+			}
+			for _, bug := range testCase.remoteBugs {
+				remote.Bugs[bug.ID] = bug
+			}
+			bc.Remotes = map[string]*bugzilla.Fake{"www.upstream": remote}
+			for id, count := range testCase.transientBugErrors {
+				bc.TransientBugErrors[id] = count
+			}
+			for _, externalBug := range testCase.externalBugs {
+				bc.ExternalBugs[externalBug.BugzillaBugID] = append(bc.ExternalBugs[externalBug.BugzillaBugID], externalBug)
+			}
+			e.missing = testCase.missing
+			e.merged = testCase.merged
+			e.assign = testCase.assign
+			ur := fakeUserResolver{logins: testCase.emailLogins, err: testCase.emailErr}
+			err := handle(context.Background(), e, &gc, ur, &bc, testCase.options, logrus.WithField("testCase", testCase.name))
+			if err != nil {
+				t.Errorf("%s: expected no error but got one: %v", testCase.name, err)
+			}
+
+			expected := sets.NewString()
+			for _, label := range testCase.expectedLabels {
+				expected.Insert(fmt.Sprintf("%s/%s#%d:%s", e.org, e.repo, e.number, label))
+			}
+
+			actual := sets.NewString(gc.IssueLabelsExisting...)
+			actual.Insert(gc.IssueLabelsAdded...)
+			actual.Delete(gc.IssueLabelsRemoved...)
+
+			if missing := expected.Difference(actual); missing.Len() > 0 {
+				t.Errorf("%s: missing expected labels: %v", testCase.name, missing.List())
+			}
+			if extra := actual.Difference(expected); extra.Len() > 0 {
+				t.Errorf("%s: unexpected labels: %v", testCase.name, extra.List())
+			}
+
+			checkComments(gc, testCase.name, testCase.expectedComment, t)
+
+			if testCase.expectedBug != nil {
+				if actual, expected := bc.Bugs[testCase.expectedBug.ID], *testCase.expectedBug; !reflect.DeepEqual(actual, expected) {
+					t.Errorf("%s: got incorrect bug after update: %s", testCase.name, diff.ObjectReflectDiff(actual, expected))
+				}
+			}
+			if len(testCase.expectedExternalBugs) > 0 {
+				if actual, expected := bc.ExternalBugs[testCase.expectedBug.ID], testCase.expectedExternalBugs; !reflect.DeepEqual(actual, expected) {
+					t.Errorf("%s: got incorrect external bugs after update: %s", testCase.name, diff.ObjectReflectDiff(actual, expected))
+				}
+			}
+			if actual, expected := bc.BugComments[e.bugId], testCase.expectedBugComments; len(actual) != 0 || len(expected) != 0 {
+				if !reflect.DeepEqual(actual, expected) {
+					t.Errorf("%s: got incorrect comments on the bug: %s", testCase.name, diff.ObjectReflectDiff(actual, expected))
+				}
+			}
+		})
+	}
+}
+
+func TestHandleStatus(t *testing.T) {
+	one, two := "v1", "v2"
+	statusContext := "bugzilla/valid"
+	var testCases = []struct {
+		name     string
+		missing  bool
+		bugs     []bugzilla.Bug
+		options  plugins.BugzillaBranchOptions
+		expected []github.Status
+	}{
+		{
+			name:    "no status is set without a status context",
+			bugs:    []bugzilla.Bug{{ID: 123}},
+			options: plugins.BugzillaBranchOptions{},
+		},
+		{
+			name:     "valid bug sets a successful status",
+			bugs:     []bugzilla.Bug{{ID: 123, TargetRelease: []string{"v1"}}},
+			options:  plugins.BugzillaBranchOptions{TargetRelease: &one, StatusContext: &statusContext},
+			expected: []github.Status{{State: github.StatusSuccess, Context: statusContext, Description: "Bug 123 is valid."}},
+		},
+		{
+			name:     "invalid bug sets a failed status with the reason",
+			bugs:     []bugzilla.Bug{{ID: 123, TargetRelease: []string{"v1"}}},
+			options:  plugins.BugzillaBranchOptions{TargetRelease: &two, StatusContext: &statusContext},
+			expected: []github.Status{{State: github.StatusFailure, Context: statusContext, Description: `Bug 123 is invalid: expected the bug to target the "v2" release, but it targets "v1" instead`}},
+		},
+		{
+			name:     "missing bug sets a failed status",
+			missing:  true,
+			options:  plugins.BugzillaBranchOptions{StatusContext: &statusContext},
+			expected: []github.Status{{State: github.StatusFailure, Context: statusContext, Description: "No Bugzilla bug is referenced in the title of this pull request."}},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			e := event{
+				org: "org", repo: "repo", baseRef: "branch", headSHA: "abcdef", number: 1, bugId: 123, missing: testCase.missing, body: "Bug 123: fixed it!", htmlUrl: "http.com", login: "user",
+			}
+			gc := fakegithub.FakeClient{
+				IssueLabelsExisting: []string{},
+				IssueComments:       map[int][]github.IssueComment{},
+			}
+			bc := bugzilla.Fake{
+				EndpointString: "www.bugzilla",
+				Bugs:           map[int]bugzilla.Bug{},
+				BugErrors:      sets.NewInt(),
+			}
+			for _, bug := range testCase.bugs {
+				bc.Bugs[bug.ID] = bug
+			}
+			if err := handle(context.Background(), e, &gc, fakeUserResolver{}, &bc, testCase.options, logrus.WithField("testCase", testCase.name)); err != nil {
+				t.Fatalf("%s: expected no error but got one: %v", testCase.name, err)
+			}
+			if actual, expected := gc.CreatedStatuses[e.headSHA], testCase.expected; !reflect.DeepEqual(actual, expected) {
+				t.Errorf("%s: got incorrect statuses: %s", testCase.name, diff.ObjectReflectDiff(actual, expected))
+			}
+		})
+	}
+}
+
+// checksUnavailableClient fails to create check runs, like a client that is
+// not authenticated as a GitHub App
+type checksUnavailableClient struct {
+	*fakegithub.FakeClient
+}
+
+func (c checksUnavailableClient) CreateCheckRun(org, repo string, checkRun github.CheckRun) error {
+	return errors.New("injected error creating check run")
+}
+
+func TestHandleCheckRun(t *testing.T) {
+	yes, no := true, false
+	var testCases = []struct {
+		name              string
+		bug               bugzilla.Bug
+		options           plugins.BugzillaBranchOptions
+		checksUnavailable bool
+		assign            bool
+		emailLogins       map[string][]string
+		expectedCheckRuns []github.CheckRun
+		expectComment     bool
+		expectedComment   string
+	}{
+		{
+			name:          "comment is made without check runs configured",
+			bug:           bugzilla.Bug{ID: 123},
+			options:       plugins.BugzillaBranchOptions{ReportCheckRun: &no},
+			expectComment: true,
+		},
+		{
+			name:    "valid bug is reported in a successful check run instead of a comment",
+			bug:     bugzilla.Bug{ID: 123},
+			options: plugins.BugzillaBranchOptions{ReportCheckRun: &yes},
+			expectedCheckRuns: []github.CheckRun{{
+				Name:       "bugzilla",
+				HeadSHA:    "abcdef",
+				Conclusion: github.CheckRunConclusionSuccess,
+				Output: &github.CheckRunOutput{
+					Title:   "Bug 123 is valid.",
+					Summary: "This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.\n\n<details><summary>No validations were run on this bug</summary></details>",
+				},
+			}},
+		},
+		{
+			name:    "invalid bug is reported in a failed check run instead of a comment",
+			bug:     bugzilla.Bug{ID: 123, IsOpen: false},
+			options: plugins.BugzillaBranchOptions{IsOpen: &yes, ReportCheckRun: &yes},
+			expectedCheckRuns: []github.CheckRun{{
+				Name:       "bugzilla",
+				HeadSHA:    "abcdef",
+				Conclusion: github.CheckRunConclusionFailure,
+				Output: &github.CheckRunOutput{
+					Title: "Bug 123 is invalid: expected the bug to be open, but it isn't",
+					Summary: `This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is invalid:
+ - expected the bug to be open, but it isn't
+
+Comment <code>/bugzilla refresh</code> to re-evaluate validity if changes to the Bugzilla bug are made, or edit the title of this pull request to link to a different bug.`,
+				},
+			}},
+		},
+		{
+			name:        "QA contact is assigned in a comment even with check runs configured",
+			bug:         bugzilla.Bug{ID: 123, QAContactDetail: &bugzilla.User{Email: "qa_tester@example.com"}},
+			options:     plugins.BugzillaBranchOptions{ReportCheckRun: &yes},
+			assign:      true,
+			emailLogins: map[string][]string{"qa_tester@example.com": {"qa"}},
+			expectedCheckRuns: []github.CheckRun{{
+				Name:       "bugzilla",
+				HeadSHA:    "abcdef",
+				Conclusion: github.CheckRunConclusionSuccess,
+				Output: &github.CheckRunOutput{
+					Title:   "Bug 123 is valid.",
+					Summary: "This pull request references [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123), which is valid.\n\n<details><summary>No validations were run on this bug</summary></details>\n\nAssigning the QA contact for review:\n/assign @qa",
+				},
+			}},
+			expectComment:   true,
+			expectedComment: "/assign @qa",
+		},
+		{
+			name:              "comment is made when the check run cannot be created",
+			bug:               bugzilla.Bug{ID: 123},
+			options:           plugins.BugzillaBranchOptions{ReportCheckRun: &yes},
+			checksUnavailable: true,
+			expectComment:     true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			e := event{
+				org: "org", repo: "repo", baseRef: "branch", headSHA: "abcdef", number: 1, bugId: 123, body: "Bug 123: fixed it!", htmlUrl: "http.com", login: "user",
+			}
+			fake := &fakegithub.FakeClient{
+				IssueLabelsExisting: []string{},
+				IssueComments:       map[int][]github.IssueComment{},
+			}
+			var gc githubClient = fake
+			if testCase.checksUnavailable {
+				gc = checksUnavailableClient{FakeClient: fake}
+			}
+			bc := bugzilla.Fake{
+				EndpointString: "www.bugzilla",
+				Bugs:           map[int]bugzilla.Bug{testCase.bug.ID: testCase.bug},
+				BugErrors:      sets.NewInt(),
+			}
+			e.assign = testCase.assign
+			if err := handle(context.Background(), e, gc, fakeUserResolver{logins: testCase.emailLogins}, &bc, testCase.options, logrus.WithField("testCase", testCase.name)); err != nil {
+				t.Fatalf("%s: expected no error but got one: %v", testCase.name, err)
+			}
+			if actual, expected := fake.CreatedCheckRuns[e.headSHA], testCase.expectedCheckRuns; !reflect.DeepEqual(actual, expected) {
+				t.Errorf("%s: got incorrect check runs: %s", testCase.name, diff.ObjectReflectDiff(actual, expected))
+			}
+			if commented := len(fake.IssueComments[e.number]) > 0; commented != testCase.expectComment {
+				t.Errorf("%s: expected a comment: %v, but commented: %v", testCase.name, testCase.expectComment, commented)
+			}
+			if testCase.expectedComment != "" {
+				var found bool
+				for _, comment := range fake.IssueComments[e.number] {
+					found = found || strings.Contains(comment.Body, testCase.expectedComment)
+				}
+				if !found {
+					t.Errorf("%s: expected a comment containing %q, got %v", testCase.name, testCase.expectedComment, fake.IssueComments[e.number])
+				}
+			}
+		})
+	}
+}
+
+func TestHandleTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// never respond before the deadline passes
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	e := event{
+		org: "org", repo: "repo", baseRef: "branch", number: 1, bugId: 123, body: "Bug 123: fixed it!", htmlUrl: "http.com", login: "user",
+	}
+	gc := fakegithub.FakeClient{
+		IssueLabelsExisting: []string{},
+		IssueComments:       map[int][]github.IssueComment{},
+	}
+	bc := bugzilla.NewClient(func() []byte { return nil }, server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := handle(ctx, e, &gc, fakeUserResolver{}, bc, plugins.BugzillaBranchOptions{}, logrus.WithField("testCase", "timeout")); err != nil {
+		t.Fatalf("expected no error but got one: %v", err)
+	}
+	checkComments(gc, "timeout", fmt.Sprintf(`org/repo#1:@user: Timed out searching for bug 123 on the Bugzilla server at %s.
+Please try again later by requesting a bug refresh with <code>/bugzilla refresh</code>.
+
+<details>
+
+In response to [this](http.com):
+
+>Bug 123: fixed it!
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%%20issue:) repository.
+</details>`, server.URL), t)
+}
+
+// recordingHook keeps every log entry fired while it is attached to a logger
+type recordingHook struct {
+	entries []*logrus.Entry
+}
+
+func (h *recordingHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *recordingHook) Fire(entry *logrus.Entry) error {
+	h.entries = append(h.entries, entry)
+	return nil
+}
+
+func TestHandleLogsEventFields(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		e        event
+		expected logrus.Fields
+	}{
+		{
+			name:     "missing bug is logged as missing",
+			e:        event{org: "org", repo: "repo", baseRef: "branch", number: 1, missing: true, body: "fixed it!", htmlUrl: "http.com", login: "user"},
+			expected: logrus.Fields{"org": "org", "repo": "repo", "pr": 1, "bugMissing": true},
+		},
+		{
+			name:     "referenced bug is logged",
+			e:        event{org: "org", repo: "repo", baseRef: "branch", number: 1, bugId: 123, body: "Bug 123: fixed it!", htmlUrl: "http.com", login: "user"},
+			expected: logrus.Fields{"org": "org", "repo": "repo", "pr": 1, "bug": 123},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			logger := logrus.New()
+			logger.SetLevel(logrus.DebugLevel)
+			logger.SetOutput(ioutil.Discard)
+			hook := &recordingHook{}
+			logger.AddHook(hook)
+
+			gc := fakegithub.FakeClient{
+				IssueLabelsExisting: []string{},
+				IssueComments:       map[int][]github.IssueComment{},
+			}
+			bc := bugzilla.Fake{
+				EndpointString: "www.bugzilla",
+				Bugs:           map[int]bugzilla.Bug{123: {ID: 123}},
+				BugErrors:      sets.NewInt(),
+			}
+			if err := handle(context.Background(), testCase.e, &gc, fakeUserResolver{}, &bc, plugins.BugzillaBranchOptions{}, logrus.NewEntry(logger)); err != nil {
+				t.Fatalf("%s: expected no error but got one: %v", testCase.name, err)
+			}
+			if len(hook.entries) == 0 {
+				t.Fatalf("%s: expected log entries, got none", testCase.name)
+			}
+			for _, entry := range hook.entries {
+				for key, value := range testCase.expected {
+					if actual, ok := entry.Data[key]; !ok || actual != value {
+						t.Errorf("%s: expected log entry %q to have field %s=%v, got %v", testCase.name, entry.Message, key, value, entry.Data)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestHandleLabelCalls(t *testing.T) {
+	open := true
+	valid, invalid := bugzilla.Bug{ID: 123, IsOpen: true}, bugzilla.Bug{ID: 123, IsOpen: false}
+	var testCases = []struct {
+		name            string
+		labels          []string
+		missing         bool
+		bug             bugzilla.Bug
+		expectedAdded   []string
+		expectedRemoved []string
+	}{
+		{
+			name:          "unlabeled valid bug only adds the valid label",
+			bug:           valid,
+			expectedAdded: []string{"bugzilla/valid-bug"},
+		},
+		{
+			name:          "unlabeled invalid bug only adds the invalid label",
+			bug:           invalid,
+			expectedAdded: []string{"bugzilla/invalid-bug"},
+		},
+		{
+			name:   "valid bug staying valid makes no label calls",
+			labels: []string{"bugzilla/valid-bug"},
+			bug:    valid,
+		},
+		{
+			name:   "invalid bug staying invalid makes no label calls",
+			labels: []string{"bugzilla/invalid-bug"},
+			bug:    invalid,
+		},
+		{
+			name:            "valid bug becoming invalid swaps the labels",
+			labels:          []string{"bugzilla/valid-bug"},
+			bug:             invalid,
+			expectedAdded:   []string{"bugzilla/invalid-bug"},
+			expectedRemoved: []string{"bugzilla/valid-bug"},
+		},
+		{
+			name:            "invalid bug becoming valid swaps the labels",
+			labels:          []string{"bugzilla/invalid-bug"},
+			bug:             valid,
+			expectedAdded:   []string{"bugzilla/valid-bug"},
+			expectedRemoved: []string{"bugzilla/invalid-bug"},
+		},
+		{
+			name:            "valid bug with both labels only removes the invalid label",
+			labels:          []string{"bugzilla/valid-bug", "bugzilla/invalid-bug"},
+			bug:             valid,
+			expectedRemoved: []string{"bugzilla/invalid-bug"},
+		},
+		{
+			name:            "missing bug removes both labels",
+			labels:          []string{"bugzilla/valid-bug", "bugzilla/invalid-bug"},
+			missing:         true,
+			expectedRemoved: []string{"bugzilla/invalid-bug", "bugzilla/valid-bug"},
+		},
+		{
+			name:    "missing bug without labels makes no label calls",
+			missing: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			e := event{
+				org: "org", repo: "repo", baseRef: "branch", number: 1, bugId: 123, body: "Bug 123: fixed it!", htmlUrl: "http.com", login: "user", missing: testCase.missing,
+			}
+			gc := fakegithub.FakeClient{
+				IssueLabelsExisting: []string{},
+				IssueComments:       map[int][]github.IssueComment{},
+			}
+			for _, label := range testCase.labels {
+				gc.IssueLabelsExisting = append(gc.IssueLabelsExisting, fmt.Sprintf("%s/%s#%d:%s", e.org, e.repo, e.number, label))
+			}
+			bc := bugzilla.Fake{
+				EndpointString: "www.bugzilla",
+				Bugs:           map[int]bugzilla.Bug{testCase.bug.ID: testCase.bug},
+				BugErrors:      sets.NewInt(),
+			}
+			if err := handle(context.Background(), e, &gc, fakeUserResolver{}, &bc, plugins.BugzillaBranchOptions{IsOpen: &open}, logrus.WithField("testCase", testCase.name)); err != nil {
+				t.Fatalf("%s: expected no error but got one: %v", testCase.name, err)
+			}
+			var expectedAdded, expectedRemoved []string
+			for _, label := range testCase.expectedAdded {
+				expectedAdded = append(expectedAdded, fmt.Sprintf("%s/%s#%d:%s", e.org, e.repo, e.number, label))
+			}
+			for _, label := range testCase.expectedRemoved {
+				expectedRemoved = append(expectedRemoved, fmt.Sprintf("%s/%s#%d:%s", e.org, e.repo, e.number, label))
+			}
+			if actual, expected := gc.IssueLabelsAdded, expectedAdded; !reflect.DeepEqual(actual, expected) {
+				t.Errorf("%s: expected labels to be added with %d calls (%v), got %d calls (%v)", testCase.name, len(expected), expected, len(actual), actual)
+			}
+			if actual, expected := gc.IssueLabelsRemoved, expectedRemoved; !reflect.DeepEqual(actual, expected) {
+				t.Errorf("%s: expected labels to be removed with %d calls (%v), got %d calls (%v)", testCase.name, len(expected), expected, len(actual), actual)
+			}
+		})
+	}
+}
+
+func TestNotifyBugOnPersistentInvalid(t *testing.T) {
+	yes, no := true, false
+	two := 2
+	valid, invalid := bugzilla.Bug{ID: 123, IsOpen: true}, bugzilla.Bug{ID: 123, IsOpen: false}
+	var testCases = []struct {
+		name    string
+		options plugins.BugzillaBranchOptions
+		// bugs holds the state of the bug at each validation
+		bugs             []bugzilla.Bug
+		expectedComments int
+	}{
+		{
+			name:             "notification disabled never comments",
+			options:          plugins.BugzillaBranchOptions{IsOpen: &yes},
+			bugs:             []bugzilla.Bug{invalid, invalid, invalid, invalid},
+			expectedComments: 0,
+		},
+		{
+			name:             "notification explicitly disabled never comments",
+			options:          plugins.BugzillaBranchOptions{IsOpen: &yes, NotifyBugOnPersistentInvalid: &no},
+			bugs:             []bugzilla.Bug{invalid, invalid, invalid, invalid},
+			expectedComments: 0,
+		},
+		{
+			name:             "fewer failures than the default threshold do not comment",
+			options:          plugins.BugzillaBranchOptions{IsOpen: &yes, NotifyBugOnPersistentInvalid: &yes},
+			bugs:             []bugzilla.Bug{invalid, invalid},
+			expectedComments: 0,
+		},
+		{
+			name:             "reaching the default threshold comments",
+			options:          plugins.BugzillaBranchOptions{IsOpen: &yes, NotifyBugOnPersistentInvalid: &yes},
+			bugs:             []bugzilla.Bug{invalid, invalid, invalid},
+			expectedComments: 1,
+		},
+		{
+			name:             "reaching a configured threshold comments",
+			options:          plugins.BugzillaBranchOptions{IsOpen: &yes, NotifyBugOnPersistentInvalid: &yes, PersistentInvalidThreshold: &two},
+			bugs:             []bugzilla.Bug{invalid, invalid},
+			expectedComments: 1,
+		},
+		{
+			name:             "failures past the threshold only comment once",
+			options:          plugins.BugzillaBranchOptions{IsOpen: &yes, NotifyBugOnPersistentInvalid: &yes, PersistentInvalidThreshold: &two},
+			bugs:             []bugzilla.Bug{invalid, invalid, invalid, valid, invalid, invalid},
+			expectedComments: 1,
+		},
+		{
+			name:             "a valid bug resets the count of failures",
+			options:          plugins.BugzillaBranchOptions{IsOpen: &yes, NotifyBugOnPersistentInvalid: &yes, PersistentInvalidThreshold: &two},
+			bugs:             []bugzilla.Bug{invalid, valid, invalid, valid},
+			expectedComments: 0,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			persistentInvalid = newInvalidTracker()
+			e := event{
+				org: "org", repo: "repo", baseRef: "branch", number: 1, bugId: 123, body: "Bug 123: fixed it!", htmlUrl: "http.com", login: "user",
+			}
+			bc := bugzilla.Fake{
+				EndpointString: "www.bugzilla",
+				Bugs:           map[int]bugzilla.Bug{},
+				BugErrors:      sets.NewInt(),
+			}
+			for _, bug := range testCase.bugs {
+				bc.Bugs[bug.ID] = bug
+				gc := fakegithub.FakeClient{
+					IssueLabelsExisting: []string{},
+					IssueComments:       map[int][]github.IssueComment{},
+				}
+				if err := handle(context.Background(), e, &gc, fakeUserResolver{}, &bc, testCase.options, logrus.WithField("testCase", testCase.name)); err != nil {
+					t.Fatalf("%s: expected no error but got one: %v", testCase.name, err)
+				}
+			}
+			if actual, expected := len(bc.BugComments[123]), testCase.expectedComments; actual != expected {
+				t.Errorf("%s: expected %d comments on the bug, got %d: %v", testCase.name, expected, actual, bc.BugComments[123])
+			}
+		})
+	}
+}
+
+func TestNotifyPersistentInvalidComment(t *testing.T) {
+	persistentInvalid = newInvalidTracker()
+	yes, one := true, 1
+	e := event{org: "org", repo: "repo", number: 1, bugId: 123}
+	bc := bugzilla.Fake{
+		EndpointString: "www.bugzilla",
+		Bugs:           map[int]bugzilla.Bug{123: {ID: 123}},
+		BugErrors:      sets.NewInt(),
+	}
+	options := plugins.BugzillaBranchOptions{NotifyBugOnPersistentInvalid: &yes, PersistentInvalidThreshold: &one}
+	notifyPersistentInvalid(e, &bc, options, []string{"expected the bug to be open, but it isn't"}, logrus.WithField("testCase", "comment"))
+	expected := []string{`Pull request org/repo#1 references this bug, but has failed validation 1 times in a row:
+ - expected the bug to be open, but it isn't
+Please triage this bug so that the pull request can be validated.`}
+	if actual := bc.BugComments[123]; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got incorrect comment on the bug: %v", diff.ObjectReflectDiff(expected, actual))
+	}
+}
+
+func TestInvalidTrackerExpiry(t *testing.T) {
+	now := time.Now()
+	tracker := newInvalidTracker()
+	tracker.now = func() time.Time { return now }
+
+	if tracker.recordInvalid("www.bugzilla", 1, 2) {
+		t.Fatal("expected the first failure not to reach the threshold")
+	}
+	if !tracker.recordInvalid("www.bugzilla", 1, 2) {
+		t.Fatal("expected the second failure to reach the threshold")
+	}
+	tracker.recordNotified("www.bugzilla", 1)
+	if tracker.recordInvalid("www.bugzilla", 1, 2) {
+		t.Fatal("expected a notified bug not to be commented on again")
+	}
+
+	now = now.Add(invalidTrackerTTL + time.Minute)
+	if tracker.recordInvalid("www.bugzilla", 2, 2) {
+		t.Fatal("expected the first failure of another bug not to reach the threshold")
+	}
+	if _, tracked := tracker.records[trackerKey("www.bugzilla", 1)]; tracked {
+		t.Error("expected a bug that has not failed validation recently to be forgotten")
+	}
+	if actual := len(tracker.records); actual != 1 {
+		t.Errorf("expected the tracker to hold one bug, got %d", actual)
+	}
+}
+
+func TestTimeoutFor(t *testing.T) {
+	configured, malformed := "30s", "thirty seconds"
+	var testCases = []struct {
+		name     string
+		options  plugins.BugzillaBranchOptions
+		expected time.Duration
+	}{
+		{
+			name:     "no timeout configured uses the default",
+			expected: defaultTimeout,
+		},
+		{
+			name:     "configured timeout is used",
+			options:  plugins.BugzillaBranchOptions{Timeout: &configured},
+			expected: 30 * time.Second,
+		},
+		{
+			name:     "malformed timeout uses the default",
+			options:  plugins.BugzillaBranchOptions{Timeout: &malformed},
+			expected: defaultTimeout,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual, expected := timeoutFor(testCase.options), testCase.expected; actual != expected {
+				t.Errorf("%s: expected timeout %s, got %s", testCase.name, expected, actual)
+			}
+		})
+	}
+}
+
+func TestInvalidStatusDescription(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		why      []string
+		expected string
+	}{
+		{
+			name:     "no reasons",
+			expected: "Bug 123 is invalid.",
+		},
+		{
+			name:     "markdown links are replaced with their text",
+			why:      []string{"expected dependent [Bugzilla bug 1](bugzilla.com/show_bug.cgi?id=1) to be in one of the following states: VERIFIED, but it is NEW instead"},
+			expected: "Bug 123 is invalid: expected dependent Bugzilla bug 1 to be in one of the following states: VERIFIED, but it is NEW instead",
+		},
+		{
+			name:     "further reasons are counted",
+			why:      []string{"expected the bug to be open, but it isn't", "expected the bug to be assigned to someone"},
+			expected: "Bug 123 is invalid: expected the bug to be open, but it isn't (and 1 more)",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual, expected := invalidStatusDescription(123, testCase.why), testCase.expected; actual != expected {
+				t.Errorf("%s: expected description %q, got %q", testCase.name, expected, actual)
+			}
+		})
+	}
+}
+
+func TestTruncateStatusDescription(t *testing.T) {
+	short := "Bug 123 is valid."
+	if actual := truncateStatusDescription(short); actual != short {
+		t.Errorf("expected short description to be unchanged, got %q", actual)
+	}
+	long := strings.Repeat("a", maxStatusDescriptionLength+1)
+	if actual, expected := truncateStatusDescription(long), strings.Repeat("a", maxStatusDescriptionLength-3)+"..."; actual != expected {
+		t.Errorf("expected description %q, got %q", expected, actual)
+	}
+}
+
+func TestTruncateSummary(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		summary  string
+		expected string
+	}{
+		{
+			name: "empty summary stays empty",
+		},
+		{
+			name:     "short summary is unchanged",
+			summary:  "pods cannot reach the service",
+			expected: "pods cannot reach the service",
+		},
+		{
+			name:     "surrounding whitespace is trimmed",
+			summary:  "  pods cannot reach the service\n",
+			expected: "pods cannot reach the service",
+		},
+		{
+			name:     "summary of exactly the maximum length is unchanged",
+			summary:  strings.Repeat("a", maxSummaryLength),
+			expected: strings.Repeat("a", maxSummaryLength),
+		},
+		{
+			name:     "long summary is truncated",
+			summary:  strings.Repeat("a", maxSummaryLength+1),
+			expected: strings.Repeat("a", maxSummaryLength-3) + "...",
+		},
+		{
+			name:     "truncation counts characters, not bytes",
+			summary:  strings.Repeat("ü", maxSummaryLength+1),
+			expected: strings.Repeat("ü", maxSummaryLength-3) + "...",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual, expected := truncateSummary(testCase.summary), testCase.expected; actual != expected {
+				t.Errorf("%s: expected summary %q, got %q", testCase.name, expected, actual)
+			}
+		})
+	}
+}
+
+func checkComments(client fakegithub.FakeClient, name, expectedComment string, t *testing.T) {
+	wantedComments := 0
+	if expectedComment != "" {
+		wantedComments = 1
+	}
+	if len(client.IssueCommentsAdded) != wantedComments {
+		t.Errorf("%s: wanted %d comment, got %d: %v", name, wantedComments, len(client.IssueCommentsAdded), client.IssueCommentsAdded)
+	}
+
+	if expectedComment != "" && len(client.IssueCommentsAdded) == 1 {
+		if expectedComment != client.IssueCommentsAdded[0] {
+			t.Errorf("%s: got incorrect comment: %v", name, diff.StringDiff(expectedComment, client.IssueCommentsAdded[0]))
+		}
+	}
+}
+
+func TestHandleCherrypick(t *testing.T) {
+	v2 := "v2"
+	base := &event{
+		org: "org", repo: "repo", baseRef: "branch", number: 1, bugId: 123, body: "/bugzilla cherrypick release-4.12", htmlUrl: "http.com", login: "user", cherrypickTo: "release-4.12",
+	}
+	var testCases = []struct {
+		name            string
+		missing         bool
+		bugs            []bugzilla.Bug
+		bugErrors       []int
+		targetOptions   plugins.BugzillaBranchOptions
+		expectedComment string
+		expectedClone   *bugzilla.Bug
+	}{
+		{
+			name:    "no bug referenced leaves a comment",
+			missing: true,
+			expectedComment: `org/repo#1:@user: No Bugzilla bug is referenced in the title of this pull request, so there is no bug to clone for the release-4.12 branch.
+To reference a bug, add 'Bug XXX:' to the title of this pull request and request another clone with <code>/bugzilla cherrypick release-4.12</code>.
 
 <details>
 
 In response to [this](http.com):
 
->Bug 123: fixed it!
+>/bugzilla cherrypick release-4.12
 
 
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
-			expectedBug: &bugzilla.Bug{ID: 123, Status: "CLOSED", Resolution: "MERGED"},
 		},
 		{
-			name:   "valid bug on merged PR with one external link migrates to new state and comments",
-			merged: true,
-			bugs:   []bugzilla.Bug{{ID: 123}},
-			externalBugs: []bugzilla.ExternalBug{{
-				BugzillaBugID: base.bugId,
-				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
-				Org:           base.org, Repo: base.repo, Num: base.number,
-			}},
-			prs:     []github.PullRequest{{Number: base.number, Merged: true}},
-			options: plugins.BugzillaBranchOptions{StateAfterMerge: &modified}, // no requirements --> always valid
-			expectedComment: `org/repo#1:@user: All pull requests linked via external trackers have merged: [org/repo#1](https://github.com/org/repo/pull/1). [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been moved to the MODIFIED state.
+			name: "missing bug leaves a comment",
+			expectedComment: `org/repo#1:@user: No Bugzilla bug with ID 123 exists in the tracker at www.bugzilla.
+Once a valid bug is referenced in the title of this pull request, request a bug refresh with <code>/bugzilla refresh</code>.
 
 <details>
 
 In response to [this](http.com):
 
->Bug 123: fixed it!
+>/bugzilla cherrypick release-4.12
 
 
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
-			expectedBug: &bugzilla.Bug{ID: 123, Status: "MODIFIED"},
 		},
 		{
-			name:   "valid bug on merged PR with many external links migrates to new state and comments",
-			merged: true,
-			bugs:   []bugzilla.Bug{{ID: 123}},
-			externalBugs: []bugzilla.ExternalBug{{
-				BugzillaBugID: base.bugId,
-				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
-				Org:           base.org, Repo: base.repo, Num: base.number,
-			}, {
-				BugzillaBugID: base.bugId,
-				ExternalBugID: fmt.Sprintf("%s/%s/pull/22", base.org, base.repo),
-				Org:           base.org, Repo: base.repo, Num: 22,
-			}},
-			prs:     []github.PullRequest{{Number: base.number, Merged: true}, {Number: 22, Merged: true}},
-			options: plugins.BugzillaBranchOptions{StateAfterMerge: &modified}, // no requirements --> always valid
-			expectedComment: `org/repo#1:@user: All pull requests linked via external trackers have merged: [org/repo#1](https://github.com/org/repo/pull/1), [org/repo#22](https://github.com/org/repo/pull/22). [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been moved to the MODIFIED state.
+			name:      "error getting the bug leaves a comment",
+			bugs:      []bugzilla.Bug{{ID: 123, Summary: "oopsie"}},
+			bugErrors: []int{123},
+			expectedComment: `org/repo#1:@user: An error was encountered searching for bug 123 on the Bugzilla server at www.bugzilla:
+> injected error getting bug
+Please contact an administrator to resolve this issue, then request a bug refresh with <code>/bugzilla refresh</code>.
 
 <details>
 
 In response to [this](http.com):
 
->Bug 123: fixed it!
+>/bugzilla cherrypick release-4.12
 
 
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
-			expectedBug: &bugzilla.Bug{ID: 123, Status: "MODIFIED"},
 		},
 		{
-			name:   "valid bug on merged PR with unmerged external links does nothing",
-			merged: true,
-			bugs:   []bugzilla.Bug{{ID: 123}},
-			externalBugs: []bugzilla.ExternalBug{{
-				BugzillaBugID: base.bugId,
-				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
-				Org:           base.org, Repo: base.repo, Num: base.number,
-			}, {
-				BugzillaBugID: base.bugId,
-				ExternalBugID: fmt.Sprintf("%s/%s/pull/22", base.org, base.repo),
-				Org:           base.org, Repo: base.repo, Num: 22,
-			}},
-			prs:         []github.PullRequest{{Number: base.number, Merged: true}, {Number: 22, Merged: false, State: "open"}},
-			options:     plugins.BugzillaBranchOptions{StateAfterMerge: &modified}, // no requirements --> always valid
-			expectedBug: &bugzilla.Bug{ID: 123},
-			expectedComment: `org/repo#1:@user: Some pull requests linked via external trackers have merged: [org/repo#1](https://github.com/org/repo/pull/1). The following pull requests linked via external trackers have not merged:
- * [org/repo#22](https://github.com/org/repo/pull/22) is open
-[Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been moved to the MODIFIED state.
+			name: "bug is cloned and the clone is reported",
+			bugs: []bugzilla.Bug{{ID: 123, Summary: "oopsie", Product: "Product", Component: []string{"Component"}, TargetRelease: []string{"v1"}}},
+			expectedComment: `org/repo#1:@user: [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been cloned as [Bugzilla bug 124](www.bugzilla/show_bug.cgi?id=124) for the release-4.12 branch.
+Title the cherry-pick pull request against the release-4.12 branch <code>Bug 124: oopsie</code> to reference the clone.
 
 <details>
 
 In response to [this](http.com):
 
->Bug 123: fixed it!
+>/bugzilla cherrypick release-4.12
 
 
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
+			expectedClone: &bugzilla.Bug{ID: 124, Summary: "oopsie", Product: "Product", Component: []string{"Component"}, TargetRelease: []string{"v1"}, DependsOn: []int{123}, IsOpen: true, Status: "NEW"},
 		},
 		{
-			name:   "valid bug on merged PR with one external link but no status after merge configured does nothing",
-			merged: true,
-			bugs:   []bugzilla.Bug{{ID: 123}},
-			externalBugs: []bugzilla.ExternalBug{{
-				BugzillaBugID: base.bugId,
-				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
-				Org:           base.org, Repo: base.repo, Num: base.number,
-			}},
-			prs:         []github.PullRequest{{Number: base.number, Merged: true}},
-			options:     plugins.BugzillaBranchOptions{}, // no requirements --> always valid
-			expectedBug: &bugzilla.Bug{ID: 123},
+			name:          "clone targets the release configured for the target branch",
+			bugs:          []bugzilla.Bug{{ID: 123, Summary: "oopsie", TargetRelease: []string{"v1"}}},
+			targetOptions: plugins.BugzillaBranchOptions{TargetRelease: &v2},
+			expectedComment: `org/repo#1:@user: [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has been cloned as [Bugzilla bug 124](www.bugzilla/show_bug.cgi?id=124) for the release-4.12 branch.
+Title the cherry-pick pull request against the release-4.12 branch <code>Bug 124: oopsie</code> to reference the clone.
+
+<details>
+
+In response to [this](http.com):
+
+>/bugzilla cherrypick release-4.12
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
+			expectedClone: &bugzilla.Bug{ID: 124, Summary: "oopsie", TargetRelease: []string{"v2"}, DependsOn: []int{123}, IsOpen: true, Status: "NEW"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			e := *base // copy so parallel tests don't collide
+			e.missing = testCase.missing
+			gc := fakegithub.FakeClient{
+				IssueComments: map[int][]github.IssueComment{},
+			}
+			bc := bugzilla.Fake{
+				EndpointString: "www.bugzilla",
+				Bugs:           map[int]bugzilla.Bug{},
+				BugErrors:      sets.NewInt(testCase.bugErrors...),
+			}
+			for _, bug := range testCase.bugs {
+				bc.Bugs[bug.ID] = bug
+			}
+			if err := handleCherrypick(context.Background(), e, &gc, &bc, plugins.BugzillaBranchOptions{}, testCase.targetOptions, logrus.WithField("testCase", testCase.name)); err != nil {
+				t.Errorf("%s: expected no error but got one: %v", testCase.name, err)
+			}
+
+			checkComments(gc, testCase.name, testCase.expectedComment, t)
+
+			if testCase.expectedClone != nil {
+				actual, err := bc.GetBug(testCase.expectedClone.ID)
+				if err != nil {
+					t.Fatalf("%s: could not get cloned bug from fake client: %v", testCase.name, err)
+				}
+				if expected := testCase.expectedClone; !reflect.DeepEqual(actual, expected) {
+					t.Errorf("%s: got incorrect clone: %v", testCase.name, diff.ObjectReflectDiff(actual, expected))
+				}
+			}
+		})
+	}
+}
+
+func TestHandleUnlink(t *testing.T) {
+	base := &event{
+		org: "org", repo: "repo", baseRef: "branch", number: 1, bugId: 123, body: "/bugzilla unlink", htmlUrl: "http.com", login: "user", unlink: true,
+	}
+	linked := bugzilla.ExternalBug{BugzillaBugID: 123, ExternalBugID: "org/repo/pull/1"}
+	other := bugzilla.ExternalBug{BugzillaBugID: 123, ExternalBugID: "org/repo/pull/2"}
+	var testCases = []struct {
+		name                 string
+		missing              bool
+		labels               []string
+		bugErrors            []int
+		externalBugs         []bugzilla.ExternalBug
+		expectedLabels       []string
+		expectedComment      string
+		expectedExternalBugs []bugzilla.ExternalBug
+	}{
+		{
+			name:           "no bug referenced leaves a comment",
+			missing:        true,
+			labels:         []string{"bugzilla/valid-bug"},
+			expectedLabels: []string{"bugzilla/valid-bug"},
+			expectedComment: `org/repo#1:@user: No Bugzilla bug is referenced in the title of this pull request, so there is no external tracker link to remove.
+
+<details>
+
+In response to [this](http.com):
+
+>/bugzilla unlink
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
 		},
 		{
-			name:    "valid bug on merged PR with one external link but no referenced bug in the title does nothing",
-			merged:  true,
-			missing: true,
-			bugs:    []bugzilla.Bug{{ID: 123}},
-			externalBugs: []bugzilla.ExternalBug{{
-				BugzillaBugID: base.bugId,
-				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
-				Org:           base.org, Repo: base.repo, Num: base.number,
-			}},
-			prs:         []github.PullRequest{{Number: base.number, Merged: true}},
-			options:     plugins.BugzillaBranchOptions{StateAfterMerge: &modified}, // no requirements --> always valid
-			expectedBug: &bugzilla.Bug{ID: 123},
+			name:                 "linked pull request is removed from the external tracker bugs and loses its labels",
+			labels:               []string{"bugzilla/valid-bug", "lgtm"},
+			externalBugs:         []bugzilla.ExternalBug{linked, other},
+			expectedLabels:       []string{"lgtm"},
+			expectedExternalBugs: []bugzilla.ExternalBug{other},
+			expectedComment: `org/repo#1:@user: This pull request has been removed from the external tracker bugs of [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123).
+Edit the title of this pull request to reference the correct bug.
+
+<details>
+
+In response to [this](http.com):
+
+>/bugzilla unlink
+
+
+Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
+</details>`,
 		},
 		{
-			name:      "valid bug on merged PR with one external link fails to update bug and comments",
-			merged:    true,
-			bugs:      []bugzilla.Bug{{ID: 123}},
-			bugErrors: []int{123},
-			externalBugs: []bugzilla.ExternalBug{{
-				BugzillaBugID: base.bugId,
-				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
-				Org:           base.org, Repo: base.repo, Num: base.number,
-			}},
-			prs:     []github.PullRequest{{Number: base.number, Merged: true}},
-			options: plugins.BugzillaBranchOptions{StateAfterMerge: &modified}, // no requirements --> always valid
-			expectedComment: `org/repo#1:@user: An error was encountered searching for external tracker bugs for bug 123 on the Bugzilla server at www.bugzilla:
-> injected error adding external bug to bug
-Please contact an administrator to resolve this issue, then request a bug refresh with <code>/bugzilla refresh</code>.
+			name:                 "pull request without an external tracker link is reported",
+			labels:               []string{"bugzilla/invalid-bug"},
+			externalBugs:         []bugzilla.ExternalBug{other},
+			expectedExternalBugs: []bugzilla.ExternalBug{other},
+			expectedComment: `org/repo#1:@user: [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) has no external tracker link to this pull request, so there is nothing to remove.
 
 <details>
 
 In response to [this](http.com):
 
->Bug 123: fixed it!
+>/bugzilla unlink
 
 
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
-			expectedBug: &bugzilla.Bug{ID: 123},
 		},
 		{
-			name:   "valid bug on merged PR with merged external links but unknown status does not migrate to new state and comments",
-			merged: true,
-			bugs:   []bugzilla.Bug{{ID: 123, Status: "CLOSED"}},
-			externalBugs: []bugzilla.ExternalBug{{
-				BugzillaBugID: base.bugId,
-				ExternalBugID: fmt.Sprintf("%s/%s/pull/%d", base.org, base.repo, base.number),
-				Org:           base.org, Repo: base.repo, Num: base.number,
-			}},
-			prs:     []github.PullRequest{{Number: base.number, Merged: true}},
-			options: plugins.BugzillaBranchOptions{StateAfterValidation: &updated, StateAfterMerge: &modified}, // no requirements --> always valid
-			expectedComment: `org/repo#1:@user: [Bugzilla bug 123](www.bugzilla/show_bug.cgi?id=123) is in an unrecognized state (CLOSED) and will not be moved to the MODIFIED state.
+			name:                 "error removing the link leaves a comment and keeps the labels",
+			labels:               []string{"bugzilla/valid-bug"},
+			bugErrors:            []int{123},
+			externalBugs:         []bugzilla.ExternalBug{linked},
+			expectedLabels:       []string{"bugzilla/valid-bug"},
+			expectedExternalBugs: []bugzilla.ExternalBug{linked},
+			expectedComment: `org/repo#1:@user: An error was encountered removing this pull request from the external tracker bugs for bug 123 on the Bugzilla server at www.bugzilla:
+> injected error removing external bug from bug
+Please contact an administrator to resolve this issue, then request a bug refresh with <code>/bugzilla refresh</code>.
 
 <details>
 
 In response to [this](http.com):
 
->Bug 123: fixed it!
+>/bugzilla unlink
 
 
 Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [kubernetes/test-infra](https://github.com/kubernetes/test-infra/issues/new?title=Prow%20issue:) repository.
 </details>`,
-			expectedBug: &bugzilla.Bug{ID: 123, Status: "CLOSED"},
 		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
 			e := *base // copy so parallel tests don't collide
+			e.missing = testCase.missing
 			gc := fakegithub.FakeClient{
 				IssueLabelsExisting: []string{},
 				IssueComments:       map[int][]github.IssueComment{},
-				PullRequests:        map[int]*github.PullRequest{},
 			}
 			for _, label := range testCase.labels {
 				gc.IssueLabelsExisting = append(gc.IssueLabelsExisting, fmt.Sprintf("%s/%s#%d:%s", e.org, e.repo, e.number, label))
 			}
-			for _, pr := range testCase.prs {
-				gc.PullRequests[pr.Number] = &pr
-			}
 			bc := bugzilla.Fake{
 				EndpointString: "www.bugzilla",
-				Bugs:           map[int]bugzilla.Bug{},
-				BugErrors:      sets.NewInt(),
-				ExternalBugs:   map[int][]bugzilla.ExternalBug{},
-			}
-			for _, bug := range testCase.bugs {
-				bc.Bugs[bug.ID] = bug
-			}
-			bc.BugErrors.Insert(testCase.bugErrors...)
-			for _, externalBug := range testCase.externalBugs {
-				bc.ExternalBugs[externalBug.BugzillaBugID] = append(bc.ExternalBugs[externalBug.BugzillaBugID], externalBug)
+				Bugs:           map[int]bugzilla.Bug{123: {ID: 123}},
+				BugErrors:      sets.NewInt(testCase.bugErrors...),
+				ExternalBugs:   map[int][]bugzilla.ExternalBug{123: testCase.externalBugs},
 			}
-			e.missing = testCase.missing
-			e.merged = testCase.merged
-			err := handle(e, &gc, &bc, testCase.options, logrus.WithField("testCase", testCase.name))
-			if err != nil {
+			if err := handleUnlink(context.Background(), e, &gc, &bc, plugins.BugzillaBranchOptions{}, logrus.WithField("testCase", testCase.name)); err != nil {
 				t.Errorf("%s: expected no error but got one: %v", testCase.name, err)
 			}
 
@@ -1062,47 +3302,21 @@ Instructions for interacting with me using PR comments are available [here](http
 			for _, label := range testCase.expectedLabels {
 				expected.Insert(fmt.Sprintf("%s/%s#%d:%s", e.org, e.repo, e.number, label))
 			}
-
 			actual := sets.NewString(gc.IssueLabelsExisting...)
 			actual.Insert(gc.IssueLabelsAdded...)
 			actual.Delete(gc.IssueLabelsRemoved...)
-
-			if missing := expected.Difference(actual); missing.Len() > 0 {
-				t.Errorf("%s: missing expected labels: %v", testCase.name, missing.List())
-			}
-			if extra := actual.Difference(expected); extra.Len() > 0 {
-				t.Errorf("%s: unexpected labels: %v", testCase.name, extra.List())
+			if !expected.Equal(actual) {
+				t.Errorf("%s: got incorrect labels: %s", testCase.name, diff.ObjectReflectDiff(expected.List(), actual.List()))
 			}
 
 			checkComments(gc, testCase.name, testCase.expectedComment, t)
 
-			if testCase.expectedBug != nil {
-				if actual, expected := bc.Bugs[testCase.expectedBug.ID], *testCase.expectedBug; !reflect.DeepEqual(actual, expected) {
-					t.Errorf("%s: got incorrect bug after update: %s", testCase.name, diff.ObjectReflectDiff(actual, expected))
-				}
-			}
-			if len(testCase.expectedExternalBugs) > 0 {
-				if actual, expected := bc.ExternalBugs[testCase.expectedBug.ID], testCase.expectedExternalBugs; !reflect.DeepEqual(actual, expected) {
-					t.Errorf("%s: got incorrect external bugs after update: %s", testCase.name, diff.ObjectReflectDiff(actual, expected))
-				}
-			}
-		})
-	}
-}
-
-func checkComments(client fakegithub.FakeClient, name, expectedComment string, t *testing.T) {
-	wantedComments := 0
-	if expectedComment != "" {
-		wantedComments = 1
-	}
-	if len(client.IssueCommentsAdded) != wantedComments {
-		t.Errorf("%s: wanted %d comment, got %d: %v", name, wantedComments, len(client.IssueCommentsAdded), client.IssueCommentsAdded)
-	}
-
-	if expectedComment != "" && len(client.IssueCommentsAdded) == 1 {
-		if expectedComment != client.IssueCommentsAdded[0] {
-			t.Errorf("%s: got incorrect comment: %v", name, diff.StringDiff(expectedComment, client.IssueCommentsAdded[0]))
-		}
+			if actual, expected := bc.ExternalBugs[123], testCase.expectedExternalBugs; len(actual) != 0 || len(expected) != 0 {
+				if !reflect.DeepEqual(actual, expected) {
+					t.Errorf("%s: got incorrect external bugs: %s", testCase.name, diff.ObjectReflectDiff(actual, expected))
+				}
+			}
+		})
 	}
 }
 
@@ -1139,11 +3353,39 @@ func TestTitleMatch(t *testing.T) {
 			title:    "Bug 34: Revert: \"Bug 12: Revert default\"",
 			expected: 34,
 		},
+		{
+			title:    "Bug\u00a012: Non-breaking space",
+			expected: 12,
+		},
+		{
+			title:    "Bug  12: Repeated spaces",
+			expected: 12,
+		},
+		{
+			title:    "Bug 12\uff1a Full-width colon",
+			expected: 12,
+		},
+		{
+			title:    "Bug \uff11\uff12: Full-width digits",
+			expected: 12,
+		},
+		{
+			title:    "Bug 12\u200b: Zero-width space",
+			expected: 12,
+		},
+		{
+			title:    "\ufeffBug 12: Byte order mark",
+			expected: 12,
+		},
+		{
+			title:    "Bug 12\u00a0: Non-breaking space before colon",
+			expected: -1,
+		},
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.title, func(t *testing.T) {
 			actual := -1
-			match := titleMatch.FindStringSubmatch(testCase.title)
+			match := titleMatch.FindStringSubmatch(normalizeTitle(testCase.title))
 			if match != nil {
 				id, err := strconv.Atoi(match[1])
 				if err != nil {
@@ -1166,14 +3408,19 @@ func TestValidateBug(t *testing.T) {
 	verified := []plugins.BugzillaBugState{{Status: "VERIFIED"}}
 	modified := []plugins.BugzillaBugState{{Status: "MODIFIED"}}
 	updated := plugins.BugzillaBugState{Status: "UPDATED"}
+	summaryPrefix := `^\[[^\]]+\] `
+	componentsByPath := map[string]string{"pkg/": "Core", "pkg/network/": "Networking", "pkg/storage/": "Storage"}
+	oneBug, twoBugs := 1, 2
+	dependsOn, blocks := plugins.BugzillaDependentDirectionDependsOn, plugins.BugzillaDependentDirectionBlocks
 	var testCases = []struct {
-		name        string
-		bug         bugzilla.Bug
-		dependents  []bugzilla.Bug
-		options     plugins.BugzillaBranchOptions
-		valid       bool
-		validations []string
-		why         []string
+		name         string
+		bug          bugzilla.Bug
+		dependents   []bugzilla.Bug
+		changedFiles []string
+		options      plugins.BugzillaBranchOptions
+		valid        bool
+		validations  []string
+		why          []string
 	}{
 		{
 			name:    "no requirements means a valid bug",
@@ -1230,6 +3477,61 @@ func TestValidateBug(t *testing.T) {
 			valid:   false,
 			why:     []string{"expected the bug to target the \"v1\" release, but no target release was set"},
 		},
+		{
+			name:        "having all required flags set means a valid bug",
+			bug:         bugzilla.Bug{Flags: []bugzilla.Flag{{Name: "qe_test_coverage", Status: "+"}, {Name: "requires_doc_text", Status: "+"}}},
+			options:     plugins.BugzillaBranchOptions{RequiredFlags: []string{"qe_test_coverage", "requires_doc_text"}},
+			valid:       true,
+			validations: []string{`bug has the required flags set to "+": qe_test_coverage, requires_doc_text`},
+		},
+		{
+			name:    "missing a required flag means an invalid bug",
+			bug:     bugzilla.Bug{Flags: []bugzilla.Flag{{Name: "qe_test_coverage", Status: "+"}}},
+			options: plugins.BugzillaBranchOptions{RequiredFlags: []string{"qe_test_coverage", "requires_doc_text"}},
+			valid:   false,
+			why:     []string{`expected the bug to have the following flags set to "+": qe_test_coverage, requires_doc_text, but these are missing: requires_doc_text`},
+		},
+		{
+			name:    "a required flag set to another status means an invalid bug",
+			bug:     bugzilla.Bug{Flags: []bugzilla.Flag{{Name: "qe_test_coverage", Status: "?"}}},
+			options: plugins.BugzillaBranchOptions{RequiredFlags: []string{"qe_test_coverage"}},
+			valid:   false,
+			why:     []string{`expected the bug to have the following flags set to "+": qe_test_coverage, but these are missing: qe_test_coverage`},
+		},
+		{
+			name:    "no required flags means flags are not checked",
+			bug:     bugzilla.Bug{Flags: []bugzilla.Flag{{Name: "qe_test_coverage", Status: "-"}}},
+			options: plugins.BugzillaBranchOptions{},
+			valid:   true,
+		},
+		{
+			name:        "matching any of the target releases means a valid bug",
+			bug:         bugzilla.Bug{TargetRelease: []string{"v2"}},
+			options:     plugins.BugzillaBranchOptions{TargetReleases: &[]string{"v1", "v2"}},
+			valid:       true,
+			validations: []string{"bug target release (v2) matches configured target release for branch (v1, v2)"},
+		},
+		{
+			name:        "matching a target release in addition to the target release means a valid bug",
+			bug:         bugzilla.Bug{TargetRelease: []string{"v2"}},
+			options:     plugins.BugzillaBranchOptions{TargetRelease: &one, TargetReleases: &[]string{"v2"}},
+			valid:       true,
+			validations: []string{"bug target release (v2) matches configured target release for branch (v1, v2)"},
+		},
+		{
+			name:    "not matching any of the target releases means an invalid bug",
+			bug:     bugzilla.Bug{TargetRelease: []string{"v3"}},
+			options: plugins.BugzillaBranchOptions{TargetRelease: &one, TargetReleases: &[]string{"v2"}},
+			valid:   false,
+			why:     []string{"expected the bug to target one of the following releases: \"v1\", \"v2\", but it targets \"v3\" instead"},
+		},
+		{
+			name:    "not setting a target release with target releases configured means an invalid bug",
+			bug:     bugzilla.Bug{},
+			options: plugins.BugzillaBranchOptions{TargetReleases: &[]string{"v1", "v2"}},
+			valid:   false,
+			why:     []string{"expected the bug to target one of the following releases: \"v1\", \"v2\", but no target release was set"},
+		},
 		{
 			name:        "matching status requirement means a valid bug",
 			bug:         bugzilla.Bug{Status: "MODIFIED"},
@@ -1244,6 +3546,20 @@ func TestValidateBug(t *testing.T) {
 			valid:       true,
 			validations: []string{"bug is in the state UPDATED, which is one of the valid states (MODIFIED, UPDATED)"},
 		},
+		{
+			name:        "matching status requirement that overlaps the migrated state lists each state once",
+			bug:         bugzilla.Bug{Status: "MODIFIED"},
+			options:     plugins.BugzillaBranchOptions{ValidStates: &modified, StateAfterValidation: &modified[0]},
+			valid:       true,
+			validations: []string{"bug is in the state MODIFIED, which is one of the valid states (MODIFIED)"},
+		},
+		{
+			name:    "not matching status requirement that overlaps the migrated state lists each state once",
+			bug:     bugzilla.Bug{Status: "NEW"},
+			options: plugins.BugzillaBranchOptions{ValidStates: &[]plugins.BugzillaBugState{{Status: "VERIFIED"}, {Status: "MODIFIED"}}, StateAfterValidation: &modified[0]},
+			valid:   false,
+			why:     []string{"expected the bug to be in one of the following states: VERIFIED, MODIFIED, but it is NEW instead"},
+		},
 		{
 			name:    "not matching status requirement means an invalid bug",
 			bug:     bugzilla.Bug{Status: "MODIFIED"},
@@ -1285,6 +3601,54 @@ func TestValidateBug(t *testing.T) {
 			validations: []string{"bug has dependents"},
 			why:         []string{"expected dependent [Bugzilla bug 1](bugzilla.com/show_bug.cgi?id=1) to target the \"v1\" release, but no target release was set"},
 		},
+		{
+			name:        "dependents failing the target release check for the same reason are reported together",
+			bug:         bugzilla.Bug{DependsOn: []int{1, 2, 3}},
+			dependents:  []bugzilla.Bug{{ID: 1, TargetRelease: []string{"v2"}}, {ID: 2, TargetRelease: []string{"v2"}}, {ID: 3}},
+			options:     plugins.BugzillaBranchOptions{DependentBugTargetRelease: &one},
+			valid:       false,
+			validations: []string{"bug has dependents"},
+			why: []string{
+				`expected dependent bugs [Bugzilla bug 1](bugzilla.com/show_bug.cgi?id=1), [Bugzilla bug 2](bugzilla.com/show_bug.cgi?id=2) to target the "v1" release, but they target "v2" instead`,
+				`expected dependent [Bugzilla bug 3](bugzilla.com/show_bug.cgi?id=3) to target the "v1" release, but no target release was set`,
+			},
+		},
+		{
+			name:        "matching any of the dependent bug target releases means a valid bug",
+			bug:         bugzilla.Bug{DependsOn: []int{1}},
+			dependents:  []bugzilla.Bug{{ID: 1, TargetRelease: []string{"v2"}}},
+			options:     plugins.BugzillaBranchOptions{DependentBugTargetRelease: &one, DependentBugTargetReleases: &[]string{"v2"}},
+			valid:       true,
+			validations: []string{"dependent [Bugzilla bug 1](bugzilla.com/show_bug.cgi?id=1) targets the \"v2\" release, matching the expected (v1, v2) release", "bug has dependents"},
+		},
+		{
+			name:        "not matching any of the dependent bug target releases means an invalid bug",
+			bug:         bugzilla.Bug{DependsOn: []int{1}},
+			dependents:  []bugzilla.Bug{{ID: 1, TargetRelease: []string{"v3"}}},
+			options:     plugins.BugzillaBranchOptions{DependentBugTargetReleases: &[]string{"v1", "v2"}},
+			valid:       false,
+			validations: []string{"bug has dependents"},
+			why:         []string{"expected dependent [Bugzilla bug 1](bugzilla.com/show_bug.cgi?id=1) to target one of the following releases: \"v1\", \"v2\", but it targets \"v3\" instead"},
+		},
+		{
+			name:    "no dependents with dependent bug target releases configured means an invalid bug",
+			bug:     bugzilla.Bug{ID: 123},
+			options: plugins.BugzillaBranchOptions{DependentBugTargetReleases: &[]string{"v1", "v2"}},
+			valid:   false,
+			why:     []string{"expected [Bugzilla bug 123](bugzilla.com/show_bug.cgi?id=123) to depend on a bug targeting one of the following releases: \"v1\", \"v2\", but no dependents were found"},
+		},
+		{
+			name:        "dependents in the same invalid state are reported together",
+			bug:         bugzilla.Bug{DependsOn: []int{1, 2, 3}},
+			dependents:  []bugzilla.Bug{{ID: 1, Status: "MODIFIED"}, {ID: 2, Status: "NEW"}, {ID: 3, Status: "MODIFIED"}},
+			options:     plugins.BugzillaBranchOptions{DependentBugStates: &verified},
+			valid:       false,
+			validations: []string{"bug has dependents"},
+			why: []string{
+				"expected dependent bugs [Bugzilla bug 1](bugzilla.com/show_bug.cgi?id=1), [Bugzilla bug 3](bugzilla.com/show_bug.cgi?id=3) to be in one of the following states: VERIFIED, but they are MODIFIED instead",
+				"expected dependent [Bugzilla bug 2](bugzilla.com/show_bug.cgi?id=2) to be in one of the following states: VERIFIED, but it is NEW instead",
+			},
+		},
 		{
 			name:       "matching all requirements means a valid bug",
 			bug:        bugzilla.Bug{IsOpen: false, TargetRelease: []string{"v1"}, Status: "MODIFIED", DependsOn: []int{1}},
@@ -1342,6 +3706,38 @@ func TestValidateBug(t *testing.T) {
 			valid:       true,
 			validations: []string{"bug is in the state CLOSED (ERRATA), which is one of the valid states (any status with resolution ERRATA)"},
 		},
+		{
+			name:        "closed bug with a valid closed resolution is valid",
+			bug:         bugzilla.Bug{Status: "CLOSED", Resolution: "CURRENTRELEASE"},
+			options:     plugins.BugzillaBranchOptions{ValidClosedResolutions: []string{"ERRATA", "CURRENTRELEASE"}},
+			valid:       true,
+			validations: []string{"bug is in the state CLOSED (CURRENTRELEASE), which is one of the valid states (CLOSED (ERRATA), CLOSED (CURRENTRELEASE))"},
+		},
+		{
+			name:    "closed bug with another resolution is invalid",
+			bug:     bugzilla.Bug{Status: "CLOSED", Resolution: "WONTFIX"},
+			options: plugins.BugzillaBranchOptions{ValidClosedResolutions: []string{"ERRATA", "CURRENTRELEASE"}},
+			valid:   false,
+			why: []string{
+				"expected the bug to be in one of the following states: CLOSED (ERRATA), CLOSED (CURRENTRELEASE), but it is CLOSED (WONTFIX) instead",
+			},
+		},
+		{
+			name:        "valid states are still accepted with valid closed resolutions",
+			bug:         bugzilla.Bug{Status: "MODIFIED"},
+			options:     plugins.BugzillaBranchOptions{ValidStates: &modified, ValidClosedResolutions: []string{"ERRATA"}},
+			valid:       true,
+			validations: []string{"bug is in the state MODIFIED, which is one of the valid states (MODIFIED, CLOSED (ERRATA))"},
+		},
+		{
+			name:    "bug in neither a valid state nor closed with a valid resolution is invalid",
+			bug:     bugzilla.Bug{Status: "NEW"},
+			options: plugins.BugzillaBranchOptions{ValidStates: &modified, ValidClosedResolutions: []string{"ERRATA"}},
+			valid:   false,
+			why: []string{
+				"expected the bug to be in one of the following states: MODIFIED, CLOSED (ERRATA), but it is NEW instead",
+			},
+		},
 		{
 			name:    "matching just resolution means an invalid bug when status does not match",
 			bug:     bugzilla.Bug{Status: "CLOSED", Resolution: "ERRATA"},
@@ -1378,11 +3774,217 @@ func TestValidateBug(t *testing.T) {
 			valid:       true,
 			validations: []string{"dependent bug [Bugzilla bug 1](bugzilla.com/show_bug.cgi?id=1) is in the state CLOSED (ERRATA), which is one of the valid states (CLOSED (ERRATA))", "bug has dependents"},
 		},
+		{
+			name:        "summary matching the required expression means a valid bug",
+			bug:         bugzilla.Bug{Summary: "[Networking] pods cannot reach the service"},
+			options:     plugins.BugzillaBranchOptions{SummaryMustMatch: &summaryPrefix},
+			valid:       true,
+			validations: []string{`bug summary matches the required regular expression (^\[[^\]]+\] )`},
+		},
+		{
+			name:    "summary not matching the required expression means an invalid bug",
+			bug:     bugzilla.Bug{Summary: "pods cannot reach the service"},
+			options: plugins.BugzillaBranchOptions{SummaryMustMatch: &summaryPrefix},
+			valid:   false,
+			why:     []string{`expected the bug summary to match the regular expression "^\\[[^\\]]+\\] ", but it is "pods cannot reach the service" instead; update the summary of the bug in Bugzilla to conform`},
+		},
+		{
+			name:        "exactly the required number of dependent bugs means a valid bug",
+			bug:         bugzilla.Bug{DependsOn: []int{1}},
+			options:     plugins.BugzillaBranchOptions{MinDependentBugs: &oneBug, MaxDependentBugs: &oneBug},
+			valid:       true,
+			validations: []string{"bug depends on 1 bug(s), matching the expected number (exactly 1)"},
+		},
+		{
+			name:    "too few dependent bugs means an invalid bug",
+			bug:     bugzilla.Bug{ID: 123},
+			options: plugins.BugzillaBranchOptions{MinDependentBugs: &oneBug},
+			valid:   false,
+			why:     []string{"expected [Bugzilla bug 123](bugzilla.com/show_bug.cgi?id=123) to depend on at least 1 bug(s), but it depends on 0"},
+		},
+		{
+			name:    "too many dependent bugs means an invalid bug",
+			bug:     bugzilla.Bug{ID: 123, DependsOn: []int{1, 2, 3}},
+			options: plugins.BugzillaBranchOptions{MinDependentBugs: &oneBug, MaxDependentBugs: &twoBugs},
+			valid:   false,
+			why:     []string{"expected [Bugzilla bug 123](bugzilla.com/show_bug.cgi?id=123) to depend on between 1 and 2 bug(s), but it depends on 3"},
+		},
+		{
+			name:        "dependent bug count replaces the generic dependents validation",
+			bug:         bugzilla.Bug{DependsOn: []int{1}},
+			dependents:  []bugzilla.Bug{{ID: 1, Status: "VERIFIED"}},
+			options:     plugins.BugzillaBranchOptions{DependentBugStates: &verified, MaxDependentBugs: &oneBug},
+			valid:       true,
+			validations: []string{"dependent bug [Bugzilla bug 1](bugzilla.com/show_bug.cgi?id=1) is in the state VERIFIED, which is one of the valid states (VERIFIED)", "bug depends on 1 bug(s), matching the expected number (at most 1)"},
+		},
+		{
+			name:        "dependent bug count follows the depends on direction",
+			bug:         bugzilla.Bug{DependsOn: []int{1}, Blocks: []int{2, 3}},
+			options:     plugins.BugzillaBranchOptions{MaxDependentBugs: &oneBug, DependentDirection: &dependsOn},
+			valid:       true,
+			validations: []string{"bug depends on 1 bug(s), matching the expected number (at most 1)"},
+		},
+		{
+			name:    "dependent bug count follows the blocks direction",
+			bug:     bugzilla.Bug{ID: 123, DependsOn: []int{1}, Blocks: []int{2, 3}},
+			options: plugins.BugzillaBranchOptions{MaxDependentBugs: &oneBug, DependentDirection: &blocks},
+			valid:   false,
+			why:     []string{"expected [Bugzilla bug 123](bugzilla.com/show_bug.cgi?id=123) to depend on at most 1 bug(s), but it depends on 2"},
+		},
+		{
+			name:    "bug in a hard-blocked state means an invalid bug regardless of other options",
+			bug:     bugzilla.Bug{Status: "CLOSED", Resolution: "WONTFIX", IsOpen: false},
+			options: plugins.BugzillaBranchOptions{IsOpen: &closed, HardBlockStatuses: &[]plugins.BugzillaBugState{{Status: "CLOSED", Resolution: "WONTFIX"}}},
+			valid:   false,
+			why:     []string{"the bug is in the CLOSED (WONTFIX) state, which is never automatically validated; a manual override is required to merge this pull request"},
+		},
+		{
+			name:        "bug not in a hard-blocked state is validated as usual",
+			bug:         bugzilla.Bug{Status: "CLOSED", Resolution: "ERRATA", IsOpen: false},
+			options:     plugins.BugzillaBranchOptions{IsOpen: &closed, HardBlockStatuses: &[]plugins.BugzillaBugState{{Status: "CLOSED", Resolution: "WONTFIX"}}},
+			valid:       true,
+			validations: []string{"bug isn't open, matching expected state (not open)"},
+		},
+		{
+			name:        "allowlisted bug is valid regardless of other options",
+			bug:         bugzilla.Bug{ID: 123, Status: "CLOSED", Resolution: "WONTFIX", IsOpen: false, TargetRelease: []string{"v2"}},
+			options:     plugins.BugzillaBranchOptions{IsOpen: &open, TargetRelease: &one, DependentBugStates: &verified, AlwaysValidBugIDs: []int{123}, HardBlockStatuses: &[]plugins.BugzillaBugState{{Status: "CLOSED", Resolution: "WONTFIX"}}},
+			valid:       true,
+			validations: []string{"bug is configured to always be valid, so no other validations were run"},
+		},
+		{
+			name:    "bug not in the allowlist is validated as usual",
+			bug:     bugzilla.Bug{ID: 123, IsOpen: false},
+			options: plugins.BugzillaBranchOptions{IsOpen: &open, AlwaysValidBugIDs: []int{456}},
+			valid:   false,
+			why:     []string{"expected the bug to be open, but it isn't"},
+		},
+		{
+			name:        "bug with a target release when one is required means a valid bug",
+			bug:         bugzilla.Bug{TargetRelease: []string{"v1"}},
+			options:     plugins.BugzillaBranchOptions{RequireTargetReleaseSet: &open},
+			valid:       true,
+			validations: []string{"bug has a target release set (v1)"},
+		},
+		{
+			name:    "bug without a target release when one is required means an invalid bug",
+			bug:     bugzilla.Bug{},
+			options: plugins.BugzillaBranchOptions{RequireTargetReleaseSet: &open},
+			valid:   false,
+			why:     []string{"expected the bug to have a target release set, but no target release was set"},
+		},
+		{
+			name:    "bug with the placeholder target release when one is required means an invalid bug",
+			bug:     bugzilla.Bug{TargetRelease: []string{"---"}},
+			options: plugins.BugzillaBranchOptions{RequireTargetReleaseSet: &open},
+			valid:   false,
+			why:     []string{"expected the bug to have a target release set, but no target release was set"},
+		},
+		{
+			name:    "bug without a target release when one is not required means a valid bug",
+			bug:     bugzilla.Bug{},
+			options: plugins.BugzillaBranchOptions{RequireTargetReleaseSet: &closed},
+			valid:   true,
+		},
+		{
+			name:    "bug without a target release when a specific one is also required is reported once",
+			bug:     bugzilla.Bug{},
+			options: plugins.BugzillaBranchOptions{RequireTargetReleaseSet: &open, TargetRelease: &one},
+			valid:   false,
+			why:     []string{`expected the bug to target the "v1" release, but no target release was set`},
+		},
+		{
+			name:        "bug with the specific target release when one is also required means a valid bug",
+			bug:         bugzilla.Bug{TargetRelease: []string{"v1"}},
+			options:     plugins.BugzillaBranchOptions{RequireTargetReleaseSet: &open, TargetRelease: &one},
+			valid:       true,
+			validations: []string{"bug target release (v1) matches configured target release for branch (v1)"},
+		},
+		{
+			name:        "assigned bug when an assignee is required means a valid bug",
+			bug:         bugzilla.Bug{AssignedTo: "engineer@example.com"},
+			options:     plugins.BugzillaBranchOptions{RequireAssignee: &open},
+			valid:       true,
+			validations: []string{"bug is assigned to engineer@example.com"},
+		},
+		{
+			name:    "unassigned bug when an assignee is required means an invalid bug",
+			bug:     bugzilla.Bug{},
+			options: plugins.BugzillaBranchOptions{RequireAssignee: &open},
+			valid:   false,
+			why:     []string{"expected the bug to be assigned to someone, but it is not assigned; set an assignee in Bugzilla"},
+		},
+		{
+			name:    "bug assigned to the default assignee when an assignee is required means an invalid bug",
+			bug:     bugzilla.Bug{AssignedTo: "nobody@example.com"},
+			options: plugins.BugzillaBranchOptions{RequireAssignee: &open},
+			valid:   false,
+			why:     []string{"expected the bug to be assigned to someone, but it is not assigned; set an assignee in Bugzilla"},
+		},
+		{
+			name:    "unassigned bug when an assignee is not required means a valid bug",
+			bug:     bugzilla.Bug{},
+			options: plugins.BugzillaBranchOptions{RequireAssignee: &closed},
+			valid:   true,
+		},
+		{
+			name:    "assignee is checked independently of the state",
+			bug:     bugzilla.Bug{Status: "NEW"},
+			options: plugins.BugzillaBranchOptions{RequireAssignee: &open, ValidStates: &modified},
+			valid:   false,
+			why: []string{
+				"expected the bug to be in one of the following states: MODIFIED, but it is NEW instead",
+				"expected the bug to be assigned to someone, but it is not assigned; set an assignee in Bugzilla",
+			},
+		},
+		{
+			name:         "component matching the longest prefix of a changed file means a valid bug",
+			bug:          bugzilla.Bug{Component: []string{"Networking"}},
+			changedFiles: []string{"pkg/network/service.go"},
+			options:      plugins.BugzillaBranchOptions{ValidateComponentFromChangedFiles: componentsByPath},
+			valid:        true,
+			validations:  []string{"bug component (Networking) matches one of the components for the files changed in this pull request (Networking)"},
+		},
+		{
+			name:         "component matching any of the changed files means a valid bug",
+			bug:          bugzilla.Bug{Component: []string{"Storage"}},
+			changedFiles: []string{"pkg/network/service.go", "pkg/storage/volume.go"},
+			options:      plugins.BugzillaBranchOptions{ValidateComponentFromChangedFiles: componentsByPath},
+			valid:        true,
+			validations:  []string{"bug component (Storage) matches one of the components for the files changed in this pull request (Networking, Storage)"},
+		},
+		{
+			name:         "changed files not matching any prefix are not validated",
+			bug:          bugzilla.Bug{Component: []string{"Networking"}},
+			changedFiles: []string{"docs/README.md"},
+			options:      plugins.BugzillaBranchOptions{ValidateComponentFromChangedFiles: componentsByPath},
+			valid:        true,
+		},
+		{
+			name:         "component not matching the changed files means an invalid bug",
+			bug:          bugzilla.Bug{Component: []string{"Networking"}},
+			changedFiles: []string{"pkg/util/strings.go"},
+			options:      plugins.BugzillaBranchOptions{ValidateComponentFromChangedFiles: componentsByPath},
+			valid:        false,
+			why:          []string{"expected the bug to be filed against one of the components for the files changed in this pull request (Core), but it is filed against Networking instead"},
+		},
+		{
+			name:         "no component when changed files map to one means an invalid bug",
+			bug:          bugzilla.Bug{},
+			changedFiles: []string{"pkg/storage/volume.go"},
+			options:      plugins.BugzillaBranchOptions{ValidateComponentFromChangedFiles: componentsByPath},
+			valid:        false,
+			why:          []string{"expected the bug to be filed against one of the components for the files changed in this pull request (Storage), but no component was set"},
+		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			valid, validations, why := validateBug(testCase.bug, testCase.dependents, testCase.options, "bugzilla.com")
+			result, err := validateBug(testCase.bug, testCase.dependents, testCase.changedFiles, testCase.options, "bugzilla.com")
+			if err != nil {
+				t.Fatalf("%s: expected no error but got one: %v", testCase.name, err)
+			}
+			valid, validations, why := result.Valid, result.Validations, result.Errors
 			if valid != testCase.valid {
 				t.Errorf("%s: didn't validate bug correctly, expected %t got %t", testCase.name, testCase.valid, valid)
 			}
@@ -1396,11 +3998,80 @@ func TestValidateBug(t *testing.T) {
 	}
 }
 
+func TestValidateBugExported(t *testing.T) {
+	open := true
+	summaryPrefix, badExpression := `^\[[^\]]+\] `, `^\[[^\]+\] `
+	var testCases = []struct {
+		name        string
+		bug         bugzilla.Bug
+		options     plugins.BugzillaBranchOptions
+		expected    ValidationResult
+		expectedErr bool
+	}{
+		{
+			name:     "valid bug has a structured result",
+			bug:      bugzilla.Bug{IsOpen: true, Summary: "[component] fix it"},
+			options:  plugins.BugzillaBranchOptions{IsOpen: &open, SummaryMustMatch: &summaryPrefix},
+			expected: ValidationResult{Valid: true, Validations: []string{"bug is open, matching expected state (open)", `bug summary matches the required regular expression (^\[[^\]]+\] )`}},
+		},
+		{
+			name:     "invalid bug has a structured result",
+			bug:      bugzilla.Bug{IsOpen: false},
+			options:  plugins.BugzillaBranchOptions{IsOpen: &open},
+			expected: ValidationResult{Valid: false, Errors: []string{"expected the bug to be open, but it isn't"}},
+		},
+		{
+			name:     "checks on changed files are skipped",
+			bug:      bugzilla.Bug{Component: []string{"Storage"}},
+			options:  plugins.BugzillaBranchOptions{ValidateComponentFromChangedFiles: map[string]string{"pkg/network/": "Networking"}},
+			expected: ValidationResult{Valid: true},
+		},
+		{
+			name:        "malformed summary expression is an error",
+			bug:         bugzilla.Bug{Summary: "fix it"},
+			options:     plugins.BugzillaBranchOptions{SummaryMustMatch: &badExpression},
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := ValidateBug(testCase.bug, nil, testCase.options, "bugzilla.com")
+			if err == nil && testCase.expectedErr {
+				t.Errorf("%s: expected an error but got none", testCase.name)
+			}
+			if err != nil && !testCase.expectedErr {
+				t.Errorf("%s: expected no error but got one: %v", testCase.name, err)
+			}
+			if !reflect.DeepEqual(result, testCase.expected) {
+				t.Errorf("%s: got incorrect result: %v", testCase.name, diff.ObjectReflectDiff(testCase.expected, result))
+			}
+		})
+	}
+}
+
+type fakeUserResolver struct {
+	logins map[string][]string
+	err    error
+}
+
+func (r fakeUserResolver) ResolveEmail(email string) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.logins[email], nil
+}
+
+func (r fakeUserResolver) WithContext(ctx context.Context) userResolver {
+	return r
+}
+
 func TestProcessQuery(t *testing.T) {
 	var testCases = []struct {
 		name     string
 		query    emailToLoginQuery
 		email    string
+		cc       bool
 		expected string
 	}{
 		{
@@ -1448,11 +4119,37 @@ func TestProcessQuery(t *testing.T) {
 			},
 			email:    "qa_tester@example.com",
 			expected: "Multiple GitHub users were found matching the public email listed for the QA contact in Bugzilla (qa_tester@example.com), skipping assignment. List of users with matching email:\n\t- Login1\n\t- Login2",
+		}, {
+			name: "single login with cc returns cc",
+			query: emailToLoginQuery{
+				Search: querySearch{
+					Edges: []queryEdge{{
+						Node: queryNode{
+							User: queryUser{
+								Login: "ValidLogin",
+							},
+						},
+					}},
+				},
+			},
+			email:    "qa_tester@example.com",
+			cc:       true,
+			expected: "Requesting review from QA contact:\n/cc @ValidLogin",
+		}, {
+			name: "no login with cc returns not found error",
+			query: emailToLoginQuery{
+				Search: querySearch{
+					Edges: []queryEdge{},
+				},
+			},
+			email:    "qa_tester@example.com",
+			cc:       true,
+			expected: "No GitHub users were found matching the public email listed for the QA contact in Bugzilla (qa_tester@example.com), skipping review request.",
 		},
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			response := processQuery(&testCase.query, testCase.email, logrus.WithField("testCase", testCase.name))
+			response := processLogins(loginsFromQuery(&testCase.query), testCase.email, "QA contact", testCase.cc, logrus.WithField("testCase", testCase.name))
 			if response != testCase.expected {
 				t.Errorf("%s: Expected \"%s\", got \"%s\"", testCase.name, testCase.expected, response)
 			}
@@ -19,11 +19,14 @@ package plugins
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"path"
+	"reflect"
 	"regexp"
 	"sort"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -1077,6 +1080,103 @@ func validateProjectManager(pm ProjectManager) error {
 	return nil
 }
 
+func validateBugzilla(b Bugzilla) error {
+	validateBranches := func(prefix string, branches map[string]BugzillaBranchOptions) error {
+		for branch, options := range branches {
+			if options.MinDependentBugs != nil && *options.MinDependentBugs < 0 {
+				return fmt.Errorf("%s branch %q: min_dependent_bugs must not be negative, got %d", prefix, branch, *options.MinDependentBugs)
+			}
+			if options.MaxDependentBugs != nil && *options.MaxDependentBugs < 0 {
+				return fmt.Errorf("%s branch %q: max_dependent_bugs must not be negative, got %d", prefix, branch, *options.MaxDependentBugs)
+			}
+			if options.MinDependentBugs != nil && options.MaxDependentBugs != nil && *options.MinDependentBugs > *options.MaxDependentBugs {
+				return fmt.Errorf("%s branch %q: min_dependent_bugs (%d) must not be greater than max_dependent_bugs (%d)", prefix, branch, *options.MinDependentBugs, *options.MaxDependentBugs)
+			}
+			if options.BugRetries != nil && *options.BugRetries < 0 {
+				return fmt.Errorf("%s branch %q: bug_retries must not be negative, got %d", prefix, branch, *options.BugRetries)
+			}
+			if options.ValidLabel != nil && *options.ValidLabel == "" {
+				return fmt.Errorf("%s branch %q: valid_label must not be empty", prefix, branch)
+			}
+			if options.InvalidLabel != nil && *options.InvalidLabel == "" {
+				return fmt.Errorf("%s branch %q: invalid_label must not be empty", prefix, branch)
+			}
+			for _, id := range options.AlwaysValidBugIDs {
+				if id < 1 {
+					return fmt.Errorf("%s branch %q: always_valid_bug_ids must only contain positive IDs, got %d", prefix, branch, id)
+				}
+			}
+			if options.StatusContext != nil && *options.StatusContext == "" {
+				return fmt.Errorf("%s branch %q: status_context must not be empty", prefix, branch)
+			}
+			if options.ValidLabel != nil && options.InvalidLabel != nil && *options.ValidLabel == *options.InvalidLabel {
+				return fmt.Errorf("%s branch %q: valid_label and invalid_label must differ, both are %q", prefix, branch, *options.ValidLabel)
+			}
+			if options.PersistentInvalidThreshold != nil && *options.PersistentInvalidThreshold < 1 {
+				return fmt.Errorf("%s branch %q: persistent_invalid_threshold must be positive, got %d", prefix, branch, *options.PersistentInvalidThreshold)
+			}
+			if options.GracePeriodMinutes != nil && *options.GracePeriodMinutes < 0 {
+				return fmt.Errorf("%s branch %q: grace_period_minutes must not be negative, got %d", prefix, branch, *options.GracePeriodMinutes)
+			}
+			if options.DependentDirection != nil && *options.DependentDirection != BugzillaDependentDirectionDependsOn && *options.DependentDirection != BugzillaDependentDirectionBlocks {
+				return fmt.Errorf("%s branch %q: dependent_direction must be %q or %q, got %q", prefix, branch, BugzillaDependentDirectionDependsOn, BugzillaDependentDirectionBlocks, *options.DependentDirection)
+			}
+			for _, resolution := range options.ValidClosedResolutions {
+				if resolution == "" {
+					return fmt.Errorf("%s branch %q: valid_closed_resolutions must not contain empty resolutions", prefix, branch)
+				}
+			}
+			if options.SummaryMustMatch != nil {
+				if _, err := regexp.Compile(*options.SummaryMustMatch); err != nil {
+					return fmt.Errorf("%s branch %q: failed to compile summary_must_match regexp: %q, error: %v", prefix, branch, *options.SummaryMustMatch, err)
+				}
+			}
+			if options.DependentBugEndpoint != nil {
+				if _, err := url.ParseRequestURI(*options.DependentBugEndpoint); err != nil {
+					return fmt.Errorf("%s branch %q: invalid dependent_bug_endpoint URI: %q", prefix, branch, *options.DependentBugEndpoint)
+				}
+			}
+			if options.Timeout != nil {
+				if timeout, err := time.ParseDuration(*options.Timeout); err != nil {
+					return fmt.Errorf("%s branch %q: failed to parse timeout: %v", prefix, branch, err)
+				} else if timeout <= 0 {
+					return fmt.Errorf("%s branch %q: timeout must be positive, not %s", prefix, branch, *options.Timeout)
+				}
+			}
+			if options.ValidCommentTemplate != nil {
+				if _, err := template.New("valid").Parse(*options.ValidCommentTemplate); err != nil {
+					return fmt.Errorf("%s branch %q: failed to parse valid_comment_template: %v", prefix, branch, err)
+				}
+			}
+			if options.InvalidCommentTemplate != nil {
+				if _, err := template.New("invalid").Parse(*options.InvalidCommentTemplate); err != nil {
+					return fmt.Errorf("%s branch %q: failed to parse invalid_comment_template: %v", prefix, branch, err)
+				}
+			}
+			for path, component := range options.ValidateComponentFromChangedFiles {
+				if path == "" || component == "" {
+					return fmt.Errorf("%s branch %q: validate_component_from_changed_files must map non-empty paths to non-empty components, got %q: %q", prefix, branch, path, component)
+				}
+			}
+		}
+		return nil
+	}
+	if err := validateBranches("bugzilla default", b.Default); err != nil {
+		return err
+	}
+	for org, orgOptions := range b.Orgs {
+		if err := validateBranches(fmt.Sprintf("bugzilla org %q", org), orgOptions.Default); err != nil {
+			return err
+		}
+		for repo, repoOptions := range orgOptions.Repos {
+			if err := validateBranches(fmt.Sprintf("bugzilla repo %s/%s", org, repo), repoOptions.Branches); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 var warnTriggerTrustedOrg time.Time
 
 func validateTrigger(triggers []Trigger) error {
@@ -1161,6 +1261,9 @@ func (c *Configuration) Validate() error {
 	if err := validateTrigger(c.Triggers); err != nil {
 		return err
 	}
+	if err := validateBugzilla(c.Bugzilla); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -1297,15 +1400,27 @@ type BugzillaBranchOptions struct {
 	// ValidateByDefault determines whether a validation check is run for all pull
 	// requests by default
 	ValidateByDefault *bool `json:"validate_by_default,omitempty"`
+	// ExcludedLogins are GitHub logins, like those of bots, for which pull requests
+	// and commands are ignored entirely. Matching is case-insensitive.
+	ExcludedLogins []string `json:"excluded_logins,omitempty"`
 
 	// IsOpen determines whether a bug needs to be open to be valid
 	IsOpen *bool `json:"is_open,omitempty"`
 	// TargetRelease determines which release a bug needs to target to be valid
 	TargetRelease *string `json:"target_release,omitempty"`
+	// TargetReleases determine releases a bug may target to be valid, in addition
+	// to TargetRelease. A bug targeting any one of them is valid.
+	TargetReleases *[]string `json:"target_releases,omitempty"`
 	// Statuses determine which statuses a bug may have to be valid
 	Statuses *[]string `json:"statuses,omitempty"`
 	// ValidStates determine states in which the bug may be to be valid
 	ValidStates *[]BugzillaBugState `json:"valid_states,omitempty"`
+	// ValidClosedResolutions determine resolutions with which a CLOSED bug is
+	// valid, regardless of the resolutions listed in ValidStates, so that closed
+	// bugs do not need a state for every accepted resolution. A bug is in a valid
+	// state if it matches ValidStates or is CLOSED with one of these resolutions;
+	// if ValidStates is unset, only such CLOSED bugs are in a valid state.
+	ValidClosedResolutions []string `json:"valid_closed_resolutions,omitempty"`
 
 	// DependentBugStatuses determine which statuses a bug's dependent bugs may have
 	// to deem the child bug valid.  These are merged into DependentBugStates when
@@ -1318,6 +1433,10 @@ type BugzillaBranchOptions struct {
 	// need to target to be valid.  If set, all blockers must have a valid target
 	// releasee.
 	DependentBugTargetRelease *string `json:"dependent_bug_target_release,omitempty"`
+	// DependentBugTargetReleases determine releases a bug's dependent bugs may
+	// target to be valid, in addition to DependentBugTargetRelease. A dependent
+	// targeting any one of them is valid.
+	DependentBugTargetReleases *[]string `json:"dependent_bug_target_releases,omitempty"`
 
 	// StatusAfterValidation is the status which the bug will be moved to after being
 	// deemed valid and linked to a PR. Will implicitly be considered a part of `statuses`
@@ -1336,6 +1455,164 @@ type BugzillaBranchOptions struct {
 	// StateAfterMerge is the state to which the bug will be moved after all pull requests
 	// in the external bug tracker have been merged.
 	StateAfterMerge *BugzillaBugState `json:"state_after_merge,omitempty"`
+
+	// SummaryMustMatch is a regular expression that the bug's summary must match
+	// for the bug to be valid, e.g. `^\[[^\]]+\] ` to require a component prefix.
+	SummaryMustMatch *string `json:"summary_must_match,omitempty"`
+
+	// WarnOnUnassignedBug determines whether a warning is added to the comment for
+	// a valid bug that has no assignee. This does not affect the validity of the bug.
+	WarnOnUnassignedBug *bool `json:"warn_on_unassigned_bug,omitempty"`
+
+	// BugRetries is the number of times fetching a bug will be retried, with
+	// exponential backoff, when the Bugzilla server responds with a transient
+	// error, like when rate limiting requests. Defaults to no retries.
+	BugRetries *int `json:"bug_retries,omitempty"`
+
+	// ValidateComponentFromChangedFiles maps path prefixes in the repository to
+	// Bugzilla components. If set, the bug must be filed against a component that
+	// the files changed in the pull request map to. The longest matching prefix
+	// is used for every changed file and files that match no prefix are ignored.
+	ValidateComponentFromChangedFiles map[string]string `json:"validate_component_from_changed_files,omitempty"`
+
+	// RequireAssignee determines whether a bug needs to be assigned to someone
+	// to be valid. The default assignee of a component does not count.
+	RequireAssignee *bool `json:"require_assignee,omitempty"`
+
+	// RequireTargetReleaseSet determines whether a bug needs to have any target release
+	// set to be valid. This is implied when TargetRelease or TargetReleases is set.
+	RequireTargetReleaseSet *bool `json:"require_target_release_set,omitempty"`
+
+	// HardBlockStatuses determine states in which a bug is never valid, regardless
+	// of any other options, so that a manual override is required to merge.
+	HardBlockStatuses *[]BugzillaBugState `json:"hard_block_statuses,omitempty"`
+
+	// MinDependentBugs is the minimum number of bugs a bug must depend on to be valid
+	MinDependentBugs *int `json:"min_dependent_bugs,omitempty"`
+	// MaxDependentBugs is the maximum number of bugs a bug may depend on to be valid
+	MaxDependentBugs *int `json:"max_dependent_bugs,omitempty"`
+
+	// RequiredFlags are the names of Bugzilla flags that must be set with a "+"
+	// status for a bug to be valid, e.g. "qe_test_coverage"
+	RequiredFlags []string `json:"required_flags,omitempty"`
+
+	// AlwaysValidBugIDs are the IDs of bugs that are always valid, like bugs that
+	// track work on CI infrastructure. Referencing one of them bypasses all other
+	// conditions on bugs, including hard-blocking states, dependent bugs and the
+	// checks of the assignee.
+	AlwaysValidBugIDs []int `json:"always_valid_bug_ids,omitempty"`
+
+	// RequireActiveAssignee determines whether the assignee of a bug needs to be an
+	// active member of the GitHub organization for the bug to be valid. Assignees that
+	// cannot be mapped to a GitHub user are not checked.
+	RequireActiveAssignee *bool `json:"require_active_assignee,omitempty"`
+
+	// RequireAuthorIsAssignee determines whether the assignee of a bug needs to be
+	// the author of the pull request for the bug to be valid. The assignee's email
+	// is mapped to GitHub users through their public emails, so the author needs
+	// to list it publicly.
+	RequireAuthorIsAssignee *bool `json:"require_author_is_assignee,omitempty"`
+
+	// ValidCommentTemplate is a Go text/template used to open the comment made when
+	// a bug is valid, receiving a BugzillaCommentTemplateData. Notes on updates made
+	// to the bug and the list of validations run are appended after it.
+	ValidCommentTemplate *string `json:"valid_comment_template,omitempty"`
+
+	// InvalidCommentTemplate is a Go text/template used for the comment made when a
+	// bug is invalid, receiving a BugzillaCommentTemplateData.
+	InvalidCommentTemplate *string `json:"invalid_comment_template,omitempty"`
+
+	// DependentBugEndpoint is the address of a separate Bugzilla server that holds
+	// the bugs a bug depends on, for split upstream and downstream trackers. Dependent
+	// bugs are fetched from it anonymously. If unset, dependents are fetched from the
+	// same server as the bug.
+	DependentBugEndpoint *string `json:"dependent_bug_endpoint,omitempty"`
+
+	// Timeout bounds the time spent talking to Bugzilla while handling a single
+	// event, given as a duration string like "30s". If unset, a default of two
+	// minutes is used.
+	Timeout *string `json:"timeout,omitempty"`
+
+	// NotifyBugOnPersistentInvalid determines whether the plugin comments on a bug
+	// once, to prompt triage, when a pull request referencing it fails validation
+	// repeatedly.
+	NotifyBugOnPersistentInvalid *bool `json:"notify_bug_on_persistent_invalid,omitempty"`
+
+	// PersistentInvalidThreshold is the number of consecutive failed validations
+	// of a bug after which the bug is commented on, if NotifyBugOnPersistentInvalid
+	// is set. Defaults to 3.
+	PersistentInvalidThreshold *int `json:"persistent_invalid_threshold,omitempty"`
+
+	// ValidLabel is the label added to pull requests that reference a valid bug.
+	// Defaults to bugzilla/valid-bug.
+	ValidLabel *string `json:"valid_label,omitempty"`
+
+	// InvalidLabel is the label added to pull requests that reference an invalid bug.
+	// Defaults to bugzilla/invalid-bug.
+	InvalidLabel *string `json:"invalid_label,omitempty"`
+
+	// AssignReporterIfNoQA determines whether /bugzilla assign-qa assigns the
+	// bug reporter for review when the bug has no QA contact.
+	AssignReporterIfNoQA *bool `json:"assign_reporter_if_no_qa,omitempty"`
+
+	// GracePeriodMinutes is the age, in minutes, below which pull requests that
+	// do not reference a bug are neither commented on nor labeled, to give authors
+	// of new pull requests time to add a reference. Defaults to no grace period.
+	GracePeriodMinutes *int `json:"grace_period_minutes,omitempty"`
+
+	// DependentDirection determines which related bugs are validated as the bug's
+	// dependents: the bugs it depends on ("dependson") or the bugs it blocks
+	// ("blocks"). Defaults to "dependson".
+	DependentDirection *string `json:"dependent_direction,omitempty"`
+
+	// AddCommentOnMerge determines whether the plugin comments on the bug, linking
+	// to the pull request that merged, when the bug is moved to StateAfterMerge.
+	AddCommentOnMerge *bool `json:"add_comment_on_merge,omitempty"`
+
+	// ReportAllUnmergedPRs determines whether every pull request linked to a bug
+	// is checked when one merges, so that all unmerged pull requests are listed.
+	// By default, checking stops at the first unmerged pull request to save tokens.
+	ReportAllUnmergedPRs *bool `json:"report_all_unmerged_prs,omitempty"`
+
+	// ValidateOnPush determines whether the bug referenced by a pull request is
+	// validated again whenever new commits are pushed to the pull request.
+	ValidateOnPush *bool `json:"validate_on_push,omitempty"`
+
+	// StatusContext is the context of a commit status, e.g. "bugzilla/valid", set
+	// on the head of pull requests to report whether they reference a valid bug,
+	// so that branch protection can gate merges on it. If unset, no status is set.
+	StatusContext *string `json:"status_context,omitempty"`
+
+	// ReportCheckRun determines whether the verdict and the validations run are
+	// reported in a check run named "bugzilla" on the head of pull requests instead
+	// of in a comment. Comments are still made when the check run cannot be created,
+	// e.g. when the plugin is not authenticated as a GitHub App.
+	ReportCheckRun *bool `json:"report_check_run,omitempty"`
+}
+
+const (
+	// BugzillaDependentDirectionDependsOn validates the bugs a bug depends on as its dependents
+	BugzillaDependentDirectionDependsOn = "dependson"
+	// BugzillaDependentDirectionBlocks validates the bugs a bug blocks as its dependents
+	BugzillaDependentDirectionBlocks = "blocks"
+)
+
+// BugzillaCommentTemplateData is the data available to the templates that
+// customize the comments made by the bugzilla plugin
+type BugzillaCommentTemplateData struct {
+	// Bug is the Bugzilla bug referenced by the pull request
+	Bug *bugzilla.Bug
+	// BugLink is a markdown link to the bug on the Bugzilla server
+	BugLink string
+	// Summary is the summary of the bug, shortened to be quoted in a comment
+	Summary string
+	// Endpoint is the address of the Bugzilla server
+	Endpoint string
+	// Validations describe the checks that the bug passed
+	Validations []string
+	// Reasons describe the checks that the bug failed; this is
+	// empty when the bug is valid
+	Reasons []string
 }
 
 type BugzillaBugStateSet map[BugzillaBugState]interface{}
@@ -1395,7 +1672,68 @@ func (o BugzillaBranchOptions) matches(other BugzillaBranchOptions) bool {
 		(o.AddExternalLink != nil && other.AddExternalLink != nil && *o.AddExternalLink == *other.AddExternalLink)
 	statesAfterMergeMatch := o.StateAfterMerge == nil && other.StateAfterMerge == nil ||
 		(o.StateAfterMerge != nil && other.StateAfterMerge != nil && *o.StateAfterMerge == *other.StateAfterMerge)
-	return validateByDefaultMatch && isOpenMatch && targetReleaseMatch && bugStatesMatch && dependentBugStatesMatch && statesAfterValidationMatch && addExternalLinkMatch && statesAfterMergeMatch
+	summaryMustMatchMatch := o.SummaryMustMatch == nil && other.SummaryMustMatch == nil ||
+		(o.SummaryMustMatch != nil && other.SummaryMustMatch != nil && *o.SummaryMustMatch == *other.SummaryMustMatch)
+	warnOnUnassignedBugMatch := o.WarnOnUnassignedBug == nil && other.WarnOnUnassignedBug == nil ||
+		(o.WarnOnUnassignedBug != nil && other.WarnOnUnassignedBug != nil && *o.WarnOnUnassignedBug == *other.WarnOnUnassignedBug)
+	bugRetriesMatch := o.BugRetries == nil && other.BugRetries == nil ||
+		(o.BugRetries != nil && other.BugRetries != nil && *o.BugRetries == *other.BugRetries)
+	excludedLoginsMatch := sets.NewString(o.ExcludedLogins...).Equal(sets.NewString(other.ExcludedLogins...))
+	componentsFromChangedFilesMatch := reflect.DeepEqual(o.ValidateComponentFromChangedFiles, other.ValidateComponentFromChangedFiles)
+	requireAssigneeMatch := o.RequireAssignee == nil && other.RequireAssignee == nil ||
+		(o.RequireAssignee != nil && other.RequireAssignee != nil && *o.RequireAssignee == *other.RequireAssignee)
+	requireTargetReleaseSetMatch := o.RequireTargetReleaseSet == nil && other.RequireTargetReleaseSet == nil ||
+		(o.RequireTargetReleaseSet != nil && other.RequireTargetReleaseSet != nil && *o.RequireTargetReleaseSet == *other.RequireTargetReleaseSet)
+	hardBlockStatusesMatch := o.HardBlockStatuses == nil && other.HardBlockStatuses == nil ||
+		(o.HardBlockStatuses != nil && other.HardBlockStatuses != nil && statesMatch(*o.HardBlockStatuses, *other.HardBlockStatuses))
+	minDependentBugsMatch := o.MinDependentBugs == nil && other.MinDependentBugs == nil ||
+		(o.MinDependentBugs != nil && other.MinDependentBugs != nil && *o.MinDependentBugs == *other.MinDependentBugs)
+	maxDependentBugsMatch := o.MaxDependentBugs == nil && other.MaxDependentBugs == nil ||
+		(o.MaxDependentBugs != nil && other.MaxDependentBugs != nil && *o.MaxDependentBugs == *other.MaxDependentBugs)
+	requiredFlagsMatch := sets.NewString(o.RequiredFlags...).Equal(sets.NewString(other.RequiredFlags...))
+	alwaysValidBugIDsMatch := sets.NewInt(o.AlwaysValidBugIDs...).Equal(sets.NewInt(other.AlwaysValidBugIDs...))
+	reportAllUnmergedPRsMatch := o.ReportAllUnmergedPRs == nil && other.ReportAllUnmergedPRs == nil ||
+		(o.ReportAllUnmergedPRs != nil && other.ReportAllUnmergedPRs != nil && *o.ReportAllUnmergedPRs == *other.ReportAllUnmergedPRs)
+	targetReleasesMatch := o.TargetReleases == nil && other.TargetReleases == nil ||
+		(o.TargetReleases != nil && other.TargetReleases != nil && sets.NewString(*o.TargetReleases...).Equal(sets.NewString(*other.TargetReleases...)))
+	requireActiveAssigneeMatch := o.RequireActiveAssignee == nil && other.RequireActiveAssignee == nil ||
+		(o.RequireActiveAssignee != nil && other.RequireActiveAssignee != nil && *o.RequireActiveAssignee == *other.RequireActiveAssignee)
+	requireAuthorIsAssigneeMatch := o.RequireAuthorIsAssignee == nil && other.RequireAuthorIsAssignee == nil ||
+		(o.RequireAuthorIsAssignee != nil && other.RequireAuthorIsAssignee != nil && *o.RequireAuthorIsAssignee == *other.RequireAuthorIsAssignee)
+	validCommentTemplateMatch := o.ValidCommentTemplate == nil && other.ValidCommentTemplate == nil ||
+		(o.ValidCommentTemplate != nil && other.ValidCommentTemplate != nil && *o.ValidCommentTemplate == *other.ValidCommentTemplate)
+	invalidCommentTemplateMatch := o.InvalidCommentTemplate == nil && other.InvalidCommentTemplate == nil ||
+		(o.InvalidCommentTemplate != nil && other.InvalidCommentTemplate != nil && *o.InvalidCommentTemplate == *other.InvalidCommentTemplate)
+	dependentBugEndpointMatch := o.DependentBugEndpoint == nil && other.DependentBugEndpoint == nil ||
+		(o.DependentBugEndpoint != nil && other.DependentBugEndpoint != nil && *o.DependentBugEndpoint == *other.DependentBugEndpoint)
+	timeoutMatch := o.Timeout == nil && other.Timeout == nil ||
+		(o.Timeout != nil && other.Timeout != nil && *o.Timeout == *other.Timeout)
+	notifyBugOnPersistentInvalidMatch := o.NotifyBugOnPersistentInvalid == nil && other.NotifyBugOnPersistentInvalid == nil ||
+		(o.NotifyBugOnPersistentInvalid != nil && other.NotifyBugOnPersistentInvalid != nil && *o.NotifyBugOnPersistentInvalid == *other.NotifyBugOnPersistentInvalid)
+	persistentInvalidThresholdMatch := o.PersistentInvalidThreshold == nil && other.PersistentInvalidThreshold == nil ||
+		(o.PersistentInvalidThreshold != nil && other.PersistentInvalidThreshold != nil && *o.PersistentInvalidThreshold == *other.PersistentInvalidThreshold)
+	validLabelMatch := o.ValidLabel == nil && other.ValidLabel == nil ||
+		(o.ValidLabel != nil && other.ValidLabel != nil && *o.ValidLabel == *other.ValidLabel)
+	invalidLabelMatch := o.InvalidLabel == nil && other.InvalidLabel == nil ||
+		(o.InvalidLabel != nil && other.InvalidLabel != nil && *o.InvalidLabel == *other.InvalidLabel)
+	assignReporterIfNoQAMatch := o.AssignReporterIfNoQA == nil && other.AssignReporterIfNoQA == nil ||
+		(o.AssignReporterIfNoQA != nil && other.AssignReporterIfNoQA != nil && *o.AssignReporterIfNoQA == *other.AssignReporterIfNoQA)
+	gracePeriodMinutesMatch := o.GracePeriodMinutes == nil && other.GracePeriodMinutes == nil ||
+		(o.GracePeriodMinutes != nil && other.GracePeriodMinutes != nil && *o.GracePeriodMinutes == *other.GracePeriodMinutes)
+	dependentDirectionMatch := o.DependentDirection == nil && other.DependentDirection == nil ||
+		(o.DependentDirection != nil && other.DependentDirection != nil && *o.DependentDirection == *other.DependentDirection)
+	addCommentOnMergeMatch := o.AddCommentOnMerge == nil && other.AddCommentOnMerge == nil ||
+		(o.AddCommentOnMerge != nil && other.AddCommentOnMerge != nil && *o.AddCommentOnMerge == *other.AddCommentOnMerge)
+	validateOnPushMatch := o.ValidateOnPush == nil && other.ValidateOnPush == nil ||
+		(o.ValidateOnPush != nil && other.ValidateOnPush != nil && *o.ValidateOnPush == *other.ValidateOnPush)
+	validClosedResolutionsMatch := sets.NewString(o.ValidClosedResolutions...).Equal(sets.NewString(other.ValidClosedResolutions...))
+	dependentBugTargetReleasesMatch := o.DependentBugTargetReleases == nil && other.DependentBugTargetReleases == nil ||
+		(o.DependentBugTargetReleases != nil && other.DependentBugTargetReleases != nil && sets.NewString(*o.DependentBugTargetReleases...).Equal(sets.NewString(*other.DependentBugTargetReleases...)))
+	statusContextMatch := o.StatusContext == nil && other.StatusContext == nil ||
+		(o.StatusContext != nil && other.StatusContext != nil && *o.StatusContext == *other.StatusContext)
+	reportCheckRunMatch := o.ReportCheckRun == nil && other.ReportCheckRun == nil ||
+		(o.ReportCheckRun != nil && other.ReportCheckRun != nil && *o.ReportCheckRun == *other.ReportCheckRun)
+	return validateByDefaultMatch && excludedLoginsMatch && isOpenMatch && targetReleaseMatch && bugStatesMatch && dependentBugStatesMatch && statesAfterValidationMatch && addExternalLinkMatch && statesAfterMergeMatch && summaryMustMatchMatch && warnOnUnassignedBugMatch && bugRetriesMatch && componentsFromChangedFilesMatch && requireAssigneeMatch && requireTargetReleaseSetMatch && hardBlockStatusesMatch && minDependentBugsMatch && maxDependentBugsMatch && targetReleasesMatch && requiredFlagsMatch && requireActiveAssigneeMatch && validCommentTemplateMatch && invalidCommentTemplateMatch && dependentBugEndpointMatch && timeoutMatch && notifyBugOnPersistentInvalidMatch && persistentInvalidThresholdMatch && validLabelMatch && invalidLabelMatch && assignReporterIfNoQAMatch && gracePeriodMinutesMatch && dependentDirectionMatch && addCommentOnMergeMatch && validateOnPushMatch && validClosedResolutionsMatch && dependentBugTargetReleasesMatch && statusContextMatch && reportCheckRunMatch && requireAuthorIsAssigneeMatch && alwaysValidBugIDsMatch && reportAllUnmergedPRsMatch
 }
 
 const BugzillaOptionsWildcard = `*`
@@ -1444,6 +1782,9 @@ func ResolveBugzillaOptions(parent, child BugzillaBranchOptions) BugzillaBranchO
 		if parent.ValidateByDefault != nil {
 			output.ValidateByDefault = parent.ValidateByDefault
 		}
+		if parent.ExcludedLogins != nil {
+			output.ExcludedLogins = parent.ExcludedLogins
+		}
 		if parent.IsOpen != nil {
 			output.IsOpen = parent.IsOpen
 		}
@@ -1467,6 +1808,9 @@ func ResolveBugzillaOptions(parent, child BugzillaBranchOptions) BugzillaBranchO
 		if parent.DependentBugTargetRelease != nil {
 			output.DependentBugTargetRelease = parent.DependentBugTargetRelease
 		}
+		if parent.DependentBugTargetReleases != nil {
+			output.DependentBugTargetReleases = parent.DependentBugTargetReleases
+		}
 		if parent.StatusAfterValidation != nil {
 			output.StatusAfterValidation = parent.StatusAfterValidation
 			output.StateAfterValidation = &BugzillaBugState{Status: *output.StatusAfterValidation}
@@ -1484,12 +1828,108 @@ func ResolveBugzillaOptions(parent, child BugzillaBranchOptions) BugzillaBranchO
 		if parent.StateAfterMerge != nil {
 			output.StateAfterMerge = parent.StateAfterMerge
 		}
+		if parent.SummaryMustMatch != nil {
+			output.SummaryMustMatch = parent.SummaryMustMatch
+		}
+		if parent.WarnOnUnassignedBug != nil {
+			output.WarnOnUnassignedBug = parent.WarnOnUnassignedBug
+		}
+		if parent.BugRetries != nil {
+			output.BugRetries = parent.BugRetries
+		}
+		if parent.ValidateComponentFromChangedFiles != nil {
+			output.ValidateComponentFromChangedFiles = parent.ValidateComponentFromChangedFiles
+		}
+		if parent.RequireAssignee != nil {
+			output.RequireAssignee = parent.RequireAssignee
+		}
+		if parent.RequireTargetReleaseSet != nil {
+			output.RequireTargetReleaseSet = parent.RequireTargetReleaseSet
+		}
+		if parent.HardBlockStatuses != nil {
+			output.HardBlockStatuses = parent.HardBlockStatuses
+		}
+		if parent.MinDependentBugs != nil {
+			output.MinDependentBugs = parent.MinDependentBugs
+		}
+		if parent.MaxDependentBugs != nil {
+			output.MaxDependentBugs = parent.MaxDependentBugs
+		}
+		if parent.TargetReleases != nil {
+			output.TargetReleases = parent.TargetReleases
+		}
+		if parent.RequiredFlags != nil {
+			output.RequiredFlags = parent.RequiredFlags
+		}
+		if parent.AlwaysValidBugIDs != nil {
+			output.AlwaysValidBugIDs = parent.AlwaysValidBugIDs
+		}
+		if parent.RequireActiveAssignee != nil {
+			output.RequireActiveAssignee = parent.RequireActiveAssignee
+		}
+		if parent.RequireAuthorIsAssignee != nil {
+			output.RequireAuthorIsAssignee = parent.RequireAuthorIsAssignee
+		}
+		if parent.ValidCommentTemplate != nil {
+			output.ValidCommentTemplate = parent.ValidCommentTemplate
+		}
+		if parent.InvalidCommentTemplate != nil {
+			output.InvalidCommentTemplate = parent.InvalidCommentTemplate
+		}
+		if parent.DependentBugEndpoint != nil {
+			output.DependentBugEndpoint = parent.DependentBugEndpoint
+		}
+		if parent.Timeout != nil {
+			output.Timeout = parent.Timeout
+		}
+		if parent.NotifyBugOnPersistentInvalid != nil {
+			output.NotifyBugOnPersistentInvalid = parent.NotifyBugOnPersistentInvalid
+		}
+		if parent.PersistentInvalidThreshold != nil {
+			output.PersistentInvalidThreshold = parent.PersistentInvalidThreshold
+		}
+		if parent.ValidLabel != nil {
+			output.ValidLabel = parent.ValidLabel
+		}
+		if parent.InvalidLabel != nil {
+			output.InvalidLabel = parent.InvalidLabel
+		}
+		if parent.AssignReporterIfNoQA != nil {
+			output.AssignReporterIfNoQA = parent.AssignReporterIfNoQA
+		}
+		if parent.GracePeriodMinutes != nil {
+			output.GracePeriodMinutes = parent.GracePeriodMinutes
+		}
+		if parent.DependentDirection != nil {
+			output.DependentDirection = parent.DependentDirection
+		}
+		if parent.AddCommentOnMerge != nil {
+			output.AddCommentOnMerge = parent.AddCommentOnMerge
+		}
+		if parent.ReportAllUnmergedPRs != nil {
+			output.ReportAllUnmergedPRs = parent.ReportAllUnmergedPRs
+		}
+		if parent.ValidateOnPush != nil {
+			output.ValidateOnPush = parent.ValidateOnPush
+		}
+		if parent.ValidClosedResolutions != nil {
+			output.ValidClosedResolutions = parent.ValidClosedResolutions
+		}
+		if parent.StatusContext != nil {
+			output.StatusContext = parent.StatusContext
+		}
+		if parent.ReportCheckRun != nil {
+			output.ReportCheckRun = parent.ReportCheckRun
+		}
 	}
 
 	// override with the child
 	if child.ValidateByDefault != nil {
 		output.ValidateByDefault = child.ValidateByDefault
 	}
+	if child.ExcludedLogins != nil {
+		output.ExcludedLogins = child.ExcludedLogins
+	}
 	if child.IsOpen != nil {
 		output.IsOpen = child.IsOpen
 	}
@@ -1521,6 +1961,9 @@ func ResolveBugzillaOptions(parent, child BugzillaBranchOptions) BugzillaBranchO
 	if child.DependentBugTargetRelease != nil {
 		output.DependentBugTargetRelease = child.DependentBugTargetRelease
 	}
+	if child.DependentBugTargetReleases != nil {
+		output.DependentBugTargetReleases = child.DependentBugTargetReleases
+	}
 	if child.StatusAfterValidation != nil {
 		output.StatusAfterValidation = child.StatusAfterValidation
 		if child.StateAfterValidation == nil {
@@ -1542,6 +1985,99 @@ func ResolveBugzillaOptions(parent, child BugzillaBranchOptions) BugzillaBranchO
 	if child.StateAfterMerge != nil {
 		output.StateAfterMerge = child.StateAfterMerge
 	}
+	if child.SummaryMustMatch != nil {
+		output.SummaryMustMatch = child.SummaryMustMatch
+	}
+	if child.WarnOnUnassignedBug != nil {
+		output.WarnOnUnassignedBug = child.WarnOnUnassignedBug
+	}
+	if child.BugRetries != nil {
+		output.BugRetries = child.BugRetries
+	}
+	if child.ValidateComponentFromChangedFiles != nil {
+		output.ValidateComponentFromChangedFiles = child.ValidateComponentFromChangedFiles
+	}
+	if child.RequireAssignee != nil {
+		output.RequireAssignee = child.RequireAssignee
+	}
+	if child.RequireTargetReleaseSet != nil {
+		output.RequireTargetReleaseSet = child.RequireTargetReleaseSet
+	}
+	if child.HardBlockStatuses != nil {
+		output.HardBlockStatuses = child.HardBlockStatuses
+	}
+	if child.MinDependentBugs != nil {
+		output.MinDependentBugs = child.MinDependentBugs
+	}
+	if child.MaxDependentBugs != nil {
+		output.MaxDependentBugs = child.MaxDependentBugs
+	}
+	if child.TargetReleases != nil {
+		output.TargetReleases = child.TargetReleases
+	}
+	if child.RequiredFlags != nil {
+		output.RequiredFlags = child.RequiredFlags
+	}
+	if child.AlwaysValidBugIDs != nil {
+		output.AlwaysValidBugIDs = child.AlwaysValidBugIDs
+	}
+	if child.RequireActiveAssignee != nil {
+		output.RequireActiveAssignee = child.RequireActiveAssignee
+	}
+	if child.RequireAuthorIsAssignee != nil {
+		output.RequireAuthorIsAssignee = child.RequireAuthorIsAssignee
+	}
+	if child.ValidCommentTemplate != nil {
+		output.ValidCommentTemplate = child.ValidCommentTemplate
+	}
+	if child.InvalidCommentTemplate != nil {
+		output.InvalidCommentTemplate = child.InvalidCommentTemplate
+	}
+	if child.DependentBugEndpoint != nil {
+		output.DependentBugEndpoint = child.DependentBugEndpoint
+	}
+	if child.Timeout != nil {
+		output.Timeout = child.Timeout
+	}
+	if child.NotifyBugOnPersistentInvalid != nil {
+		output.NotifyBugOnPersistentInvalid = child.NotifyBugOnPersistentInvalid
+	}
+	if child.PersistentInvalidThreshold != nil {
+		output.PersistentInvalidThreshold = child.PersistentInvalidThreshold
+	}
+	if child.ValidLabel != nil {
+		output.ValidLabel = child.ValidLabel
+	}
+	if child.InvalidLabel != nil {
+		output.InvalidLabel = child.InvalidLabel
+	}
+	if child.AssignReporterIfNoQA != nil {
+		output.AssignReporterIfNoQA = child.AssignReporterIfNoQA
+	}
+	if child.GracePeriodMinutes != nil {
+		output.GracePeriodMinutes = child.GracePeriodMinutes
+	}
+	if child.DependentDirection != nil {
+		output.DependentDirection = child.DependentDirection
+	}
+	if child.AddCommentOnMerge != nil {
+		output.AddCommentOnMerge = child.AddCommentOnMerge
+	}
+	if child.ReportAllUnmergedPRs != nil {
+		output.ReportAllUnmergedPRs = child.ReportAllUnmergedPRs
+	}
+	if child.ValidateOnPush != nil {
+		output.ValidateOnPush = child.ValidateOnPush
+	}
+	if child.ValidClosedResolutions != nil {
+		output.ValidClosedResolutions = child.ValidClosedResolutions
+	}
+	if child.StatusContext != nil {
+		output.StatusContext = child.StatusContext
+	}
+	if child.ReportCheckRun != nil {
+		output.ReportCheckRun = child.ReportCheckRun
+	}
 
 	// Status fields should not be used anywhere now when they were mirrored to states
 	output.Statuses = nil
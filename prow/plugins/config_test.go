@@ -488,6 +488,12 @@ func TestResolveBugzillaOptions(t *testing.T) {
 			child:    BugzillaBranchOptions{DependentBugTargetRelease: &two},
 			expected: BugzillaBranchOptions{IsOpen: &open, TargetRelease: &one, ValidStates: &[]BugzillaBugState{modifiedState}, StateAfterValidation: &postState, DependentBugTargetRelease: &two},
 		},
+		{
+			name:     "child overrides parent on dependent bug target releases",
+			parent:   BugzillaBranchOptions{IsOpen: &open, DependentBugTargetReleases: &[]string{"v1"}},
+			child:    BugzillaBranchOptions{DependentBugTargetReleases: &[]string{"v2", "v3"}},
+			expected: BugzillaBranchOptions{IsOpen: &open, DependentBugTargetReleases: &[]string{"v2", "v3"}},
+		},
 		{
 			name:   "child overrides parent on state after merge",
 			parent: BugzillaBranchOptions{IsOpen: &open, TargetRelease: &one, ValidStates: &[]BugzillaBugState{modifiedState}, StateAfterValidation: &postState, StateAfterMerge: &postState},
@@ -1204,3 +1210,279 @@ func TestValidateConfigUpdater(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateBugzilla(t *testing.T) {
+	valid, invalid := `^\[[^\]]+\] `, `^\[[^\]+\] `
+	negative, one, two := -1, 1, 2
+	goodEndpoint, badEndpoint := "https://bugzilla.upstream.org", "not a url"
+	goodTemplate, badTemplate := `Bug {{.Bug.ID}} passed: {{range .Validations}}{{.}}; {{end}}`, `Bug {{.Bug.ID`
+	goodTimeout, badTimeout, zeroTimeout := "30s", "thirty seconds", "0s"
+	validLabel, invalidLabel, emptyLabel := "bug/ok", "bug/not-ok", ""
+	blocks, sideways := BugzillaDependentDirectionBlocks, "sideways"
+	testCases := []struct {
+		name        string
+		config      Bugzilla
+		expectedErr bool
+	}{
+		{
+			name: "no summary expression is valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {}},
+			},
+		},
+		{
+			name: "valid summary expression in defaults is valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {SummaryMustMatch: &valid}},
+			},
+		},
+		{
+			name: "invalid summary expression in defaults is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {SummaryMustMatch: &invalid}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid summary expression in org defaults is invalid",
+			config: Bugzilla{
+				Orgs: map[string]BugzillaOrgOptions{"org": {
+					Default: map[string]BugzillaBranchOptions{"*": {SummaryMustMatch: &invalid}},
+				}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid summary expression for a repo branch is invalid",
+			config: Bugzilla{
+				Orgs: map[string]BugzillaOrgOptions{"org": {
+					Repos: map[string]BugzillaRepoOptions{"repo": {
+						Branches: map[string]BugzillaBranchOptions{"master": {SummaryMustMatch: &invalid}},
+					}},
+				}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "component mapping from changed files is valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {ValidateComponentFromChangedFiles: map[string]string{"pkg/network/": "Networking"}}},
+			},
+		},
+		{
+			name: "component mapping with an empty component is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {ValidateComponentFromChangedFiles: map[string]string{"pkg/network/": ""}}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "component mapping with an empty path is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {ValidateComponentFromChangedFiles: map[string]string{"": "Networking"}}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "dependent bug endpoint URI is valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {DependentBugEndpoint: &goodEndpoint}},
+			},
+		},
+		{
+			name: "malformed dependent bug endpoint is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {DependentBugEndpoint: &badEndpoint}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "parseable timeout is valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {Timeout: &goodTimeout}},
+			},
+		},
+		{
+			name: "unparseable timeout is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {Timeout: &badTimeout}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "zero timeout is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {Timeout: &zeroTimeout}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "parseable comment templates are valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {ValidCommentTemplate: &goodTemplate, InvalidCommentTemplate: &goodTemplate}},
+			},
+		},
+		{
+			name: "unparseable valid comment template is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {ValidCommentTemplate: &badTemplate}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "unparseable invalid comment template in repo branches is invalid",
+			config: Bugzilla{
+				Orgs: map[string]BugzillaOrgOptions{"org": {
+					Repos: map[string]BugzillaRepoOptions{"repo": {
+						Branches: map[string]BugzillaBranchOptions{"*": {InvalidCommentTemplate: &badTemplate}},
+					}},
+				}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "dependent bug bounds are valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {MinDependentBugs: &one, MaxDependentBugs: &one}},
+			},
+		},
+		{
+			name: "negative minimum dependent bugs are invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {MinDependentBugs: &negative}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "minimum dependent bugs above the maximum are invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {MinDependentBugs: &two, MaxDependentBugs: &one}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "negative bug retries are invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {BugRetries: &negative}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "custom labels are valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {ValidLabel: &validLabel, InvalidLabel: &invalidLabel}},
+			},
+		},
+		{
+			name: "empty valid label is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {ValidLabel: &emptyLabel}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "empty invalid label is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {InvalidLabel: &emptyLabel}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "always valid bug IDs are valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {AlwaysValidBugIDs: []int{123, 456}}},
+			},
+		},
+		{
+			name: "non-positive always valid bug ID is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {AlwaysValidBugIDs: []int{123, 0}}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "status context is valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {StatusContext: &validLabel}},
+			},
+		},
+		{
+			name: "empty status context is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {StatusContext: &emptyLabel}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "identical valid and invalid labels are invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {ValidLabel: &validLabel, InvalidLabel: &validLabel}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "positive persistent invalid threshold is valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {PersistentInvalidThreshold: &two}},
+			},
+		},
+		{
+			name: "non-positive persistent invalid threshold is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {PersistentInvalidThreshold: &negative}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "positive grace period is valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {GracePeriodMinutes: &two}},
+			},
+		},
+		{
+			name: "negative grace period is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {GracePeriodMinutes: &negative}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "blocks dependent direction is valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {DependentDirection: &blocks}},
+			},
+		},
+		{
+			name: "unknown dependent direction is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {DependentDirection: &sideways}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "valid closed resolutions are valid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {ValidClosedResolutions: []string{"ERRATA", "CURRENTRELEASE"}}},
+			},
+		},
+		{
+			name: "empty valid closed resolution is invalid",
+			config: Bugzilla{
+				Default: map[string]BugzillaBranchOptions{"*": {ValidClosedResolutions: []string{"ERRATA", ""}}},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateBugzilla(tc.config)
+			if tc.expectedErr && err == nil {
+				t.Error("expected an error, but got none")
+			}
+			if !tc.expectedErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -35,6 +36,7 @@ import (
 
 	"cloud.google.com/go/storage"
 	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
 )
 
 type multiString []string
@@ -48,6 +50,20 @@ func (m *multiString) Set(v string) error {
 	return nil
 }
 
+// configuratorDefaults holds the settings in the default YAML that only
+// Configurator uses, next to the testgrid defaults.
+type configuratorDefaults struct {
+	// DefaultDashboard is a dashboard that jobs are added to when they get a
+	// test group but have no testgrid-dashboards annotation. Unset by default.
+	DefaultDashboard string `json:"default_dashboard,omitempty"`
+	// MinPresubmitNumColumnsRecent overrides the minimum num_columns_recent of
+	// test groups created for presubmits, which is 20 by default.
+	MinPresubmitNumColumnsRecent *int32 `json:"min_presubmit_num_columns_recent,omitempty"`
+	// MinPostsubmitNumColumnsRecent overrides the minimum num_columns_recent of
+	// test groups created for postsubmits, which is 10 by default.
+	MinPostsubmitNumColumnsRecent *int32 `json:"min_postsubmit_num_columns_recent,omitempty"`
+}
+
 // How long Configurator waits between file checks in polling mode
 const pollingTime = time.Second
 
@@ -63,6 +79,9 @@ type options struct {
 	prowConfig         string
 	prowJobConfig      string
 	defaultYAML        string
+	warningsOutput     string
+	blockingDashboard  string
+	sortTabs           bool
 }
 
 func (o *options) gatherOptions(fs *flag.FlagSet, args []string) error {
@@ -77,6 +96,9 @@ func (o *options) gatherOptions(fs *flag.FlagSet, args []string) error {
 	fs.StringVar(&o.prowConfig, "prow-config", "", "path to the prow config file. Required by --prow-job-config")
 	fs.StringVar(&o.prowJobConfig, "prow-job-config", "", "path to the prow job config. If specified, incorporates testgrid annotations on prowjobs. Requires --prow-config.")
 	fs.StringVar(&o.defaultYAML, "default", "", "path to default settings; required for proto outputs")
+	fs.StringVar(&o.warningsOutput, "annotation-warnings-output", "", "write warnings about under-specified prow jobs as JSON to gs://bucket/obj or /local/path. Requires --prow-job-config.")
+	fs.StringVar(&o.blockingDashboard, "release-blocking-dashboard", "", "if set, collects every release-blocking tab on the dashboard with this name, creating it if needed")
+	fs.BoolVar(&o.sortTabs, "sort-tabs", false, "sort the tabs of every dashboard by name after applying prow job annotations, including hand-authored tabs")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -94,6 +116,12 @@ func (o *options) gatherOptions(fs *flag.FlagSet, args []string) error {
 	if (o.prowConfig == "") != (o.prowJobConfig == "") {
 		return errors.New("--prow-config and --prow-job-config must be specified together")
 	}
+	if o.warningsOutput != "" && o.prowJobConfig == "" {
+		return errors.New("--annotation-warnings-output requires --prow-job-config")
+	}
+	if o.validateConfigFile && strings.HasPrefix(o.warningsOutput, "gs://") {
+		return errors.New("--validate-config-file can only write --annotation-warnings-output to a local path")
+	}
 	if o.defaultYAML == "" && !o.writeYAML {
 		logrus.Warnf("--default not explicitly specified; assuming %s", o.inputs[0])
 		o.defaultYAML = o.inputs[0]
@@ -196,6 +224,7 @@ func doOneshot(ctx context.Context, client *storage.Client, opt options, prowCon
 
 	// Remains nil if no default YAML
 	var d *yamlcfg.DefaultConfiguration
+	var cd configuratorDefaults
 	if opt.defaultYAML != "" {
 		b, err := ioutil.ReadFile(opt.defaultYAML)
 		if err != nil {
@@ -206,12 +235,35 @@ func doOneshot(ctx context.Context, client *storage.Client, opt options, prowCon
 			return err
 		}
 		d = &val
-
+		if err := yaml.Unmarshal(b, &cd); err != nil {
+			return fmt.Errorf("could not read configurator defaults: %v", err)
+		}
 	}
 
-	if err := applyProwjobAnnotations(&c, d, prowConfigAgent); err != nil {
+	warnings, err := applyProwjobAnnotations(&c, d, cd, prowConfigAgent)
+	if err != nil {
 		return fmt.Errorf("could not apply prowjob annotations: %v", err)
 	}
+	if len(warnings) > 0 {
+		logrus.Infof("Found %d warnings while applying prowjob annotations", len(warnings))
+	}
+	if opt.sortTabs {
+		sortDashboardTabs(&c)
+	}
+	if opt.blockingDashboard != "" {
+		if err := aggregateReleaseBlockingTabs(&c, opt.blockingDashboard, releaseBlockingTestGroups(prowConfigAgent.Config())); err != nil {
+			return fmt.Errorf("could not aggregate release-blocking tabs: %v", err)
+		}
+	}
+	if opt.warningsOutput != "" {
+		b, err := json.MarshalIndent(warnings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("could not marshal annotation warnings: %v", err)
+		}
+		if err := write(ctx, client, opt.warningsOutput, b, opt.worldReadable, ""); err != nil {
+			return fmt.Errorf("could not write annotation warnings: %v", err)
+		}
+	}
 
 	// Print proto if requested
 	if opt.printText {
@@ -272,7 +324,7 @@ func main() {
 
 	// Set up GCS client if output is to GCS
 	var client *storage.Client
-	if strings.HasPrefix(opt.output, "gs://") {
+	if strings.HasPrefix(opt.output, "gs://") || strings.HasPrefix(opt.warningsOutput, "gs://") {
 		var err error
 		var creds []string
 		if opt.creds != "" {
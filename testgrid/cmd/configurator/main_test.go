@@ -68,6 +68,46 @@ func Test_Options(t *testing.T) {
 			name: "--validate-config-file with output: fails",
 			args: []string{"--yaml=file.yaml", "--validate-config-file", "--output=/foo/bar"},
 		},
+		{
+			name: "Annotation warnings with prow jobs",
+			args: []string{"--yaml=file.yaml", "--output=/foo/bar", "--prow-config=/prow/config", "--prow-job-config=/prow/jobs", "--annotation-warnings-output=/foo/warnings.json"},
+			expected: &options{
+				inputs:         []string{"file.yaml"},
+				defaultYAML:    "file.yaml",
+				output:         "/foo/bar",
+				prowConfig:     "/prow/config",
+				prowJobConfig:  "/prow/jobs",
+				warningsOutput: "/foo/warnings.json",
+			},
+		},
+		{
+			name: "Release-blocking dashboard",
+			args: []string{"--yaml=file.yaml", "--output=/foo/bar", "--release-blocking-dashboard=release-blocking"},
+			expected: &options{
+				inputs:            []string{"file.yaml"},
+				defaultYAML:       "file.yaml",
+				output:            "/foo/bar",
+				blockingDashboard: "release-blocking",
+			},
+		},
+		{
+			name: "Sort tabs",
+			args: []string{"--yaml=file.yaml", "--output=/foo/bar", "--sort-tabs"},
+			expected: &options{
+				inputs:      []string{"file.yaml"},
+				defaultYAML: "file.yaml",
+				output:      "/foo/bar",
+				sortTabs:    true,
+			},
+		},
+		{
+			name: "Annotation warnings without prow jobs: fails",
+			args: []string{"--yaml=file.yaml", "--output=/foo/bar", "--annotation-warnings-output=/foo/warnings.json"},
+		},
+		{
+			name: "Annotation warnings to GCS while validating: fails",
+			args: []string{"--yaml=file.yaml", "--validate-config-file", "--prow-config=/prow/config", "--prow-job-config=/prow/jobs", "--annotation-warnings-output=gs://foo/warnings.json"},
+		},
 		{
 			name: "Prow jobs with no root config: fails",
 			args: []string{"--yaml=file.yaml", "--output=/foo/bar", "--prow-job-config=/prow/jobs"},
@@ -18,14 +18,20 @@ package main
 
 import (
 	"fmt"
+	"net/mail"
 	"path"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 
 	"github.com/GoogleCloudPlatform/testgrid/config"
 	"github.com/GoogleCloudPlatform/testgrid/config/yamlcfg"
 	configpb "github.com/GoogleCloudPlatform/testgrid/pb/config"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
 
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	prowConfig "k8s.io/test-infra/prow/config"
@@ -34,18 +40,82 @@ import (
 )
 
 const testgridCreateTestGroupAnnotation = "testgrid-create-test-group"
+const testgridDisableAnnotation = "testgrid-disable"
 const testgridDashboardsAnnotation = "testgrid-dashboards"
+const testgridDashboardsExcludeAnnotation = "testgrid-dashboards-exclude"
 const testgridTabNameAnnotation = "testgrid-tab-name"
 const testgridEmailAnnotation = "testgrid-alert-email"
+const testgridEmailAllTabsAnnotation = "testgrid-alert-email-all-tabs"
 const testgridNumColumnsRecentAnnotation = "testgrid-num-columns-recent"
+const testgridDaysOfResultsAnnotation = "testgrid-days-of-results"
+const testgridColumnHeaderAnnotation = "testgrid-column-header"
 const testgridAlertStaleResultsHoursAnnotation = "testgrid-alert-stale-results-hours"
+const testgridTabAlertStaleResultsHoursAnnotation = "testgrid-tab-alert-stale-results-hours"
 const testgridNumFailuresToAlertAnnotation = "testgrid-num-failures-to-alert"
+const testgridTabBrokenThresholdAnnotation = "testgrid-tab-broken-threshold"
+const testgridResultsTextAnnotation = "testgrid-results-text"
+const testgridResultsURLTemplateAnnotation = "testgrid-results-url-template"
+const testgridReleaseBlockingAnnotation = "testgrid-release-blocking"
+const testgridBaseOptionsAnnotation = "testgrid-base-options"
+const testgridCodeSearchURLAnnotation = "testgrid-code-search-url"
+const testgridOpenBugURLAnnotation = "testgrid-open-bug-url"
+const testgridGCSPrefixAnnotation = "testgrid-gcs-prefix"
+const testgridIgnorePendingAnnotation = "testgrid-ignore-pending"
+const testgridNumPassesToDisableAlertAnnotation = "testgrid-num-passes-to-disable-alert"
+const testgridMaxTestMethodsAnnotation = "testgrid-max-test-methods"
+const releaseBlockingDescriptionPrefix = "[release-blocking] "
 const descriptionAnnotation = "description"
 const minPresubmitNumColumnsRecent = 20
+const minPostsubmitNumColumnsRecent = 10
 
 // Talk to @michelle192837 if you're thinking about adding more of these!
 
-func applySingleProwjobAnnotations(c *configpb.Configuration, pc *prowConfig.Config, j prowConfig.JobBase, jobType prowapi.ProwJobType, repo string, dc *yamlcfg.DefaultConfiguration) error {
+// testGroupAnnotations are the annotations that only make sense if the job has a test group.
+var testGroupAnnotations = []string{testgridNumColumnsRecentAnnotation, testgridDaysOfResultsAnnotation, testgridColumnHeaderAnnotation, testgridAlertStaleResultsHoursAnnotation,
+	testgridNumFailuresToAlertAnnotation, testgridTabNameAnnotation, testgridEmailAnnotation, testgridEmailAllTabsAnnotation,
+	testgridTabBrokenThresholdAnnotation, testgridResultsTextAnnotation, testgridResultsURLTemplateAnnotation,
+	testgridReleaseBlockingAnnotation, testgridBaseOptionsAnnotation, testgridCodeSearchURLAnnotation, testgridOpenBugURLAnnotation, testgridGCSPrefixAnnotation,
+	testgridTabAlertStaleResultsHoursAnnotation, testgridIgnorePendingAnnotation, testgridNumPassesToDisableAlertAnnotation,
+	testgridMaxTestMethodsAnnotation}
+
+// recognizedAnnotations are all the testgrid annotations handled here.
+var recognizedAnnotations = append([]string{testgridCreateTestGroupAnnotation, testgridDisableAnnotation, testgridDashboardsAnnotation, testgridDashboardsExcludeAnnotation}, testGroupAnnotations...)
+
+// descriptionTemplateData is the data available to a description annotation
+// that is a Go template, like "{{.JobName}} on {{.Repo}}".
+type descriptionTemplateData struct {
+	// JobName is the name of the job
+	JobName string
+	// Repo is the org/repo the job runs against, if any
+	Repo string
+	// JobType is the type of the job, like "presubmit"
+	JobType string
+}
+
+// annotationWarning describes a job that is under-specified, where configurator
+// fell back to a default instead of failing.
+type annotationWarning struct {
+	Job        string `json:"job"`
+	Annotation string `json:"annotation,omitempty"`
+	Message    string `json:"message"`
+}
+
+// applySingleProwjobAnnotations applies the annotations of a single job to the configuration.
+// If the defaults name a default dashboard, jobs that get a new test group but no
+// testgrid-dashboards annotation are added to it.
+func applySingleProwjobAnnotations(c *configpb.Configuration, pc *prowConfig.Config, j prowConfig.JobBase, jobType prowapi.ProwJobType, repo string, dc *yamlcfg.DefaultConfiguration, defaults configuratorDefaults) ([]annotationWarning, error) {
+	if err := validateAnnotations(j); err != nil {
+		return nil, err
+	}
+
+	if disabled, err := isDisabled(j); err != nil {
+		return nil, err
+	} else if disabled {
+		// exit early: the job opted out of testgrid entirely, even if it would
+		// otherwise get a test group by default.
+		return nil, nil
+	}
+
 	tabName := j.Name
 	testGroupName := j.Name
 	description := j.Name
@@ -55,61 +125,130 @@ func applySingleProwjobAnnotations(c *configpb.Configuration, pc *prowConfig.Con
 	dashboards, addToDashboards := j.Annotations[testgridDashboardsAnnotation]
 	mightMakeGroup := (mustMakeGroup || addToDashboards || jobType != prowapi.PresubmitJob) && !mustNotMakeGroup
 	var testGroup *configpb.TestGroup
+	var createdGroup bool
+	var warnings []annotationWarning
+
+	if mustNotMakeGroup {
+		for _, a := range testGroupAnnotations {
+			if _, ok := j.Annotations[a]; ok {
+				message := fmt.Sprintf("annotation configures a test group, but %s is \"false\"", testgridCreateTestGroupAnnotation)
+				logrus.WithFields(logrus.Fields{"job": j.Name, "annotation": a}).Warn(message)
+				warnings = append(warnings, annotationWarning{
+					Job:        j.Name,
+					Annotation: a,
+					Message:    message,
+				})
+			}
+		}
+	}
 
 	if mightMakeGroup {
 		if testGroup = config.FindTestGroup(testGroupName, c); testGroup != nil {
 			if mustMakeGroup {
-				return fmt.Errorf("test group %q already exists", testGroupName)
+				return nil, fmt.Errorf("test group %q already exists", testGroupName)
 			}
 		} else {
-			var prefix string
-			if j.DecorationConfig != nil && j.DecorationConfig.GCSConfiguration != nil {
-				prefix = path.Join(j.DecorationConfig.GCSConfiguration.Bucket, j.DecorationConfig.GCSConfiguration.PathPrefix)
-			} else if pc.Plank.GetDefaultDecorationConfigs(repo) != nil && pc.Plank.GetDefaultDecorationConfigs(repo).GCSConfiguration != nil {
-				prefix = path.Join(pc.Plank.GetDefaultDecorationConfigs(repo).GCSConfiguration.Bucket, pc.Plank.GetDefaultDecorationConfigs(repo).GCSConfiguration.PathPrefix)
-			} else {
-				return fmt.Errorf("job %s: couldn't figure out a default decoration config", j.Name)
+			gcsPrefix, usedDefault, err := testGroupGCSPrefix(pc, j, jobType, repo)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := j.Annotations[testgridGCSPrefixAnnotation]; ok {
+				logrus.WithFields(logrus.Fields{"job": j.Name, "gcs-prefix": gcsPrefix}).Info("Using GCS prefix override for test group")
+			}
+			if usedDefault {
+				warnings = append(warnings, annotationWarning{
+					Job:     j.Name,
+					Message: "job has no GCS configuration; using the default decoration config to find its results",
+				})
 			}
 
 			testGroup = &configpb.TestGroup{
 				Name:      testGroupName,
-				GcsPrefix: path.Join(prefix, prowGCS.RootForSpec(&downwardapi.JobSpec{Job: j.Name, Type: jobType})),
+				GcsPrefix: gcsPrefix,
 			}
 			if dc != nil {
 				yamlcfg.ReconcileTestGroup(testGroup, dc.DefaultTestGroup)
 			}
 			c.TestGroups = append(c.TestGroups, testGroup)
+			createdGroup = true
 		}
 	} else {
 		testGroup = config.FindTestGroup(testGroupName, c)
 	}
 
 	if testGroup == nil {
-		for _, a := range []string{testgridNumColumnsRecentAnnotation, testgridAlertStaleResultsHoursAnnotation,
-			testgridNumFailuresToAlertAnnotation, testgridTabNameAnnotation, testgridEmailAnnotation} {
+		for _, a := range testGroupAnnotations {
 			_, ok := j.Annotations[a]
 			if ok {
-				return fmt.Errorf("no testgroup exists for job %q, but annotation %q implies one should exist", j.Name, a)
+				return nil, fmt.Errorf("no testgroup exists for job %q, but annotation %q implies one should exist", j.Name, a)
 			}
 		}
 		// exit early: with no test group, there's nothing else for us to usefully do with the job.
-		return nil
+		return nil, nil
 	}
 
 	if ncr, ok := j.Annotations[testgridNumColumnsRecentAnnotation]; ok {
 		ncrInt, err := strconv.ParseInt(ncr, 10, 32)
 		if err != nil {
-			return fmt.Errorf("%s value %q is not a valid integer", testgridNumColumnsRecentAnnotation, ncr)
+			return nil, fmt.Errorf("%s value %q is not a valid integer", testgridNumColumnsRecentAnnotation, ncr)
+		}
+		if ncrInt < 1 {
+			return nil, fmt.Errorf("job %q: %s value %q must be positive", j.Name, testgridNumColumnsRecentAnnotation, ncr)
 		}
 		testGroup.NumColumnsRecent = int32(ncrInt)
-	} else if jobType == prowapi.PresubmitJob && testGroup.NumColumnsRecent < minPresubmitNumColumnsRecent {
-		testGroup.NumColumnsRecent = minPresubmitNumColumnsRecent
+	} else if floor := numColumnsRecentFloor(jobType, defaults); testGroup.NumColumnsRecent < floor {
+		// the postsubmit floor only applies to generated test groups, so that hand-authored ones are kept
+		if createdGroup || jobType != prowapi.PostsubmitJob {
+			testGroup.NumColumnsRecent = floor
+		}
+	}
+
+	if dor, ok := j.Annotations[testgridDaysOfResultsAnnotation]; ok {
+		dorInt, err := strconv.ParseInt(dor, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s value %q is not a valid integer", testgridDaysOfResultsAnnotation, dor)
+		}
+		testGroup.DaysOfResults = int32(dorInt)
+	}
+
+	if mtm, ok := j.Annotations[testgridMaxTestMethodsAnnotation]; ok {
+		mtmInt, err := strconv.ParseInt(mtm, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s value %q is not a valid integer", testgridMaxTestMethodsAnnotation, mtm)
+		}
+		if mtmInt < 1 {
+			return nil, fmt.Errorf("job %q: %s value %q must be positive", j.Name, testgridMaxTestMethodsAnnotation, mtm)
+		}
+		testGroup.MaxTestMethodsPerTest = int32(mtmInt)
+	}
+
+	if ip, ok := j.Annotations[testgridIgnorePendingAnnotation]; ok {
+		ignorePending, err := strconv.ParseBool(ip)
+		if err != nil {
+			return nil, fmt.Errorf("%s value %q is not a valid boolean", testgridIgnorePendingAnnotation, ip)
+		}
+		testGroup.IgnorePending = ignorePending
+	}
+
+	if ch, ok := j.Annotations[testgridColumnHeaderAnnotation]; ok {
+		var headers []*configpb.TestGroup_ColumnHeader
+		for _, key := range strings.Split(ch, ",") {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				return nil, fmt.Errorf("%s value %q contains an empty metadata key", testgridColumnHeaderAnnotation, ch)
+			}
+			headers = append(headers, &configpb.TestGroup_ColumnHeader{ConfigurationValue: key})
+		}
+		testGroup.ColumnHeader = headers
 	}
 
 	if srh, ok := j.Annotations[testgridAlertStaleResultsHoursAnnotation]; ok {
 		srhInt, err := strconv.ParseInt(srh, 10, 32)
 		if err != nil {
-			return fmt.Errorf("%s value %q is not a valid integer", testgridAlertStaleResultsHoursAnnotation, srh)
+			return nil, fmt.Errorf("%s value %q is not a valid integer", testgridAlertStaleResultsHoursAnnotation, srh)
+		}
+		if srhInt < 0 {
+			return nil, fmt.Errorf("job %q: %s value %q must not be negative", j.Name, testgridAlertStaleResultsHoursAnnotation, srh)
 		}
 		testGroup.AlertStaleResultsHours = int32(srhInt)
 	}
@@ -117,7 +256,10 @@ func applySingleProwjobAnnotations(c *configpb.Configuration, pc *prowConfig.Con
 	if nfta, ok := j.Annotations[testgridNumFailuresToAlertAnnotation]; ok {
 		nftaInt, err := strconv.ParseInt(nfta, 10, 32)
 		if err != nil {
-			return fmt.Errorf("%s value %q is not a valid integer", testgridNumFailuresToAlertAnnotation, nfta)
+			return nil, fmt.Errorf("%s value %q is not a valid integer", testgridNumFailuresToAlertAnnotation, nfta)
+		}
+		if nftaInt < 0 {
+			return nil, fmt.Errorf("job %q: %s value %q must not be negative", j.Name, testgridNumFailuresToAlertAnnotation, nfta)
 		}
 		testGroup.NumFailuresToAlert = int32(nftaInt)
 	}
@@ -125,23 +267,149 @@ func applySingleProwjobAnnotations(c *configpb.Configuration, pc *prowConfig.Con
 	if tn, ok := j.Annotations[testgridTabNameAnnotation]; ok {
 		tabName = tn
 	}
+	if repo == "" && len(j.ExtraRefs) > 0 {
+		repo = fmt.Sprintf("%s/%s", j.ExtraRefs[0].Org, j.ExtraRefs[0].Repo)
+	}
 	if d := j.Annotations[descriptionAnnotation]; d != "" {
-		description = d
+		rendered, err := renderDescription(d, descriptionTemplateData{JobName: j.Name, Repo: repo, JobType: string(jobType)})
+		if err != nil {
+			return nil, fmt.Errorf("job %q: %v", j.Name, err)
+		}
+		description = rendered
+	} else if addToDashboards {
+		warnings = append(warnings, annotationWarning{
+			Job:        j.Name,
+			Annotation: descriptionAnnotation,
+			Message:    "job has no description; using the job name as the tab description",
+		})
+	}
+
+	var brokenThreshold float32
+	if bt, ok := j.Annotations[testgridTabBrokenThresholdAnnotation]; ok {
+		btFloat, err := strconv.ParseFloat(bt, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s value %q is not a valid float", testgridTabBrokenThresholdAnnotation, bt)
+		}
+		if btFloat < 0 || btFloat > 1 {
+			return nil, fmt.Errorf("%s value %q must be between 0 and 1", testgridTabBrokenThresholdAnnotation, bt)
+		}
+		brokenThreshold = float32(btFloat)
+	}
+
+	resultsText, hasResultsText := j.Annotations[testgridResultsTextAnnotation]
+	resultsURLTemplate, hasResultsURLTemplate := j.Annotations[testgridResultsURLTemplateAnnotation]
+	if hasResultsText != hasResultsURLTemplate {
+		return nil, fmt.Errorf("job %q: %s and %s must be set together", j.Name, testgridResultsTextAnnotation, testgridResultsURLTemplateAnnotation)
+	}
+
+	if emails, ok := j.Annotations[testgridEmailAnnotation]; ok {
+		for _, email := range strings.Split(emails, ",") {
+			if _, err := mail.ParseAddress(strings.TrimSpace(email)); err != nil {
+				return nil, fmt.Errorf("job %q: %s address %q is not valid: %v", j.Name, testgridEmailAnnotation, strings.TrimSpace(email), err)
+			}
+		}
+	}
+
+	var tabStaleResultsHours int32
+	if tsrh, ok := j.Annotations[testgridTabAlertStaleResultsHoursAnnotation]; ok {
+		tsrhInt, err := strconv.ParseInt(tsrh, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s value %q is not a valid integer", testgridTabAlertStaleResultsHoursAnnotation, tsrh)
+		}
+		if tsrhInt < 0 {
+			return nil, fmt.Errorf("job %q: %s value %q must not be negative", j.Name, testgridTabAlertStaleResultsHoursAnnotation, tsrh)
+		}
+		tabStaleResultsHours = int32(tsrhInt)
+	}
+
+	var numPassesToDisableAlert int32
+	if nptda, ok := j.Annotations[testgridNumPassesToDisableAlertAnnotation]; ok {
+		nptdaInt, err := strconv.ParseInt(nptda, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s value %q is not a valid integer", testgridNumPassesToDisableAlertAnnotation, nptda)
+		}
+		if nptdaInt < 0 {
+			return nil, fmt.Errorf("job %q: %s value %q must not be negative", j.Name, testgridNumPassesToDisableAlertAnnotation, nptda)
+		}
+		numPassesToDisableAlert = int32(nptdaInt)
+	}
+
+	var emailAllTabs bool
+	if eat, ok := j.Annotations[testgridEmailAllTabsAnnotation]; ok {
+		var err error
+		if emailAllTabs, err = strconv.ParseBool(eat); err != nil {
+			return nil, fmt.Errorf("%s value %q is not a valid boolean", testgridEmailAllTabsAnnotation, eat)
+		}
+	}
+
+	baseOptions, hasBaseOptions := j.Annotations[testgridBaseOptionsAnnotation]
+	if hasBaseOptions && baseOptions == "" {
+		return nil, fmt.Errorf("job %q: %s must not be empty", j.Name, testgridBaseOptionsAnnotation)
+	}
+
+	codeSearchURL, hasCodeSearchURL := j.Annotations[testgridCodeSearchURLAnnotation]
+	if hasCodeSearchURL && codeSearchURL == "" {
+		return nil, fmt.Errorf("job %q: %s must not be empty", j.Name, testgridCodeSearchURLAnnotation)
+	}
+	openBugURL, hasOpenBugURL := j.Annotations[testgridOpenBugURLAnnotation]
+	if hasOpenBugURL && openBugURL == "" {
+		return nil, fmt.Errorf("job %q: %s must not be empty", j.Name, testgridOpenBugURLAnnotation)
+	}
+
+	if rb, ok := j.Annotations[testgridReleaseBlockingAnnotation]; ok {
+		releaseBlocking, err := strconv.ParseBool(rb)
+		if err != nil {
+			return nil, fmt.Errorf("%s value %q is not a valid boolean", testgridReleaseBlockingAnnotation, rb)
+		}
+		if releaseBlocking {
+			description = releaseBlockingDescriptionPrefix + description
+		}
+	}
+
+	if !addToDashboards && createdGroup && defaults.DefaultDashboard != "" {
+		dashboards, addToDashboards = defaults.DefaultDashboard, true
 	}
 
 	if addToDashboards {
-		firstDashboard := true
+		var excluded []string
+		if exclude, ok := j.Annotations[testgridDashboardsExcludeAnnotation]; ok {
+			for _, name := range strings.Split(exclude, ",") {
+				name = strings.TrimSpace(name)
+				if name == "" {
+					return nil, fmt.Errorf("job %q: %s value %q contains an empty dashboard name", j.Name, testgridDashboardsExcludeAnnotation, exclude)
+				}
+				excluded = append(excluded, name)
+			}
+		}
+
+		var targets []*configpb.Dashboard
+		seen := map[string]bool{}
 		for _, dashboardName := range strings.Split(dashboards, ",") {
 			dashboardName = strings.TrimSpace(dashboardName)
-			d := config.FindDashboard(dashboardName, c)
-			if d == nil {
-				return fmt.Errorf("couldn't find dashboard %q for job %q", dashboardName, j.Name)
+			matched, err := findDashboards(dashboardName, c)
+			if err != nil {
+				return nil, fmt.Errorf("job %q: %v", j.Name, err)
 			}
-			if repo == "" {
-				if len(j.ExtraRefs) > 0 {
-					repo = fmt.Sprintf("%s/%s", j.ExtraRefs[0].Org, j.ExtraRefs[0].Repo)
+			if len(matched) == 0 {
+				return nil, fmt.Errorf("couldn't find dashboard %q for job %q", dashboardName, j.Name)
+			}
+			for _, d := range matched {
+				skip, err := matchesAny(d.Name, excluded)
+				if err != nil {
+					return nil, fmt.Errorf("job %q: %s: %v", j.Name, testgridDashboardsExcludeAnnotation, err)
+				}
+				if skip {
+					continue
+				}
+				if !seen[d.Name] {
+					seen[d.Name] = true
+					targets = append(targets, d)
 				}
 			}
+		}
+
+		firstDashboard := true
+		for _, d := range targets {
 			var codeSearchLinkTemplate, openBugLinkTemplate *configpb.LinkTemplate
 			if repo != "" {
 				codeSearchLinkTemplate = &configpb.LinkTemplate{
@@ -151,29 +419,209 @@ func applySingleProwjobAnnotations(c *configpb.Configuration, pc *prowConfig.Con
 					Url: fmt.Sprintf("https://github.com/%s/issues/", repo),
 				}
 			}
+			if hasCodeSearchURL {
+				codeSearchLinkTemplate = &configpb.LinkTemplate{Url: codeSearchURL}
+			}
+			if hasOpenBugURL {
+				openBugLinkTemplate = &configpb.LinkTemplate{Url: openBugURL}
+			}
 			dt := &configpb.DashboardTab{
 				Name:                  tabName,
 				TestGroupName:         testGroupName,
 				Description:           description,
 				CodeSearchUrlTemplate: codeSearchLinkTemplate,
 				OpenBugTemplate:       openBugLinkTemplate,
+				BrokenColumnThreshold: brokenThreshold,
+				BaseOptions:           baseOptions,
 			}
-			if firstDashboard {
+			if hasResultsText {
+				dt.ResultsText = resultsText
+				dt.ResultsUrlTemplate = &configpb.LinkTemplate{Url: resultsURLTemplate}
+			}
+			if firstDashboard || emailAllTabs {
 				firstDashboard = false
 				if emails, ok := j.Annotations[testgridEmailAnnotation]; ok {
 					dt.AlertOptions = &configpb.DashboardTabAlertOptions{AlertMailToAddresses: emails}
 				}
+				if numPassesToDisableAlert != 0 {
+					if dt.AlertOptions == nil {
+						dt.AlertOptions = &configpb.DashboardTabAlertOptions{}
+					}
+					dt.AlertOptions.NumPassesToDisableAlert = numPassesToDisableAlert
+				}
+			}
+			if tabStaleResultsHours != 0 {
+				if dt.AlertOptions == nil {
+					dt.AlertOptions = &configpb.DashboardTabAlertOptions{}
+				}
+				dt.AlertOptions.AlertStaleResultsHours = tabStaleResultsHours
 			}
 			if dc != nil {
 				yamlcfg.ReconcileDashboardTab(dt, dc.DefaultDashboardTab)
 			}
-			d.DashboardTab = append(d.DashboardTab, dt)
+			upsertDashboardTab(d, dt)
+		}
+	}
+
+	return warnings, nil
+}
+
+// upsertDashboardTab adds the tab to the dashboard, replacing a tab with the same
+// name and test group in place, so that re-running against a config that already
+// has the tab doesn't duplicate it.
+func upsertDashboardTab(d *configpb.Dashboard, dt *configpb.DashboardTab) {
+	for i, existing := range d.DashboardTab {
+		if existing.Name == dt.Name && existing.TestGroupName == dt.TestGroupName {
+			d.DashboardTab[i] = dt
+			return
 		}
 	}
+	d.DashboardTab = append(d.DashboardTab, dt)
+}
+
+// renderDescription renders a description annotation that is a Go template.
+// Descriptions without template actions are used verbatim.
+func renderDescription(description string, data descriptionTemplateData) (string, error) {
+	if !strings.Contains(description, "{{") {
+		return description, nil
+	}
+	t, err := template.New(descriptionAnnotation).Parse(description)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a valid template: %v", descriptionAnnotation, err)
+	}
+	var rendered strings.Builder
+	if err := t.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %v", descriptionAnnotation, err)
+	}
+	return rendered.String(), nil
+}
 
+// isDisabled determines whether the job opted out of testgrid with the testgrid-disable annotation.
+func isDisabled(j prowConfig.JobBase) (bool, error) {
+	d, ok := j.Annotations[testgridDisableAnnotation]
+	if !ok {
+		return false, nil
+	}
+	disabled, err := strconv.ParseBool(d)
+	if err != nil {
+		return false, fmt.Errorf("%s value %q is not a valid boolean", testgridDisableAnnotation, d)
+	}
+	return disabled, nil
+}
+
+// validateAnnotations makes sure every annotation of the job that looks like a testgrid
+// annotation is one we recognize, so that typos don't go unnoticed.
+func validateAnnotations(j prowConfig.JobBase) error {
+	var keys []string
+	for k := range j.Annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !strings.HasPrefix(k, "testgrid-") {
+			continue
+		}
+		recognized := false
+		for _, a := range recognizedAnnotations {
+			if k == a {
+				recognized = true
+				break
+			}
+		}
+		if !recognized {
+			return fmt.Errorf("job %q has unknown testgrid annotation %q", j.Name, k)
+		}
+	}
 	return nil
 }
 
+// numColumnsRecentFloor returns the minimum num_columns_recent for test groups
+// of jobs of the given job type. The defaults may override the floors
+// for presubmits and postsubmits in either direction.
+func numColumnsRecentFloor(jobType prowapi.ProwJobType, defaults configuratorDefaults) int32 {
+	switch jobType {
+	case prowapi.PresubmitJob:
+		if defaults.MinPresubmitNumColumnsRecent != nil {
+			return *defaults.MinPresubmitNumColumnsRecent
+		}
+		return minPresubmitNumColumnsRecent
+	case prowapi.PostsubmitJob:
+		if defaults.MinPostsubmitNumColumnsRecent != nil {
+			return *defaults.MinPostsubmitNumColumnsRecent
+		}
+		return minPostsubmitNumColumnsRecent
+	default:
+		return 0
+	}
+}
+
+// testGroupGCSPrefix determines the GCS prefix holding the results of the job,
+// and whether it was derived from the default decoration config.
+func testGroupGCSPrefix(pc *prowConfig.Config, j prowConfig.JobBase, jobType prowapi.ProwJobType, repo string) (string, bool, error) {
+	if override, ok := j.Annotations[testgridGCSPrefixAnnotation]; ok {
+		if override == "" {
+			return "", false, fmt.Errorf("job %q: %s must not be empty", j.Name, testgridGCSPrefixAnnotation)
+		}
+		return override, false, nil
+	}
+	var prefix string
+	var usedDefault bool
+	if j.DecorationConfig != nil && j.DecorationConfig.GCSConfiguration != nil {
+		prefix = path.Join(j.DecorationConfig.GCSConfiguration.Bucket, j.DecorationConfig.GCSConfiguration.PathPrefix)
+	} else if pc.Plank.GetDefaultDecorationConfigs(repo) != nil && pc.Plank.GetDefaultDecorationConfigs(repo).GCSConfiguration != nil {
+		prefix = path.Join(pc.Plank.GetDefaultDecorationConfigs(repo).GCSConfiguration.Bucket, pc.Plank.GetDefaultDecorationConfigs(repo).GCSConfiguration.PathPrefix)
+		usedDefault = true
+	} else {
+		return "", false, fmt.Errorf("job %s: couldn't figure out a default decoration config", j.Name)
+	}
+	return path.Join(prefix, prowGCS.RootForSpec(&downwardapi.JobSpec{Job: j.Name, Type: jobType})), usedDefault, nil
+}
+
+// describeJob identifies a job in error messages.
+func describeJob(name string, jobType prowapi.ProwJobType, repo string) string {
+	if repo == "" {
+		return fmt.Sprintf("%s job %q", jobType, name)
+	}
+	return fmt.Sprintf("%s job %q in %s", jobType, name, repo)
+}
+
+// findDashboards finds the dashboards with the given name, which may be a glob
+// pattern like "sig-node-*" matching any number of dashboards.
+func findDashboards(name string, c *configpb.Configuration) ([]*configpb.Dashboard, error) {
+	if !strings.ContainsAny(name, "*?[") {
+		if d := config.FindDashboard(name, c); d != nil {
+			return []*configpb.Dashboard{d}, nil
+		}
+		return nil, nil
+	}
+	var matched []*configpb.Dashboard
+	for _, d := range c.Dashboards {
+		match, err := path.Match(name, d.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dashboard pattern %q: %v", name, err)
+		}
+		if match {
+			matched = append(matched, d)
+		}
+	}
+	return matched, nil
+}
+
+// matchesAny determines whether the dashboard name equals or matches a glob
+// pattern in any of the given names.
+func matchesAny(dashboardName string, names []string) (bool, error) {
+	for _, name := range names {
+		match, err := path.Match(name, dashboardName)
+		if err != nil {
+			return false, fmt.Errorf("invalid dashboard pattern %q: %v", name, err)
+		}
+		if match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // sortPeriodics sorts all periodics by name (ascending).
 func sortPeriodics(per []prowConfig.Periodic) {
 	sort.Slice(per, func(a, b int) bool {
@@ -219,28 +667,120 @@ func sortPresubmits(pre map[string][]prowConfig.Presubmit) []string {
 	return preRepos
 }
 
-func applyProwjobAnnotations(c *configpb.Configuration, reconcile *yamlcfg.DefaultConfiguration, prowConfigAgent *prowConfig.Agent) error {
+// sortDashboardTabs sorts the tabs of every dashboard by name, so that the order
+// doesn't depend on the order in which jobs were applied. Tabs with the same name
+// keep their relative order.
+func sortDashboardTabs(c *configpb.Configuration) {
+	for _, d := range c.Dashboards {
+		sort.SliceStable(d.DashboardTab, func(a, b int) bool {
+			return d.DashboardTab[a].Name < d.DashboardTab[b].Name
+		})
+	}
+}
+
+// releaseBlockingTestGroups lists the test groups of the enabled jobs annotated
+// as release-blocking.
+func releaseBlockingTestGroups(pc *prowConfig.Config) map[string]bool {
+	blocking := map[string]bool{}
+	if pc == nil {
+		return blocking
+	}
+	for _, j := range orderedProwJobs(pc.JobConfig) {
+		if disabled, err := isDisabled(j.base); err != nil || disabled {
+			continue
+		}
+		if rb, err := strconv.ParseBool(j.base.Annotations[testgridReleaseBlockingAnnotation]); err == nil && rb {
+			blocking[j.base.Name] = true
+		}
+	}
+	return blocking
+}
+
+// aggregateReleaseBlockingTabs copies every release-blocking dashboard tab onto the
+// named dashboard, creating it if necessary, so that one view shows all of them.
+// A tab is release-blocking if its test group is in blockingGroups and its description
+// carries the prefix added for the annotation, so hand-written tabs are left alone.
+// Tabs for a test group already on the dashboard are not copied again, and tabs
+// whose name is already taken are qualified with the name of their dashboard.
+func aggregateReleaseBlockingTabs(c *configpb.Configuration, dashboardName string, blockingGroups map[string]bool) error {
+	for _, group := range c.DashboardGroups {
+		if group.Name == dashboardName {
+			return fmt.Errorf("release-blocking dashboard %q conflicts with a dashboard group of the same name", dashboardName)
+		}
+	}
+	aggregate := config.FindDashboard(dashboardName, c)
+	if aggregate == nil {
+		aggregate = &configpb.Dashboard{Name: dashboardName}
+		c.Dashboards = append(c.Dashboards, aggregate)
+	}
+
+	tabNames := map[string]bool{}
+	testGroups := map[string]bool{}
+	for _, dt := range aggregate.DashboardTab {
+		tabNames[dt.Name] = true
+		testGroups[dt.TestGroupName] = true
+	}
+	for _, d := range c.Dashboards {
+		if d == aggregate {
+			continue
+		}
+		for _, dt := range d.DashboardTab {
+			if !blockingGroups[dt.TestGroupName] || !strings.HasPrefix(dt.Description, releaseBlockingDescriptionPrefix) || testGroups[dt.TestGroupName] {
+				continue
+			}
+			copied := proto.Clone(dt).(*configpb.DashboardTab)
+			if tabNames[copied.Name] {
+				copied.Name = fmt.Sprintf("%s - %s", d.Name, dt.Name)
+			}
+			aggregate.DashboardTab = append(aggregate.DashboardTab, copied)
+			tabNames[copied.Name] = true
+			testGroups[copied.TestGroupName] = true
+		}
+	}
+	return nil
+}
+
+// prowJob is a job whose annotations are applied to the configuration.
+type prowJob struct {
+	base    prowConfig.JobBase
+	jobType prowapi.ProwJobType
+	repo    string
+}
+
+// jobResult holds what applying a single job's annotations added to a private
+// scratch configuration, to be merged into the real one afterwards.
+type jobResult struct {
+	scratch  *configpb.Configuration
+	warnings []annotationWarning
+	err      error
+}
+
+// applyProwjobAnnotations applies the annotations of all prow jobs to the configuration,
+// returning warnings about jobs that are under-specified but still usable.
+func applyProwjobAnnotations(c *configpb.Configuration, reconcile *yamlcfg.DefaultConfiguration, defaults configuratorDefaults, prowConfigAgent *prowConfig.Agent) ([]annotationWarning, error) {
 	pc := prowConfigAgent.Config()
 	if pc == nil {
-		return nil
+		return nil, nil
 	}
-	jobs := prowConfigAgent.Config().JobConfig
+	return applyJobAnnotations(c, reconcile, defaults, pc, orderedProwJobs(pc.JobConfig), runtime.GOMAXPROCS(0))
+}
+
+// orderedProwJobs lists all jobs in the order their annotations are applied:
+// periodics, then postsubmits and presubmits, each sorted by repo and name.
+func orderedProwJobs(jobs prowConfig.JobConfig) []prowJob {
+	var ordered []prowJob
 
 	per := jobs.AllPeriodics()
 	sortPeriodics(per)
 	for _, j := range per {
-		if err := applySingleProwjobAnnotations(c, pc, j.JobBase, prowapi.PeriodicJob, "", reconcile); err != nil {
-			return err
-		}
+		ordered = append(ordered, prowJob{base: j.JobBase, jobType: prowapi.PeriodicJob})
 	}
 
 	post := jobs.PostsubmitsStatic
 	postReposSorted := sortPostsubmits(post)
 	for _, orgrepo := range postReposSorted {
 		for _, j := range post[orgrepo] {
-			if err := applySingleProwjobAnnotations(c, pc, j.JobBase, prowapi.PostsubmitJob, orgrepo, reconcile); err != nil {
-				return err
-			}
+			ordered = append(ordered, prowJob{base: j.JobBase, jobType: prowapi.PostsubmitJob, repo: orgrepo})
 		}
 	}
 
@@ -248,11 +788,121 @@ func applyProwjobAnnotations(c *configpb.Configuration, reconcile *yamlcfg.Defau
 	preReposSorted := sortPresubmits(pre)
 	for _, orgrepo := range preReposSorted {
 		for _, j := range pre[orgrepo] {
-			if err := applySingleProwjobAnnotations(c, pc, j.JobBase, prowapi.PresubmitJob, orgrepo, reconcile); err != nil {
-				return err
+			ordered = append(ordered, prowJob{base: j.JobBase, jobType: prowapi.PresubmitJob, repo: orgrepo})
+		}
+	}
+
+	return ordered
+}
+
+// applyJobAnnotations applies the annotations of the jobs to the configuration.
+// Each job is first applied to its own scratch configuration by a pool of workers,
+// and the results are then merged in job order, so that the outcome is the same
+// as applying the jobs one after another.
+func applyJobAnnotations(c *configpb.Configuration, reconcile *yamlcfg.DefaultConfiguration, defaults configuratorDefaults, pc *prowConfig.Config, jobs []prowJob, workers int) ([]annotationWarning, error) {
+	existing := map[string]*configpb.TestGroup{}
+	for _, testGroup := range c.TestGroups {
+		existing[testGroup.Name] = testGroup
+	}
+
+	// Only the first job with a given name can be applied independently: any later
+	// one builds on the test group of the first, so it's applied during the merge.
+	independent := make([]bool, len(jobs))
+	seen := map[string]bool{}
+	for i, j := range jobs {
+		if !seen[j.base.Name] {
+			seen[j.base.Name] = true
+			independent[i] = true
+		}
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]jobResult, len(jobs))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i] = applyToScratch(c.Dashboards, existing[jobs[i].base.Name], reconcile, defaults, pc, jobs[i])
 			}
+		}()
+	}
+	for i := range jobs {
+		if independent[i] {
+			indices <- i
 		}
 	}
+	close(indices)
+	wg.Wait()
+
+	var warnings []annotationWarning
+	// generatedFor records the job each test group was generated for, so that a
+	// job with the same name doesn't silently reuse a group with a different GCS prefix
+	generatedFor := map[string]string{}
+	for i, j := range jobs {
+		if !independent[i] {
+			disabled, err := isDisabled(j.base)
+			if err != nil {
+				return nil, err
+			}
+			if owner, generated := generatedFor[j.base.Name]; generated && !disabled {
+				if testGroup := config.FindTestGroup(j.base.Name, c); testGroup != nil {
+					if gcsPrefix, _, err := testGroupGCSPrefix(pc, j.base, j.jobType, j.repo); err == nil && gcsPrefix != testGroup.GcsPrefix {
+						return nil, fmt.Errorf("%s has results at GCS prefix %q, but test group %q was already generated for %s with GCS prefix %q; rename one of the jobs",
+							describeJob(j.base.Name, j.jobType, j.repo), gcsPrefix, j.base.Name, owner, testGroup.GcsPrefix)
+					}
+				}
+			}
+			existed := config.FindTestGroup(j.base.Name, c) != nil
+			jobWarnings, err := applySingleProwjobAnnotations(c, pc, j.base, j.jobType, j.repo, reconcile, defaults)
+			if err != nil {
+				return nil, err
+			}
+			if !existed && config.FindTestGroup(j.base.Name, c) != nil {
+				generatedFor[j.base.Name] = describeJob(j.base.Name, j.jobType, j.repo)
+			}
+			warnings = append(warnings, jobWarnings...)
+			continue
+		}
 
-	return nil
+		result := results[i]
+		if result.err != nil {
+			return nil, result.err
+		}
+		for _, testGroup := range result.scratch.TestGroups {
+			if existing[testGroup.Name] == nil {
+				c.TestGroups = append(c.TestGroups, testGroup)
+				generatedFor[testGroup.Name] = describeJob(j.base.Name, j.jobType, j.repo)
+			}
+		}
+		for k, d := range result.scratch.Dashboards {
+			for _, dt := range d.DashboardTab {
+				upsertDashboardTab(c.Dashboards[k], dt)
+			}
+		}
+		warnings = append(warnings, result.warnings...)
+	}
+
+	return warnings, nil
+}
+
+// applyToScratch applies the annotations of the job to a scratch configuration holding
+// only the test group the job might reuse and, if it adds tabs, empty copies of the dashboards.
+// Nothing else is shared with other jobs, so it's safe to call concurrently for jobs with different names.
+func applyToScratch(dashboards []*configpb.Dashboard, existing *configpb.TestGroup, reconcile *yamlcfg.DefaultConfiguration, defaults configuratorDefaults, pc *prowConfig.Config, j prowJob) jobResult {
+	scratch := &configpb.Configuration{}
+	if existing != nil {
+		scratch.TestGroups = []*configpb.TestGroup{existing}
+	}
+	if _, ok := j.base.Annotations[testgridDashboardsAnnotation]; ok || defaults.DefaultDashboard != "" {
+		for _, d := range dashboards {
+			scratch.Dashboards = append(scratch.Dashboards, &configpb.Dashboard{Name: d.Name})
+		}
+	}
+	warnings, err := applySingleProwjobAnnotations(scratch, pc, j.base, j.jobType, j.repo, reconcile, defaults)
+	return jobResult{scratch: scratch, warnings: warnings, err: err}
 }
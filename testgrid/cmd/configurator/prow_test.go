@@ -17,7 +17,9 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"reflect"
+	"runtime"
 	"testing"
 
 	"github.com/GoogleCloudPlatform/testgrid/config/yamlcfg"
@@ -51,8 +53,9 @@ func Test_applySingleProwjobAnnotations(t *testing.T) {
 			expectedConfig: config.Configuration{
 				TestGroups: []*config.TestGroup{
 					{
-						Name:      ProwJobName,
-						GcsPrefix: ProwDefaultGCSPath + "logs/" + ProwJobName,
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
 					},
 				},
 			},
@@ -90,6 +93,56 @@ func Test_applySingleProwjobAnnotations(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "Ignore pending results in the test group",
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-ignore-pending": "true",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+						IgnorePending:    true,
+					},
+				},
+			},
+		},
+		{
+			name:        "Max test methods per test in the test group",
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-max-test-methods": "50",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:                  ProwJobName,
+						GcsPrefix:             ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent:      10,
+						MaxTestMethodsPerTest: 50,
+					},
+				},
+			},
+		},
+		{
+			name:        "Invalid ignore pending annotation: fails",
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-ignore-pending": "mostly",
+			},
+			expectError: true,
+		},
+		{
+			name:        "Ignore pending without a test group: fails",
+			prowJobType: prowapi.PresubmitJob,
+			annotations: map[string]string{
+				"testgrid-ignore-pending": "true",
+			},
+			expectError: true,
+		},
 		{
 			name:        "Non-presubmit excluding test group",
 			prowJobType: prowapi.PostsubmitJob,
@@ -98,6 +151,56 @@ func Test_applySingleProwjobAnnotations(t *testing.T) {
 			},
 			expectedConfig: config.Configuration{},
 		},
+		{
+			name:        "Disabled periodic: no change",
+			prowJobType: prowapi.PeriodicJob,
+			annotations: map[string]string{
+				"testgrid-disable": "true",
+			},
+			expectedConfig: config.Configuration{},
+		},
+		{
+			name: "Disabled job on a dashboard: no change",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Wash"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-disable":           "true",
+				"testgrid-create-test-group": "true",
+				"testgrid-dashboards":        "Wash",
+			},
+			expectedConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Wash"},
+				},
+			},
+		},
+		{
+			name:        "Explicitly enabled periodic: test group only",
+			prowJobType: prowapi.PeriodicJob,
+			annotations: map[string]string{
+				"testgrid-disable": "false",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:      ProwJobName,
+						GcsPrefix: ProwDefaultGCSPath + "logs/" + ProwJobName,
+					},
+				},
+			},
+		},
+		{
+			name:        "Invalid disable annotation: fails",
+			prowJobType: prowapi.PeriodicJob,
+			annotations: map[string]string{
+				"testgrid-disable": "sometimes",
+			},
+			expectError: true,
+		},
 		{
 			name: "Force-add job to existing test group: fails",
 			initialConfig: config.Configuration{
@@ -125,8 +228,9 @@ func Test_applySingleProwjobAnnotations(t *testing.T) {
 			expectedConfig: config.Configuration{
 				TestGroups: []*config.TestGroup{
 					{
-						Name:      ProwJobName,
-						GcsPrefix: ProwDefaultGCSPath + "logs/" + ProwJobName,
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
 					},
 				},
 				Dashboards: []*config.Dashboard{
@@ -158,28 +262,29 @@ func Test_applySingleProwjobAnnotations(t *testing.T) {
 			expectError: true,
 		},
 		{
-			name: "Add email to multiple dashboards: Two tabs, one email",
+			name: "Add job to dashboards matching a pattern",
 			initialConfig: config.Configuration{
 				Dashboards: []*config.Dashboard{
-					{Name: "Dart"},
-					{Name: "Peg"},
+					{Name: "sig-node-release"},
+					{Name: "sig-storage"},
+					{Name: "sig-node-kubelet"},
 				},
 			},
 			prowJobType: prowapi.PostsubmitJob,
 			annotations: map[string]string{
-				"testgrid-dashboards":  "Dart, Peg",
-				"testgrid-alert-email": "test@example.com",
+				"testgrid-dashboards": "sig-node-*",
 			},
 			expectedConfig: config.Configuration{
 				TestGroups: []*config.TestGroup{
 					{
-						Name:      ProwJobName,
-						GcsPrefix: ProwDefaultGCSPath + "logs/" + ProwJobName,
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
 					},
 				},
 				Dashboards: []*config.Dashboard{
 					{
-						Name: "Dart",
+						Name: "sig-node-release",
 						DashboardTab: []*config.DashboardTab{
 							{
 								Name:          ProwJobName,
@@ -191,14 +296,12 @@ func Test_applySingleProwjobAnnotations(t *testing.T) {
 								OpenBugTemplate: &config.LinkTemplate{
 									Url: "https://github.com/test/repo/issues/",
 								},
-								AlertOptions: &config.DashboardTabAlertOptions{
-									AlertMailToAddresses: "test@example.com",
-								},
 							},
 						},
 					},
+					{Name: "sig-storage"},
 					{
-						Name: "Peg",
+						Name: "sig-node-kubelet",
 						DashboardTab: []*config.DashboardTab{
 							{
 								Name:          ProwJobName,
@@ -217,47 +320,123 @@ func Test_applySingleProwjobAnnotations(t *testing.T) {
 			},
 		},
 		{
-			name: "Add job that already exists: keeps test group, makes duplicate tab",
+			name: "Excluded dashboard matching a pattern: no tab, but a test group",
 			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "sig-node-release"},
+					{Name: "sig-node-kubelet"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":         "sig-node-*",
+				"testgrid-dashboards-exclude": "sig-node-kubelet",
+			},
+			expectedConfig: config.Configuration{
 				TestGroups: []*config.TestGroup{
 					{
-						Name:      ProwJobName,
-						GcsPrefix: "CustomFoo",
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
 					},
 				},
 				Dashboards: []*config.Dashboard{
 					{
-						Name: "Surf",
+						Name: "sig-node-release",
 						DashboardTab: []*config.DashboardTab{
 							{
 								Name:          ProwJobName,
-								Description:   "RegularHumanDescription",
+								Description:   ProwJobName,
 								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
 							},
 						},
 					},
+					{Name: "sig-node-kubelet"},
+				},
+			},
+		},
+		{
+			name: "Every matched dashboard excluded by a pattern: test group only",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "sig-node-release"},
 				},
 			},
 			prowJobType: prowapi.PostsubmitJob,
 			annotations: map[string]string{
-				"testgrid-dashboards": "Surf",
+				"testgrid-dashboards":         "sig-node-*",
+				"testgrid-dashboards-exclude": "sig-*",
 			},
 			expectedConfig: config.Configuration{
 				TestGroups: []*config.TestGroup{
 					{
-						Name:      ProwJobName,
-						GcsPrefix: "CustomFoo",
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{Name: "sig-node-release"},
+				},
+			},
+		},
+		{
+			name: "Malformed excluded dashboard pattern: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "sig-node-release"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":         "sig-node-*",
+				"testgrid-dashboards-exclude": "sig-node-[",
+			},
+			expectError: true,
+		},
+		{
+			name: "Empty excluded dashboard name: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "sig-node-release"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":         "sig-node-*",
+				"testgrid-dashboards-exclude": "sig-storage,",
+			},
+			expectError: true,
+		},
+		{
+			name: "Dashboard matched by both a name and a pattern: one tab",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "sig-node-release"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards": "sig-node-release, sig-node-*",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
 					},
 				},
 				Dashboards: []*config.Dashboard{
 					{
-						Name: "Surf",
+						Name: "sig-node-release",
 						DashboardTab: []*config.DashboardTab{
-							{
-								Name:          ProwJobName,
-								Description:   "RegularHumanDescription",
-								TestGroupName: ProwJobName,
-							},
 							{
 								Name:          ProwJobName,
 								Description:   ProwJobName,
@@ -275,43 +454,79 @@ func Test_applySingleProwjobAnnotations(t *testing.T) {
 			},
 		},
 		{
-			name: "Full Annotations",
+			name: "Pattern matching no dashboards: fails",
 			initialConfig: config.Configuration{
 				Dashboards: []*config.Dashboard{
-					{Name: "Ouija"},
+					{Name: "sig-storage"},
 				},
 			},
 			prowJobType: prowapi.PostsubmitJob,
 			annotations: map[string]string{
-				"testgrid-dashboards":                "Ouija",
-				"testgrid-tab-name":                  "Planchette",
-				"testgrid-alert-email":               "ghost@example.com",
-				"description":                        "spooky scary",
-				"testgrid-num-columns-recent":        "13",
-				"testgrid-num-failures-to-alert":     "4",
-				"testgrid-alert-stale-results-hours": "24",
+				"testgrid-dashboards": "sig-node-*",
+			},
+			expectError: true,
+		},
+		{
+			name: "Malformed dashboard pattern: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "sig-node-release"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards": "sig-node-[",
+			},
+			expectError: true,
+		},
+		{
+			name: "Add email to multiple dashboards: Two tabs, one email",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Dart"},
+					{Name: "Peg"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":  "Dart, Peg",
+				"testgrid-alert-email": "test@example.com",
 			},
 			expectedConfig: config.Configuration{
 				TestGroups: []*config.TestGroup{
 					{
-						Name:                   ProwJobName,
-						GcsPrefix:              ProwDefaultGCSPath + "logs/" + ProwJobName,
-						NumColumnsRecent:       13,
-						NumFailuresToAlert:     4,
-						AlertStaleResultsHours: 24,
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
 					},
 				},
 				Dashboards: []*config.Dashboard{
 					{
-						Name: "Ouija",
+						Name: "Dart",
 						DashboardTab: []*config.DashboardTab{
 							{
-								Name:          "Planchette",
-								Description:   "spooky scary",
+								Name:          ProwJobName,
+								Description:   ProwJobName,
 								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
 								AlertOptions: &config.DashboardTabAlertOptions{
-									AlertMailToAddresses: "ghost@example.com",
+									AlertMailToAddresses: "test@example.com",
 								},
+							},
+						},
+					},
+					{
+						Name: "Peg",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
 								CodeSearchUrlTemplate: &config.LinkTemplate{
 									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
 								},
@@ -324,29 +539,1326 @@ func Test_applySingleProwjobAnnotations(t *testing.T) {
 				},
 			},
 		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			job := prowConfig.JobBase{
-				Name:        ProwJobName,
-				Annotations: test.annotations,
-			}
-
-			err := applySingleProwjobAnnotations(&test.initialConfig, fakeProwConfig(), job, test.prowJobType, ExampleRepository, nil)
-
-			if test.expectError {
-				if err == nil {
-					t.Error("Expected an error, but got none")
-				}
-			} else {
-				if err != nil {
-					t.Errorf("Unexpected error: %v", err)
-				}
+		{
+			name: "Tab stale results hours: set on every tab, independently of the test group",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Dart"},
+					{Name: "Peg"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":                    "Dart, Peg",
+				"testgrid-alert-email":                   "test@example.com",
+				"testgrid-alert-stale-results-hours":     "12",
+				"testgrid-tab-alert-stale-results-hours": "24",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:                   ProwJobName,
+						GcsPrefix:              ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent:       10,
+						AlertStaleResultsHours: 12,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Dart",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+								AlertOptions: &config.DashboardTabAlertOptions{
+									AlertMailToAddresses:   "test@example.com",
+									AlertStaleResultsHours: 24,
+								},
+							},
+						},
+					},
+					{
+						Name: "Peg",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+								AlertOptions: &config.DashboardTabAlertOptions{
+									AlertStaleResultsHours: 24,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid tab stale results hours: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Dart"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":                    "Dart",
+				"testgrid-tab-alert-stale-results-hours": "a day",
+			},
+			expectError: true,
+		},
+		{
+			name: "Num passes to disable alert: applied to the first tab only",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Dart"},
+					{Name: "Peg"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":                  "Dart, Peg",
+				"testgrid-alert-email":                 "test@example.com",
+				"testgrid-num-passes-to-disable-alert": "2",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Dart",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+								AlertOptions: &config.DashboardTabAlertOptions{
+									AlertMailToAddresses:    "test@example.com",
+									NumPassesToDisableAlert: 2,
+								},
+							},
+						},
+					},
+					{
+						Name: "Peg",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid num passes to disable alert: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Dart"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":                  "Dart",
+				"testgrid-num-passes-to-disable-alert": "a few",
+			},
+			expectError: true,
+		},
+		{
+			name: "Add email to all tabs: Two tabs, two emails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Dart"},
+					{Name: "Peg"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":           "Dart, Peg",
+				"testgrid-alert-email":          "test@example.com",
+				"testgrid-alert-email-all-tabs": "true",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Dart",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+								AlertOptions: &config.DashboardTabAlertOptions{
+									AlertMailToAddresses: "test@example.com",
+								},
+							},
+						},
+					},
+					{
+						Name: "Peg",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+								AlertOptions: &config.DashboardTabAlertOptions{
+									AlertMailToAddresses: "test@example.com",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "Invalid alert email all tabs annotation: fails",
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-create-test-group":    "true",
+				"testgrid-alert-email-all-tabs": "always",
+			},
+			expectError: true,
+		},
+		{
+			name: "Multiple alert emails are kept as given",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Dart"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":  "Dart",
+				"testgrid-alert-email": "test@example.com, other@example.com",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Dart",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+								AlertOptions: &config.DashboardTabAlertOptions{
+									AlertMailToAddresses: "test@example.com, other@example.com",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid alert email: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Dart"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":  "Dart",
+				"testgrid-alert-email": "test@example.com, not-an-email",
+			},
+			expectError: true,
+		},
+		{
+			name: "Add job that already exists: keeps test group, replaces tab",
+			initialConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:      ProwJobName,
+						GcsPrefix: "CustomFoo",
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Surf",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   "RegularHumanDescription",
+								TestGroupName: ProwJobName,
+							},
+						},
+					},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards": "Surf",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:      ProwJobName,
+						GcsPrefix: "CustomFoo",
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Surf",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "Days of results annotation sets the test group history",
+			prowJobType: prowapi.PeriodicJob,
+			annotations: map[string]string{
+				"testgrid-days-of-results": "30",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:          ProwJobName,
+						GcsPrefix:     ProwDefaultGCSPath + "logs/" + ProwJobName,
+						DaysOfResults: 30,
+					},
+				},
+			},
+		},
+		{
+			name:        "Invalid days of results annotation: fails",
+			prowJobType: prowapi.PeriodicJob,
+			annotations: map[string]string{
+				"testgrid-days-of-results": "a month",
+			},
+			expectError: true,
+		},
+		{
+			name:        "Column header annotation sets the test group column headers",
+			prowJobType: prowapi.PeriodicJob,
+			annotations: map[string]string{
+				"testgrid-column-header": "commit, infra-commit",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:      ProwJobName,
+						GcsPrefix: ProwDefaultGCSPath + "logs/" + ProwJobName,
+						ColumnHeader: []*config.TestGroup_ColumnHeader{
+							{ConfigurationValue: "commit"},
+							{ConfigurationValue: "infra-commit"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "Column header annotation with an empty key: fails",
+			prowJobType: prowapi.PeriodicJob,
+			annotations: map[string]string{
+				"testgrid-column-header": "commit,,infra-commit",
+			},
+			expectError: true,
+		},
+		{
+			name: "Full Annotations",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Ouija"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":                "Ouija",
+				"testgrid-tab-name":                  "Planchette",
+				"testgrid-alert-email":               "ghost@example.com",
+				"description":                        "spooky scary",
+				"testgrid-num-columns-recent":        "13",
+				"testgrid-num-failures-to-alert":     "4",
+				"testgrid-alert-stale-results-hours": "24",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:                   ProwJobName,
+						GcsPrefix:              ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent:       13,
+						NumFailuresToAlert:     4,
+						AlertStaleResultsHours: 24,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Ouija",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          "Planchette",
+								Description:   "spooky scary",
+								TestGroupName: ProwJobName,
+								AlertOptions: &config.DashboardTabAlertOptions{
+									AlertMailToAddresses: "ghost@example.com",
+								},
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Set broken column threshold for the tab",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Fragile"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":           "Fragile",
+				"testgrid-tab-broken-threshold": "0.25",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Fragile",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:                  ProwJobName,
+								Description:           ProwJobName,
+								TestGroupName:         ProwJobName,
+								BrokenColumnThreshold: 0.25,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Set results text and URL template for the tab",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Linked"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":           "Linked",
+				"testgrid-results-text":         "Open in Spyglass",
+				"testgrid-results-url-template": "https://prow.example.com/view/gcs/<gcs_prefix>/<changelist>",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Linked",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
+								ResultsText:   "Open in Spyglass",
+								ResultsUrlTemplate: &config.LinkTemplate{
+									Url: "https://prow.example.com/view/gcs/<gcs_prefix>/<changelist>",
+								},
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Mark the tab as release-blocking",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Release"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":       "Release",
+				"testgrid-release-blocking": "true",
+				"description":               "runs the conformance suite",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Release",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   "[release-blocking] runs the conformance suite",
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Base options annotation sets the tab base options",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Wash"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":   "Wash",
+				"testgrid-base-options": "group-by-target=&width=10",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Wash",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+								BaseOptions: "group-by-target=&width=10",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Empty base options annotation: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Wash"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":   "Wash",
+				"testgrid-base-options": "",
+			},
+			expectError: true,
+		},
+		{
+			name:        "Base options without a test group: fails",
+			prowJobType: prowapi.PresubmitJob,
+			annotations: map[string]string{
+				"testgrid-base-options": "width=10",
+			},
+			expectError: true,
+		},
+		{
+			name: "Code search and open bug annotations override the GitHub links",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Wash"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":      "Wash",
+				"testgrid-code-search-url": "https://cs.example.com/compare/<start-custom-0>...<end-custom-0>",
+				"testgrid-open-bug-url":    "https://bugs.example.com/new",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Wash",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://cs.example.com/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://bugs.example.com/new",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Open bug annotation alone keeps the GitHub code search link",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Wash"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":   "Wash",
+				"testgrid-open-bug-url": "https://bugs.example.com/new",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Wash",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName,
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://bugs.example.com/new",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "GCS prefix annotation overrides the computed prefix",
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-gcs-prefix": "custom-bucket/some/path",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        "custom-bucket/some/path",
+						NumColumnsRecent: 10,
+					},
+				},
+			},
+		},
+		{
+			name:        "Empty GCS prefix annotation: fails",
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-gcs-prefix": "",
+			},
+			expectError: true,
+		},
+		{
+			name:        "Misspelled annotation: fails",
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-create-test-grup": "true",
+			},
+			expectError: true,
+		},
+		{
+			name: "Empty code search annotation: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Wash"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":      "Wash",
+				"testgrid-code-search-url": "",
+			},
+			expectError: true,
+		},
+		{
+			name: "Release-blocking annotation set to false leaves the tab untagged",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Release"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":       "Release",
+				"testgrid-release-blocking": "false",
+				"description":               "runs the conformance suite",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Release",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   "runs the conformance suite",
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Non-boolean release-blocking annotation: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Release"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":       "Release",
+				"testgrid-release-blocking": "very",
+			},
+			expectError: true,
+		},
+		{
+			name: "Results text without URL template: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Linked"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":   "Linked",
+				"testgrid-results-text": "Open in Spyglass",
+			},
+			expectError: true,
+		},
+		{
+			name: "Results URL template without text: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Linked"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":           "Linked",
+				"testgrid-results-url-template": "https://prow.example.com/view/gcs/<gcs_prefix>/<changelist>",
+			},
+			expectError: true,
+		},
+		{
+			name: "Broken column threshold above one: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Fragile"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":           "Fragile",
+				"testgrid-tab-broken-threshold": "1.5",
+			},
+			expectError: true,
+		},
+		{
+			name: "Negative broken column threshold: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Fragile"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":           "Fragile",
+				"testgrid-tab-broken-threshold": "-0.1",
+			},
+			expectError: true,
+		},
+		{
+			name: "Existing tab for the job is updated in place",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Wash",
+						DashboardTab: []*config.DashboardTab{
+							{Name: ProwJobName, TestGroupName: ProwJobName, Description: "stale"},
+							{Name: "other", TestGroupName: "other"},
+							{Name: ProwJobName, TestGroupName: "someone-else"},
+						},
+					},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards": "Wash",
+				"description":         "fresh",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Wash",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   "fresh",
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+							},
+							{Name: "other", TestGroupName: "other"},
+							{Name: ProwJobName, TestGroupName: "someone-else"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Description template is rendered",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Templated"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards": "Templated",
+				"description":         "{{.JobName}} {{.JobType}} on {{.Repo}}",
+			},
+			expectedConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{
+						Name:             ProwJobName,
+						GcsPrefix:        ProwDefaultGCSPath + "logs/" + ProwJobName,
+						NumColumnsRecent: 10,
+					},
+				},
+				Dashboards: []*config.Dashboard{
+					{
+						Name: "Templated",
+						DashboardTab: []*config.DashboardTab{
+							{
+								Name:          ProwJobName,
+								Description:   ProwJobName + " postsubmit on " + ExampleRepository,
+								TestGroupName: ProwJobName,
+								CodeSearchUrlTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+								},
+								OpenBugTemplate: &config.LinkTemplate{
+									Url: "https://github.com/test/repo/issues/",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Malformed description template: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Templated"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards": "Templated",
+				"description":         "{{.JobName on {{.Repo}}",
+			},
+			expectError: true,
+		},
+		{
+			name: "Description template with unknown field: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Templated"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards": "Templated",
+				"description":         "{{.Branch}}",
+			},
+			expectError: true,
+		},
+		{
+			name: "Non-numeric broken column threshold: fails",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Fragile"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards":           "Fragile",
+				"testgrid-tab-broken-threshold": "lots",
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			job := prowConfig.JobBase{
+				Name:        ProwJobName,
+				Annotations: test.annotations,
+			}
+
+			_, err := applySingleProwjobAnnotations(&test.initialConfig, fakeProwConfig(), job, test.prowJobType, ExampleRepository, nil, configuratorDefaults{})
+
+			if test.expectError {
+				if err == nil {
+					t.Error("Expected an error, but got none")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+
+				if !reflect.DeepEqual(test.initialConfig, test.expectedConfig) {
+					t.Errorf("Configurations did not match; got %s, expected %s", test.initialConfig.String(), test.expectedConfig.String())
+				}
+			}
+		})
+	}
+}
+
+func Test_applySingleProwjobAnnotations_NumericBounds(t *testing.T) {
+	tests := []struct {
+		annotation  string
+		value       string
+		expectError bool
+	}{
+		{annotation: testgridNumColumnsRecentAnnotation, value: "-1", expectError: true},
+		{annotation: testgridNumColumnsRecentAnnotation, value: "0", expectError: true},
+		{annotation: testgridNumColumnsRecentAnnotation, value: "1"},
+		{annotation: testgridAlertStaleResultsHoursAnnotation, value: "-1", expectError: true},
+		{annotation: testgridAlertStaleResultsHoursAnnotation, value: "0"},
+		{annotation: testgridAlertStaleResultsHoursAnnotation, value: "1"},
+		{annotation: testgridTabAlertStaleResultsHoursAnnotation, value: "-1", expectError: true},
+		{annotation: testgridTabAlertStaleResultsHoursAnnotation, value: "0"},
+		{annotation: testgridTabAlertStaleResultsHoursAnnotation, value: "1"},
+		{annotation: testgridNumFailuresToAlertAnnotation, value: "-1", expectError: true},
+		{annotation: testgridNumFailuresToAlertAnnotation, value: "0"},
+		{annotation: testgridNumFailuresToAlertAnnotation, value: "1"},
+		{annotation: testgridNumPassesToDisableAlertAnnotation, value: "-1", expectError: true},
+		{annotation: testgridNumPassesToDisableAlertAnnotation, value: "0"},
+		{annotation: testgridNumPassesToDisableAlertAnnotation, value: "1"},
+		{annotation: testgridMaxTestMethodsAnnotation, value: "-1", expectError: true},
+		{annotation: testgridMaxTestMethodsAnnotation, value: "0", expectError: true},
+		{annotation: testgridMaxTestMethodsAnnotation, value: "1"},
+		{annotation: testgridMaxTestMethodsAnnotation, value: "many", expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s=%s", test.annotation, test.value), func(t *testing.T) {
+			c := &config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Bounds"},
+				},
+			}
+			job := prowConfig.JobBase{
+				Name: ProwJobName,
+				Annotations: map[string]string{
+					testgridDashboardsAnnotation: "Bounds",
+					test.annotation:              test.value,
+				},
+			}
+
+			_, err := applySingleProwjobAnnotations(c, fakeProwConfig(), job, prowapi.PostsubmitJob, ExampleRepository, nil, configuratorDefaults{})
+			if test.expectError && err == nil {
+				t.Error("Expected an error, but got none")
+			}
+			if !test.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_applySingleProwjobAnnotations_DefaultDashboard(t *testing.T) {
+	catchAllTab := &config.DashboardTab{
+		Name:          ProwJobName,
+		Description:   ProwJobName,
+		TestGroupName: ProwJobName,
+		CodeSearchUrlTemplate: &config.LinkTemplate{
+			Url: "https://github.com/test/repo/compare/<start-custom-0>...<end-custom-0>",
+		},
+		OpenBugTemplate: &config.LinkTemplate{
+			Url: "https://github.com/test/repo/issues/",
+		},
+	}
+	tests := []struct {
+		name               string
+		initialTestGroups  []*config.TestGroup
+		prowJobType        prowapi.ProwJobType
+		annotations        map[string]string
+		defaultDashboard   string
+		expectedCatchAll   []*config.DashboardTab
+		expectedOtherTabs  int
+		expectedTestGroups int
+	}{
+		{
+			name:               "Periodic without dashboards and no default dashboard: test group only",
+			prowJobType:        prowapi.PeriodicJob,
+			expectedTestGroups: 1,
+		},
+		{
+			name:               "Periodic without dashboards: added to the default dashboard",
+			prowJobType:        prowapi.PeriodicJob,
+			defaultDashboard:   "Catch-all",
+			expectedCatchAll:   []*config.DashboardTab{catchAllTab},
+			expectedTestGroups: 1,
+		},
+		{
+			name:               "Job with dashboards: not added to the default dashboard",
+			prowJobType:        prowapi.PeriodicJob,
+			annotations:        map[string]string{"testgrid-dashboards": "Other"},
+			defaultDashboard:   "Catch-all",
+			expectedOtherTabs:  1,
+			expectedTestGroups: 1,
+		},
+		{
+			name:               "Default dashboard excluded: test group only",
+			prowJobType:        prowapi.PeriodicJob,
+			annotations:        map[string]string{"testgrid-dashboards-exclude": "Catch-all"},
+			defaultDashboard:   "Catch-all",
+			expectedTestGroups: 1,
+		},
+		{
+			name:             "Presubmit without a test group: not added to the default dashboard",
+			prowJobType:      prowapi.PresubmitJob,
+			defaultDashboard: "Catch-all",
+		},
+		{
+			name:               "Job reusing an existing test group: not added to the default dashboard",
+			initialTestGroups:  []*config.TestGroup{{Name: ProwJobName}},
+			prowJobType:        prowapi.PeriodicJob,
+			defaultDashboard:   "Catch-all",
+			expectedTestGroups: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &config.Configuration{
+				TestGroups: test.initialTestGroups,
+				Dashboards: []*config.Dashboard{
+					{Name: "Catch-all"},
+					{Name: "Other"},
+				},
+			}
+			job := prowConfig.JobBase{
+				Name:        ProwJobName,
+				Annotations: test.annotations,
+			}
+
+			if _, err := applySingleProwjobAnnotations(c, fakeProwConfig(), job, test.prowJobType, ExampleRepository, nil, configuratorDefaults{DefaultDashboard: test.defaultDashboard}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(c.TestGroups) != test.expectedTestGroups {
+				t.Errorf("Expected %d test groups, got %d: %v", test.expectedTestGroups, len(c.TestGroups), c.TestGroups)
+			}
+			if !reflect.DeepEqual(c.Dashboards[0].DashboardTab, test.expectedCatchAll) {
+				t.Errorf("Default dashboard tabs did not match; got %v, expected %v", c.Dashboards[0].DashboardTab, test.expectedCatchAll)
+			}
+			if len(c.Dashboards[1].DashboardTab) != test.expectedOtherTabs {
+				t.Errorf("Expected %d tabs on the other dashboard, got %d", test.expectedOtherTabs, len(c.Dashboards[1].DashboardTab))
+			}
+		})
+	}
+}
+
+func Test_applySingleProwjobAnnotations_Warnings(t *testing.T) {
+	tests := []struct {
+		name             string
+		initialConfig    config.Configuration
+		prowJobType      prowapi.ProwJobType
+		decorationConfig *prowapi.DecorationConfig
+		annotations      map[string]string
+		expectedWarnings []annotationWarning
+	}{
+		{
+			name:        "Presubmit with no annotations: no warnings",
+			prowJobType: prowapi.PresubmitJob,
+		},
+		{
+			name:        "Postsubmit without GCS configuration: warns about default decoration config",
+			prowJobType: prowapi.PostsubmitJob,
+			expectedWarnings: []annotationWarning{
+				{
+					Job:     ProwJobName,
+					Message: "job has no GCS configuration; using the default decoration config to find its results",
+				},
+			},
+		},
+		{
+			name:        "Postsubmit with GCS configuration: no warnings",
+			prowJobType: prowapi.PostsubmitJob,
+			decorationConfig: &prowapi.DecorationConfig{
+				GCSConfiguration: &prowapi.GCSConfiguration{
+					Bucket:     "bucket",
+					PathPrefix: "prefix",
+				},
+			},
+		},
+		{
+			name:        "Postsubmit with GCS prefix annotation: no warnings",
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-gcs-prefix": "custom-bucket/some/path",
+			},
+		},
+		{
+			name: "Group annotations with create-test-group false: warns about conflicting intent",
+			initialConfig: config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{Name: ProwJobName, GcsPrefix: "CustomFoo"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			annotations: map[string]string{
+				"testgrid-create-test-group":  "false",
+				"testgrid-num-columns-recent": "10",
+			},
+			expectedWarnings: []annotationWarning{
+				{
+					Job:        ProwJobName,
+					Annotation: "testgrid-num-columns-recent",
+					Message:    `annotation configures a test group, but testgrid-create-test-group is "false"`,
+				},
+			},
+		},
+		{
+			name: "Dashboard tab without description: warns about description",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Wash"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			decorationConfig: &prowapi.DecorationConfig{
+				GCSConfiguration: &prowapi.GCSConfiguration{
+					Bucket:     "bucket",
+					PathPrefix: "prefix",
+				},
+			},
+			annotations: map[string]string{
+				"testgrid-dashboards": "Wash",
+			},
+			expectedWarnings: []annotationWarning{
+				{
+					Job:        ProwJobName,
+					Annotation: "description",
+					Message:    "job has no description; using the job name as the tab description",
+				},
+			},
+		},
+		{
+			name: "Dashboard tab with description: no warnings",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Wash"},
+				},
+			},
+			prowJobType: prowapi.PostsubmitJob,
+			decorationConfig: &prowapi.DecorationConfig{
+				GCSConfiguration: &prowapi.GCSConfiguration{
+					Bucket:     "bucket",
+					PathPrefix: "prefix",
+				},
+			},
+			annotations: map[string]string{
+				"testgrid-dashboards": "Wash",
+				"description":         "Tests the washing",
+			},
+		},
+		{
+			name: "Dashboard tab without description or GCS configuration: warns about both",
+			initialConfig: config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "Wash"},
+				},
+			},
+			prowJobType: prowapi.PresubmitJob,
+			annotations: map[string]string{
+				"testgrid-dashboards": "Wash",
+			},
+			expectedWarnings: []annotationWarning{
+				{
+					Job:     ProwJobName,
+					Message: "job has no GCS configuration; using the default decoration config to find its results",
+				},
+				{
+					Job:        ProwJobName,
+					Annotation: "description",
+					Message:    "job has no description; using the job name as the tab description",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			job := prowConfig.JobBase{
+				Name:        ProwJobName,
+				Annotations: test.annotations,
+				UtilityConfig: prowConfig.UtilityConfig{
+					DecorationConfig: test.decorationConfig,
+				},
+			}
+
+			warnings, err := applySingleProwjobAnnotations(&test.initialConfig, fakeProwConfig(), job, test.prowJobType, ExampleRepository, nil, configuratorDefaults{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 
-				if !reflect.DeepEqual(test.initialConfig, test.expectedConfig) {
-					t.Errorf("Configurations did not match; got %s, expected %s", test.initialConfig.String(), test.expectedConfig.String())
-				}
+			if !reflect.DeepEqual(warnings, test.expectedWarnings) {
+				t.Errorf("Warnings did not match; got %v, expected %v", warnings, test.expectedWarnings)
 			}
 		})
 	}
@@ -551,7 +2063,7 @@ func Test_applySingleProwjobAnnotation_WithDefaults(t *testing.T) {
 				Annotations: test.annotations,
 			}
 
-			err := applySingleProwjobAnnotations(test.initialConfig, fakeProwConfig(), job, test.prowJobType, ExampleRepository, defaultConfig)
+			_, err := applySingleProwjobAnnotations(test.initialConfig, fakeProwConfig(), job, test.prowJobType, ExampleRepository, defaultConfig, configuratorDefaults{})
 
 			if test.expectedConfig == nil {
 				if err == nil {
@@ -936,3 +2448,563 @@ func fakeProwConfig() *prowConfig.Config {
 		},
 	}
 }
+
+func Test_sortDashboardTabs(t *testing.T) {
+	c := &config.Configuration{
+		Dashboards: []*config.Dashboard{
+			{
+				Name: "unsorted",
+				DashboardTab: []*config.DashboardTab{
+					{Name: "zeta", TestGroupName: "zeta-job"},
+					{Name: "alpha", TestGroupName: "first-alpha-job"},
+					{Name: "mu", TestGroupName: "mu-job"},
+					{Name: "alpha", TestGroupName: "second-alpha-job"},
+				},
+			},
+			{Name: "empty"},
+		},
+	}
+	expected := &config.Configuration{
+		Dashboards: []*config.Dashboard{
+			{
+				Name: "unsorted",
+				DashboardTab: []*config.DashboardTab{
+					{Name: "alpha", TestGroupName: "first-alpha-job"},
+					{Name: "alpha", TestGroupName: "second-alpha-job"},
+					{Name: "mu", TestGroupName: "mu-job"},
+					{Name: "zeta", TestGroupName: "zeta-job"},
+				},
+			},
+			{Name: "empty"},
+		},
+	}
+
+	sortDashboardTabs(c)
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("Configurations did not match; got %s, expected %s", c.String(), expected.String())
+	}
+}
+
+func Test_aggregateReleaseBlockingTabs(t *testing.T) {
+	blocking := func(name, testGroup string) *config.DashboardTab {
+		return &config.DashboardTab{Name: name, TestGroupName: testGroup, Description: releaseBlockingDescriptionPrefix + name}
+	}
+	informing := func(name, testGroup string) *config.DashboardTab {
+		return &config.DashboardTab{Name: name, TestGroupName: testGroup, Description: name}
+	}
+	blockingGroups := map[string]bool{"unit-group": true, "e2e-group": true, "first-unit-group": true, "second-unit-group": true}
+	tests := []struct {
+		name         string
+		config       *config.Configuration
+		expectedTabs []*config.DashboardTab
+		expectError  bool
+	}{
+		{
+			name: "creates the dashboard with the blocking tabs",
+			config: &config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "first", DashboardTab: []*config.DashboardTab{blocking("unit", "unit-group"), informing("lint", "lint-group")}},
+					{Name: "second", DashboardTab: []*config.DashboardTab{blocking("e2e", "e2e-group")}},
+				},
+			},
+			expectedTabs: []*config.DashboardTab{blocking("unit", "unit-group"), blocking("e2e", "e2e-group")},
+		},
+		{
+			name: "no blocking tabs creates an empty dashboard",
+			config: &config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "first", DashboardTab: []*config.DashboardTab{informing("lint", "lint-group")}},
+				},
+			},
+		},
+		{
+			name: "tab on several dashboards is only copied once",
+			config: &config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "first", DashboardTab: []*config.DashboardTab{blocking("unit", "unit-group")}},
+					{Name: "second", DashboardTab: []*config.DashboardTab{blocking("unit", "unit-group")}},
+				},
+			},
+			expectedTabs: []*config.DashboardTab{blocking("unit", "unit-group")},
+		},
+		{
+			name: "existing dashboard keeps its tabs and gains the missing blocking tabs",
+			config: &config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "release-blocking", DashboardTab: []*config.DashboardTab{blocking("unit", "unit-group")}},
+					{Name: "first", DashboardTab: []*config.DashboardTab{blocking("unit", "unit-group"), blocking("e2e", "e2e-group")}},
+				},
+			},
+			expectedTabs: []*config.DashboardTab{blocking("unit", "unit-group"), blocking("e2e", "e2e-group")},
+		},
+		{
+			name: "tab names taken by another test group are qualified with their dashboard",
+			config: &config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "first", DashboardTab: []*config.DashboardTab{blocking("unit", "first-unit-group")}},
+					{Name: "second", DashboardTab: []*config.DashboardTab{blocking("unit", "second-unit-group")}},
+				},
+			},
+			expectedTabs: []*config.DashboardTab{
+				blocking("unit", "first-unit-group"),
+				{Name: "second - unit", TestGroupName: "second-unit-group", Description: releaseBlockingDescriptionPrefix + "unit"},
+			},
+		},
+		{
+			name: "hand-written description of a job that is not release-blocking is ignored",
+			config: &config.Configuration{
+				Dashboards: []*config.Dashboard{
+					{Name: "first", DashboardTab: []*config.DashboardTab{blocking("unit", "unit-group"), blocking("manual", "manual-group")}},
+				},
+			},
+			expectedTabs: []*config.DashboardTab{blocking("unit", "unit-group")},
+		},
+		{
+			name: "dashboard group with the same name fails",
+			config: &config.Configuration{
+				DashboardGroups: []*config.DashboardGroup{{Name: "release-blocking"}},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := aggregateReleaseBlockingTabs(test.config, "release-blocking", blockingGroups)
+			if test.expectError {
+				if err == nil {
+					t.Fatal("Expected an error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var aggregate *config.Dashboard
+			for _, d := range test.config.Dashboards {
+				if d.Name == "release-blocking" {
+					if aggregate != nil {
+						t.Fatal("Found more than one release-blocking dashboard")
+					}
+					aggregate = d
+				}
+			}
+			if aggregate == nil {
+				t.Fatal("Expected the release-blocking dashboard to exist, but it did not")
+			}
+			if !reflect.DeepEqual(aggregate.DashboardTab, test.expectedTabs) {
+				t.Errorf("Mismatched release-blocking tabs; actual: %v\n expected %v\n", aggregate.DashboardTab, test.expectedTabs)
+			}
+		})
+	}
+}
+
+func Test_aggregateReleaseBlockingTabs_CopiesDeeply(t *testing.T) {
+	original := &config.DashboardTab{
+		Name:          "unit",
+		TestGroupName: "unit-group",
+		Description:   releaseBlockingDescriptionPrefix + "unit",
+		AlertOptions:  &config.DashboardTabAlertOptions{AlertMailToAddresses: "owner@example.com"},
+	}
+	c := &config.Configuration{
+		Dashboards: []*config.Dashboard{
+			{Name: "first", DashboardTab: []*config.DashboardTab{original}},
+		},
+	}
+	if err := aggregateReleaseBlockingTabs(c, "release-blocking", map[string]bool{"unit-group": true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	copied := c.Dashboards[1].DashboardTab[0]
+	copied.AlertOptions.AlertMailToAddresses = "release@example.com"
+	if actual := original.AlertOptions.AlertMailToAddresses; actual != "owner@example.com" {
+		t.Errorf("Changing the copied tab changed the original tab's alert addresses to %q", actual)
+	}
+}
+
+func Test_releaseBlockingTestGroups(t *testing.T) {
+	periodic := func(name string, annotations map[string]string) prowConfig.Periodic {
+		return prowConfig.Periodic{JobBase: prowConfig.JobBase{Name: name, Annotations: annotations}}
+	}
+	pc := &prowConfig.Config{
+		JobConfig: prowConfig.JobConfig{
+			Periodics: []prowConfig.Periodic{
+				periodic("blocking", map[string]string{testgridReleaseBlockingAnnotation: "true"}),
+				periodic("informing", map[string]string{testgridReleaseBlockingAnnotation: "false"}),
+				periodic("unannotated", nil),
+				periodic("disabled", map[string]string{testgridReleaseBlockingAnnotation: "true", testgridDisableAnnotation: "true"}),
+				periodic("disabled-numerically", map[string]string{testgridReleaseBlockingAnnotation: "true", testgridDisableAnnotation: "1"}),
+			},
+		},
+	}
+
+	expected := map[string]bool{"blocking": true}
+	if actual := releaseBlockingTestGroups(pc); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Mismatched release-blocking test groups; actual: %v\n expected %v\n", actual, expected)
+	}
+	if actual := releaseBlockingTestGroups(nil); len(actual) != 0 {
+		t.Errorf("Expected no release-blocking test groups without a prow config, got %v", actual)
+	}
+}
+
+func Test_applyProwjobAnnotations_DuplicateJobNames(t *testing.T) {
+	postsubmit := func(name string, annotations map[string]string, dc *prowapi.DecorationConfig) prowConfig.Postsubmit {
+		return prowConfig.Postsubmit{
+			JobBase: prowConfig.JobBase{
+				Name:        name,
+				Annotations: annotations,
+				UtilityConfig: prowConfig.UtilityConfig{
+					DecorationConfig: dc,
+				},
+			},
+		}
+	}
+	otherBucket := &prowapi.DecorationConfig{
+		GCSConfiguration: &prowapi.GCSConfiguration{
+			Bucket:     "other-bucket",
+			PathPrefix: ProwDefaultGCSPath,
+		},
+	}
+
+	tests := []struct {
+		name        string
+		postsubmits map[string][]prowConfig.Postsubmit
+		expectedErr bool
+	}{
+		{
+			name: "Same job name in different repos with the same GCS prefix: shares test group",
+			postsubmits: map[string][]prowConfig.Postsubmit{
+				"org/a": {postsubmit("same-job", nil, nil)},
+				"org/b": {postsubmit("same-job", nil, nil)},
+			},
+		},
+		{
+			name: "Same job name in different repos with different GCS prefixes: error",
+			postsubmits: map[string][]prowConfig.Postsubmit{
+				"org/a": {postsubmit("same-job", nil, nil)},
+				"org/b": {postsubmit("same-job", nil, otherBucket)},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "Same job name with different GCS prefixes, but one is disabled: no error",
+			postsubmits: map[string][]prowConfig.Postsubmit{
+				"org/a": {postsubmit("same-job", nil, nil)},
+				"org/b": {postsubmit("same-job", map[string]string{testgridDisableAnnotation: "true"}, otherBucket)},
+			},
+		},
+		{
+			name: "Same job name with different GCS prefixes, but one is disabled with a capitalized boolean: no error",
+			postsubmits: map[string][]prowConfig.Postsubmit{
+				"org/a": {postsubmit("same-job", nil, nil)},
+				"org/b": {postsubmit("same-job", map[string]string{testgridDisableAnnotation: "True"}, otherBucket)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pc := fakeProwConfig()
+			pc.JobConfig.PostsubmitsStatic = test.postsubmits
+			agent := &prowConfig.Agent{}
+			agent.Set(pc)
+
+			c := &config.Configuration{}
+			_, err := applyProwjobAnnotations(c, nil, configuratorDefaults{}, agent)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("Expected an error, but didn't get one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(c.TestGroups) != 1 {
+				t.Errorf("Expected exactly one test group, got %d: %v", len(c.TestGroups), c.TestGroups)
+			}
+		})
+	}
+}
+
+func Test_validateAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expectError bool
+	}{
+		{
+			name: "No annotations",
+		},
+		{
+			name: "Recognized annotations",
+			annotations: map[string]string{
+				"testgrid-dashboards":        "Wash",
+				"testgrid-create-test-group": "true",
+				"testgrid-gcs-prefix":        "bucket/path",
+			},
+		},
+		{
+			name: "Annotations for other tools are ignored",
+			annotations: map[string]string{
+				"description":      "Words about the job",
+				"fork-per-release": "true",
+			},
+		},
+		{
+			name: "Misspelled testgrid annotation",
+			annotations: map[string]string{
+				"testgrid-dashboards":       "Wash",
+				"testgrid-create-test-grup": "true",
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateAnnotations(prowConfig.JobBase{Name: ProwJobName, Annotations: test.annotations})
+			if test.expectError && err == nil {
+				t.Error("Expected an error, but got none")
+			}
+			if !test.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_numColumnsRecentFloor(t *testing.T) {
+	floor := func(ncr int32) *int32 {
+		return &ncr
+	}
+
+	tests := []struct {
+		name     string
+		jobType  prowapi.ProwJobType
+		defaults configuratorDefaults
+		expected int32
+	}{
+		{
+			name:     "Presubmit without overrides: hardcoded floor",
+			jobType:  prowapi.PresubmitJob,
+			expected: minPresubmitNumColumnsRecent,
+		},
+		{
+			name:     "Postsubmit without overrides: hardcoded floor",
+			jobType:  prowapi.PostsubmitJob,
+			expected: minPostsubmitNumColumnsRecent,
+		},
+		{
+			name:    "Periodic: no floor",
+			jobType: prowapi.PeriodicJob,
+			defaults: configuratorDefaults{
+				MinPresubmitNumColumnsRecent:  floor(50),
+				MinPostsubmitNumColumnsRecent: floor(50),
+			},
+			expected: 0,
+		},
+		{
+			name:     "Presubmit override: lowers the floor",
+			jobType:  prowapi.PresubmitJob,
+			defaults: configuratorDefaults{MinPresubmitNumColumnsRecent: floor(5)},
+			expected: 5,
+		},
+		{
+			name:     "Postsubmit override: raises the floor",
+			jobType:  prowapi.PostsubmitJob,
+			defaults: configuratorDefaults{MinPostsubmitNumColumnsRecent: floor(30)},
+			expected: 30,
+		},
+		{
+			name:     "Presubmit ignores the postsubmit override",
+			jobType:  prowapi.PresubmitJob,
+			defaults: configuratorDefaults{MinPostsubmitNumColumnsRecent: floor(30)},
+			expected: minPresubmitNumColumnsRecent,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := numColumnsRecentFloor(test.jobType, test.defaults); actual != test.expected {
+				t.Errorf("Expected floor %d, got %d", test.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_applySingleProwjobAnnotations_NumColumnsRecentFloor(t *testing.T) {
+	floor := func(ncr int32) *int32 {
+		return &ncr
+	}
+
+	tests := []struct {
+		name          string
+		initialConfig *config.Configuration
+		jobType       prowapi.ProwJobType
+		annotations   map[string]string
+		defaults      configuratorDefaults
+		expected      int32
+	}{
+		{
+			name:    "Created presubmit group: lowered floor",
+			jobType: prowapi.PresubmitJob,
+			annotations: map[string]string{
+				"testgrid-create-test-group": "true",
+			},
+			defaults: configuratorDefaults{MinPresubmitNumColumnsRecent: floor(5)},
+			expected: 5,
+		},
+		{
+			name:     "Created postsubmit group: raised floor",
+			jobType:  prowapi.PostsubmitJob,
+			defaults: configuratorDefaults{MinPostsubmitNumColumnsRecent: floor(30)},
+			expected: 30,
+		},
+		{
+			name: "Hand-authored postsubmit group: not raised",
+			initialConfig: &config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{Name: ProwJobName, NumColumnsRecent: 3},
+				},
+			},
+			jobType:  prowapi.PostsubmitJob,
+			defaults: configuratorDefaults{MinPostsubmitNumColumnsRecent: floor(30)},
+			expected: 3,
+		},
+		{
+			name: "Hand-authored presubmit group: raised to the presubmit floor",
+			initialConfig: &config.Configuration{
+				TestGroups: []*config.TestGroup{
+					{Name: ProwJobName, NumColumnsRecent: 3},
+				},
+			},
+			jobType:  prowapi.PresubmitJob,
+			expected: minPresubmitNumColumnsRecent,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.initialConfig == nil {
+				test.initialConfig = &config.Configuration{}
+			}
+
+			job := prowConfig.JobBase{
+				Name:        ProwJobName,
+				Annotations: test.annotations,
+			}
+
+			if _, err := applySingleProwjobAnnotations(test.initialConfig, fakeProwConfig(), job, test.jobType, ExampleRepository, nil, test.defaults); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(test.initialConfig.TestGroups) != 1 {
+				t.Fatalf("Expected one test group, got %d", len(test.initialConfig.TestGroups))
+			}
+			if tg := test.initialConfig.TestGroups[0]; tg.NumColumnsRecent != test.expected {
+				t.Errorf("Expected num_columns_recent %d, got %d", test.expected, tg.NumColumnsRecent)
+			}
+		})
+	}
+}
+
+// generateJobs returns a configuration with a few dashboards, and n jobs adding tabs to them.
+// Every tenth job reuses the name of the job before it, as branch variants often do.
+func generateJobs(n int) (*config.Configuration, []prowJob) {
+	c := &config.Configuration{
+		TestGroups: []*config.TestGroup{
+			{Name: "job-0", GcsPrefix: "CustomFoo"},
+		},
+	}
+	for i := 0; i < 10; i++ {
+		c.Dashboards = append(c.Dashboards, &config.Dashboard{Name: fmt.Sprintf("dashboard-%d", i)})
+	}
+
+	var jobs []prowJob
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("job-%d", i)
+		if i%10 == 9 {
+			name = fmt.Sprintf("job-%d", i-1)
+		}
+		jobType := prowapi.PostsubmitJob
+		if i%3 == 0 {
+			jobType = prowapi.PeriodicJob
+		}
+		jobs = append(jobs, prowJob{
+			base: prowConfig.JobBase{
+				Name: name,
+				Annotations: map[string]string{
+					"testgrid-dashboards":            fmt.Sprintf("dashboard-%d, dashboard-%d", i%10, (i+1)%10),
+					"testgrid-alert-email":           "me@example.com",
+					"testgrid-num-failures-to-alert": "3",
+					"description":                    fmt.Sprintf("job number %d", i),
+				},
+			},
+			jobType: jobType,
+			repo:    ExampleRepository,
+		})
+	}
+	return c, jobs
+}
+
+func Test_applyJobAnnotations_MatchesSerial(t *testing.T) {
+	expected, jobs := generateJobs(200)
+	var expectedWarnings []annotationWarning
+	for _, j := range jobs {
+		warnings, err := applySingleProwjobAnnotations(expected, fakeProwConfig(), j.base, j.jobType, j.repo, nil, configuratorDefaults{})
+		if err != nil {
+			t.Fatalf("Unexpected error applying jobs serially: %v", err)
+		}
+		expectedWarnings = append(expectedWarnings, warnings...)
+	}
+
+	for _, workers := range []int{1, 4, 16} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			actual, jobs := generateJobs(200)
+			warnings, err := applyJobAnnotations(actual, nil, configuratorDefaults{}, fakeProwConfig(), jobs, workers)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("Configurations did not match; got %s, expected %s", actual.String(), expected.String())
+			}
+			if !reflect.DeepEqual(warnings, expectedWarnings) {
+				t.Errorf("Warnings did not match; got %v, expected %v", warnings, expectedWarnings)
+			}
+		})
+	}
+}
+
+func Test_applyJobAnnotations_Idempotent(t *testing.T) {
+	expected, jobs := generateJobs(200)
+	if _, err := applyJobAnnotations(expected, nil, configuratorDefaults{}, fakeProwConfig(), jobs, 4); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	actual, jobs := generateJobs(200)
+	for run := 0; run < 2; run++ {
+		if _, err := applyJobAnnotations(actual, nil, configuratorDefaults{}, fakeProwConfig(), jobs, 4); err != nil {
+			t.Fatalf("Unexpected error in run %d: %v", run, err)
+		}
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Configurations did not match; got %s, expected %s", actual.String(), expected.String())
+	}
+}
+
+func Benchmark_applyJobAnnotations(b *testing.B) {
+	for _, workers := range []int{1, runtime.GOMAXPROCS(0)} {
+		b.Run(fmt.Sprintf("%d workers", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				c, jobs := generateJobs(20000)
+				pc := fakeProwConfig()
+				b.StartTimer()
+				if _, err := applyJobAnnotations(c, nil, configuratorDefaults{}, pc, jobs, workers); err != nil {
+					b.Fatalf("Unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}